@@ -0,0 +1,1072 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// Symbolic algebra for the symbolic tool. Expressions are trees of sym
+// nodes kept in a canonical form by the constructors symAdd, symMul, symPow
+// and symCall: sums and products are flattened, numbers folded exactly,
+// like terms and like factors collected, and operands sorted. Two
+// expressions that are equal after this normalization print identically.
+
+const (
+	// maxSymbolicTerms bounds the terms of a sum, so that expanding a large
+	// power cannot exhaust memory.
+	maxSymbolicTerms = 5000
+	// maxSymbolicSize bounds the nodes of an expression, counting shared
+	// subexpressions once per use, which is what printing and comparing
+	// it costs. Repeated derivatives of products of powers grow this way
+	// while their sums stay short.
+	maxSymbolicSize = 50_000
+	// maxExpandExponent bounds the powers of sums that expand multiplies out.
+	maxExpandExponent = 100
+	// maxRootIndex bounds the roots taken exactly, as in 8^(1/3) = 2.
+	maxRootIndex = 64
+	// maxRootCandidate bounds the coefficients whose divisors factor tries
+	// as rational roots.
+	maxRootCandidate = 1 << 40
+	// maxSurdFactor bounds the trial divisors used to take perfect powers
+	// out of a root.
+	maxSurdFactor = 10_000
+)
+
+type symKind int
+
+const (
+	symNumber symKind = iota
+	symVariable
+	symSum
+	symProduct
+	symPower
+	symFunction
+)
+
+// sym is a node of a symbolic expression. Subtraction is represented as
+// addition of -1 times a term, and division as multiplication by a power
+// with exponent -1. Nodes are never modified once built.
+type sym struct {
+	kind symKind
+	num  *big.Rat // symNumber
+	name string   // symVariable, symFunction
+	args []*sym   // terms, factors, base and exponent, or call arguments
+	size int      // nodes in the tree, this one included
+}
+
+// compound returns the node of the given kind with operands args. Every
+// sum, product, power and call is built here, so an expression that grows
+// past maxSymbolicSize fails at the step that grows it.
+func compound(kind symKind, name string, args []*sym) *sym {
+	size := 1
+	for _, arg := range args {
+		size += arg.size
+	}
+	if size > maxSymbolicSize {
+		symFail("result would have more than %d nodes", maxSymbolicSize)
+	}
+	return &sym{kind: kind, name: name, args: args, size: size}
+}
+
+// symbolicError carries an error out of the recursive algebra routines,
+// which fail by panicking with it; catchSymbolic recovers it at the tool
+// boundary.
+type symbolicError struct{ err error }
+
+func symFail(format string, args ...interface{}) {
+	panic(symbolicError{fmt.Errorf(format, args...)})
+}
+
+// catchSymbolic turns a panic raised by symFail into *err.
+func catchSymbolic(err *error) {
+	if r := recover(); r != nil {
+		se, ok := r.(symbolicError)
+		if !ok {
+			panic(r)
+		}
+		*err = se.err
+	}
+}
+
+func symRat(r *big.Rat) *sym {
+	return &sym{kind: symNumber, num: r, size: 1}
+}
+
+func symInt(n int64) *sym {
+	return symRat(big.NewRat(n, 1))
+}
+
+func symVar(name string) *sym {
+	return &sym{kind: symVariable, name: name, size: 1}
+}
+
+func (s *sym) isNumber() bool {
+	return s.kind == symNumber
+}
+
+// isInt reports whether s is the number n.
+func (s *sym) isInt(n int64) bool {
+	return s.kind == symNumber && s.num.IsInt() && s.num.Num().IsInt64() && s.num.Num().Int64() == n
+}
+
+// contains reports whether the variable x occurs in s.
+func (s *sym) contains(x string) bool {
+	if s.kind == symVariable {
+		return s.name == x
+	}
+	for _, arg := range s.args {
+		if arg.contains(x) {
+			return true
+		}
+	}
+	return false
+}
+
+// variables returns the free variables of s in sorted order. Named
+// constants such as pi are not variables.
+func (s *sym) variables() []string {
+	seen := map[string]bool{}
+	var walk func(s *sym)
+	walk = func(s *sym) {
+		if s.kind == symVariable {
+			if _, constant := mathConstants[s.name]; !constant {
+				seen[s.name] = true
+			}
+		}
+		for _, arg := range s.args {
+			walk(arg)
+		}
+	}
+	walk(s)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// splitCoefficient splits a term into its numeric coefficient and the rest.
+func splitCoefficient(t *sym) (*big.Rat, *sym) {
+	if t.kind == symNumber {
+		return t.num, symInt(1)
+	}
+	if t.kind == symProduct && t.args[0].isNumber() {
+		if len(t.args) == 2 {
+			return t.args[0].num, t.args[1]
+		}
+		return t.args[0].num, compound(symProduct, "", t.args[1:])
+	}
+	return big.NewRat(1, 1), t
+}
+
+// scaleTerm returns c times rest, where rest has no coefficient of its own.
+func scaleTerm(c *big.Rat, rest *sym) *sym {
+	switch {
+	case c.Cmp(big.NewRat(1, 1)) == 0:
+		return rest
+	case rest.isInt(1):
+		return symRat(c)
+	case rest.kind == symProduct:
+		return compound(symProduct, "", append([]*sym{symRat(c)}, rest.args...))
+	}
+	return compound(symProduct, "", []*sym{symRat(c), rest})
+}
+
+// symAdd returns the canonical sum of terms: nested sums are flattened,
+// numbers added up and like terms collected, as in x + 2*x = 3*x.
+func symAdd(terms ...*sym) *sym {
+	constant := new(big.Rat)
+	type like struct {
+		coeff *big.Rat
+		rest  *sym
+	}
+	groups := map[string]*like{}
+	var order []string
+	var add func(t *sym)
+	add = func(t *sym) {
+		switch t.kind {
+		case symNumber:
+			constant.Add(constant, t.num)
+			return
+		case symSum:
+			for _, u := range t.args {
+				add(u)
+			}
+			return
+		}
+		coeff, rest := splitCoefficient(t)
+		key := rest.String()
+		if g, ok := groups[key]; ok {
+			g.coeff.Add(g.coeff, coeff)
+			return
+		}
+		groups[key] = &like{new(big.Rat).Set(coeff), rest}
+		order = append(order, key)
+	}
+	for _, t := range terms {
+		add(t)
+	}
+
+	var out []*sym
+	for _, key := range order {
+		if g := groups[key]; g.coeff.Sign() != 0 {
+			out = append(out, scaleTerm(g.coeff, g.rest))
+		}
+	}
+	if len(out) > maxSymbolicTerms {
+		symFail("result would have more than %d terms", maxSymbolicTerms)
+	}
+	sortTerms(out)
+	if constant.Sign() != 0 {
+		out = append(out, symRat(constant))
+	}
+	switch len(out) {
+	case 0:
+		return symInt(0)
+	case 1:
+		return out[0]
+	}
+	return compound(symSum, "", out)
+}
+
+// symMul returns the canonical product of factors: nested products are
+// flattened, numbers multiplied and powers of the same base combined, as in
+// x * x^2 = x^3. A number times a single sum is distributed, so that like
+// terms can be collected: 2*(x + 1) = 2*x + 2.
+func symMul(factors ...*sym) *sym {
+	coeff := big.NewRat(1, 1)
+	type power struct {
+		base *sym
+		exps []*sym
+	}
+	groups := map[string]*power{}
+	var order []string
+	var mul func(f *sym)
+	mul = func(f *sym) {
+		switch f.kind {
+		case symNumber:
+			coeff.Mul(coeff, f.num)
+			return
+		case symProduct:
+			for _, g := range f.args {
+				mul(g)
+			}
+			return
+		}
+		base, exp := f, symInt(1)
+		if f.kind == symPower {
+			base, exp = f.args[0], f.args[1]
+		}
+		key := base.String()
+		if g, ok := groups[key]; ok {
+			g.exps = append(g.exps, exp)
+			return
+		}
+		groups[key] = &power{base, []*sym{exp}}
+		order = append(order, key)
+	}
+	for _, f := range factors {
+		mul(f)
+	}
+	if coeff.Sign() == 0 {
+		return symInt(0)
+	}
+
+	var out []*sym
+	split := false
+	for _, key := range order {
+		g := groups[key]
+		p := symPow(g.base, symAdd(g.exps...))
+		switch p.kind {
+		case symNumber:
+			coeff.Mul(coeff, p.num)
+		case symProduct:
+			// A power of a product, such as (x*y)^(1/2) squared, may share
+			// bases with other factors, so the product is normalized again.
+			split = true
+			out = append(out, p.args...)
+		default:
+			out = append(out, p)
+		}
+	}
+	if coeff.Sign() == 0 {
+		return symInt(0)
+	}
+	if split {
+		return symMul(append(out, symRat(coeff))...)
+	}
+	sortFactors(out)
+
+	one := coeff.Cmp(big.NewRat(1, 1)) == 0
+	switch {
+	case len(out) == 0:
+		return symRat(coeff)
+	case len(out) == 1 && one:
+		return out[0]
+	case len(out) == 1 && out[0].kind == symSum:
+		terms := make([]*sym, len(out[0].args))
+		for i, t := range out[0].args {
+			terms[i] = symMul(symRat(coeff), t)
+		}
+		return symAdd(terms...)
+	case !one:
+		out = append([]*sym{symRat(coeff)}, out...)
+	}
+	return compound(symProduct, "", out)
+}
+
+// symPow returns the canonical power base^exp. Numeric powers are computed
+// exactly when the result is rational; integer powers of products and of
+// powers are distributed, as in (2*x^3)^2 = 4*x^6.
+func symPow(base, exp *sym) *sym {
+	if exp.isInt(0) {
+		return symInt(1)
+	}
+	if exp.isInt(1) {
+		return base
+	}
+	integer := exp.isNumber() && exp.num.IsInt()
+	switch base.kind {
+	case symNumber:
+		if base.num.Sign() == 0 && exp.isNumber() {
+			if exp.num.Sign() < 0 {
+				symFail("division by zero")
+			}
+			return symInt(0)
+		}
+		if base.isInt(1) {
+			return base
+		}
+		if exp.isNumber() {
+			if r, ok := rationalPower(base.num, exp.num); ok {
+				return symRat(r)
+			}
+			if k, rest, ok := extractRoot(base.num, exp.num); ok {
+				return symMul(symRat(k), compound(symPower, "", []*sym{symRat(rest), exp}))
+			}
+		}
+	case symPower:
+		// (a^b)^c = a^(b*c) holds for every integer c.
+		if integer {
+			return symPow(base.args[0], symMul(base.args[1], exp))
+		}
+	case symProduct:
+		if integer {
+			factors := make([]*sym, len(base.args))
+			for i, f := range base.args {
+				factors[i] = symPow(f, exp)
+			}
+			return symMul(factors...)
+		}
+	}
+	return compound(symPower, "", []*sym{base, exp})
+}
+
+// rationalPower returns b^e when it is rational, taking real roots: a
+// negative base has a root only for an odd index.
+func rationalPower(b, e *big.Rat) (*big.Rat, bool) {
+	if e.IsInt() {
+		r, err := ratPow(b, e)
+		if err != nil {
+			symFail("%v", err)
+		}
+		return r, true
+	}
+	q := e.Denom()
+	if !q.IsInt64() || q.Int64() > maxRootIndex || b.Sign() < 0 && q.Bit(0) == 0 {
+		return nil, false
+	}
+	n := q.Int64()
+	num, ok := integerRoot(new(big.Int).Abs(b.Num()), n)
+	if !ok {
+		return nil, false
+	}
+	den, ok := integerRoot(b.Denom(), n)
+	if !ok {
+		return nil, false
+	}
+	if b.Sign() < 0 {
+		num.Neg(num)
+	}
+	r, err := ratPow(new(big.Rat).SetFrac(num, den), new(big.Rat).SetInt(e.Num()))
+	if err != nil {
+		symFail("%v", err)
+	}
+	return r, true
+}
+
+// extractRoot writes b^e for 0 < e < 1 as k * n^e with k rational and n a
+// whole number, clearing the denominator of b and taking out the perfect
+// powers of n up to maxSurdFactor, so that sqrt(12) becomes 2*sqrt(3) and
+// sqrt(1/2) becomes sqrt(2)/2. A negative base needs an odd root index.
+func extractRoot(b, e *big.Rat) (k, rest *big.Rat, ok bool) {
+	if b.Sign() == 0 || e.Sign() <= 0 || e.Cmp(big.NewRat(1, 1)) >= 0 ||
+		!e.Denom().IsInt64() || e.Denom().Int64() > maxRootIndex {
+		return nil, nil, false
+	}
+	index := e.Denom()
+	if b.Sign() < 0 && index.Bit(0) == 0 {
+		return nil, nil, false
+	}
+	if int64(b.Denom().BitLen())*index.Int64() > maxPowerBits {
+		return nil, nil, false
+	}
+	// a/d = a*d^(q-1) / d^q.
+	d := b.Denom()
+	n := new(big.Int).Exp(d, new(big.Int).Sub(index, big.NewInt(1)), nil)
+	n.Mul(n, new(big.Int).Abs(b.Num()))
+	root := big.NewInt(1)
+	for f := int64(2); f <= maxSurdFactor; f++ {
+		power := new(big.Int).Exp(big.NewInt(f), index, nil)
+		if power.Cmp(n) > 0 {
+			break
+		}
+		for new(big.Int).Mod(n, power).Sign() == 0 {
+			n.Quo(n, power)
+			root.Mul(root, big.NewInt(f))
+		}
+	}
+	if root.Cmp(big.NewInt(1)) == 0 && b.IsInt() && b.Sign() > 0 {
+		return nil, nil, false
+	}
+	k, err := ratPow(new(big.Rat).SetFrac(root, d), new(big.Rat).SetInt(e.Num()))
+	if err != nil {
+		symFail("%v", err)
+	}
+	// (-x)^(p/q) = (-1)^p * x^(p/q) for odd q.
+	if b.Sign() < 0 && e.Num().Bit(0) == 1 {
+		k.Neg(k)
+	}
+	return k, new(big.Rat).SetInt(n), true
+}
+
+// integerRoot returns the nth root of x ≥ 0 if it is a whole number.
+func integerRoot(x *big.Int, n int64) (*big.Int, bool) {
+	if x.BitLen() > maxPowerBits {
+		return nil, false
+	}
+	// Binary search for the largest r with r^n ≤ x.
+	lo, hi := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(x.BitLen()/int(n)+1))
+	exp := big.NewInt(n)
+	for lo.Cmp(hi) < 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Add(mid, big.NewInt(1)).Rsh(mid, 1)
+		if new(big.Int).Exp(mid, exp, nil).Cmp(x) <= 0 {
+			lo = mid
+		} else {
+			hi = mid.Sub(mid, big.NewInt(1))
+		}
+	}
+	return lo, new(big.Int).Exp(lo, exp, nil).Cmp(x) == 0
+}
+
+// inverseTrigValues holds asin and acos at rational arguments where they
+// are rational multiples of pi.
+var inverseTrigValues = map[string]map[string]*big.Rat{
+	"asin": {"-1": big.NewRat(-1, 2), "-1/2": big.NewRat(-1, 6), "0": new(big.Rat), "1/2": big.NewRat(1, 6), "1": big.NewRat(1, 2)},
+	"acos": {"-1": big.NewRat(1, 1), "-1/2": big.NewRat(2, 3), "0": big.NewRat(1, 2), "1/2": big.NewRat(1, 3), "1": new(big.Rat)},
+}
+
+// symCall returns the canonical call of a function of the evaluate tool.
+// sqrt, cbrt, pow and hypot become powers; other functions are kept as
+// calls, evaluated only where the value is exact, such as sin(0) = 0.
+func symCall(name string, args ...*sym) *sym {
+	f, ok := exprFunctions[name]
+	if !ok {
+		symFail("unknown function %q", name)
+	}
+	if f.arity >= 0 && len(args) != f.arity {
+		symFail("%s expects %d argument(s), got %d", name, f.arity, len(args))
+	}
+	if f.arity < 0 && len(args) == 0 {
+		symFail("%s expects at least one argument", name)
+	}
+	call := compound(symFunction, name, args)
+	u := args[0]
+	switch name {
+	case "sqrt":
+		return symPow(u, symRat(big.NewRat(1, 2)))
+	case "cbrt":
+		return symPow(u, symRat(big.NewRat(1, 3)))
+	case "pow":
+		return symPow(u, args[1])
+	case "hypot":
+		return symPow(symAdd(symPow(u, symInt(2)), symPow(args[1], symInt(2))), symRat(big.NewRat(1, 2)))
+	case "exp":
+		if u.isInt(0) {
+			return symInt(1)
+		}
+		if u.kind == symFunction && u.name == "ln" {
+			return u.args[0]
+		}
+	case "ln":
+		switch {
+		case u.isInt(1):
+			return symInt(0)
+		case u.kind == symVariable && u.name == "e":
+			return symInt(1)
+		case u.kind == symFunction && u.name == "exp":
+			return u.args[0]
+		case u.kind == symPower && u.args[0].kind == symVariable && u.args[0].name == "e":
+			return u.args[1]
+		}
+	case "log", "log2":
+		if u.isInt(1) {
+			return symInt(0)
+		}
+	case "asin", "acos":
+		if u.isNumber() {
+			if k, ok := inverseTrigValues[name][u.num.RatString()]; ok {
+				return symMul(symRat(k), symVar("pi"))
+			}
+		}
+	case "sin", "tan", "atan", "sinh", "tanh":
+		if u.isInt(0) {
+			return symInt(0)
+		}
+	case "cos", "cosh":
+		if u.isInt(0) {
+			return symInt(1)
+		}
+	case "abs":
+		if u.isNumber() {
+			return symRat(new(big.Rat).Abs(u.num))
+		}
+		if u.kind == symFunction && u.name == "abs" {
+			return u
+		}
+	case "floor", "ceil", "round":
+		if u.isNumber() {
+			return symRat(new(big.Rat).SetInt(roundRat(u.num, 0, map[string]string{"floor": "floor", "ceil": "ceiling", "round": "half-up"}[name])))
+		}
+	case "min", "max":
+		for _, arg := range args {
+			if !arg.isNumber() {
+				return call
+			}
+		}
+		best := args[0]
+		for _, arg := range args[1:] {
+			if c := arg.num.Cmp(best.num); name == "min" && c < 0 || name == "max" && c > 0 {
+				best = arg
+			}
+		}
+		return best
+	}
+	return call
+}
+
+// substitute replaces the variables named in values and renormalizes.
+func substitute(s *sym, values map[string]*sym) *sym {
+	switch s.kind {
+	case symNumber:
+		return s
+	case symVariable:
+		if v, ok := values[s.name]; ok {
+			return v
+		}
+		return s
+	}
+	args := make([]*sym, len(s.args))
+	for i, arg := range s.args {
+		args[i] = substitute(arg, values)
+	}
+	return rebuild(s, args)
+}
+
+// rebuild returns a node of the same kind as s with the given operands,
+// normalized.
+func rebuild(s *sym, args []*sym) *sym {
+	switch s.kind {
+	case symSum:
+		return symAdd(args...)
+	case symProduct:
+		return symMul(args...)
+	case symPower:
+		return symPow(args[0], args[1])
+	case symFunction:
+		return symCall(s.name, args...)
+	}
+	return s
+}
+
+// expand multiplies out products of sums and positive integer powers of
+// sums, down to the arguments of functions.
+func expand(s *sym) *sym {
+	switch s.kind {
+	case symSum:
+		terms := make([]*sym, len(s.args))
+		for i, t := range s.args {
+			terms[i] = expand(t)
+		}
+		return symAdd(terms...)
+	case symProduct:
+		product := []*sym{symInt(1)}
+		for _, f := range s.args {
+			product = distribute(product, termsOf(expand(f)))
+		}
+		return symAdd(product...)
+	case symPower:
+		base, exp := expand(s.args[0]), expand(s.args[1])
+		if base.kind != symSum || !exp.isNumber() || !exp.num.IsInt() || exp.num.Sign() < 0 {
+			return symPow(base, exp)
+		}
+		if exp.num.Cmp(big.NewRat(maxExpandExponent, 1)) > 0 {
+			symFail("cannot expand a sum to a power above %d", maxExpandExponent)
+		}
+		product := []*sym{symInt(1)}
+		for range exp.num.Num().Int64() {
+			product = distribute(product, base.args)
+		}
+		return symAdd(product...)
+	case symFunction:
+		args := make([]*sym, len(s.args))
+		for i, arg := range s.args {
+			args[i] = expand(arg)
+		}
+		return symCall(s.name, args...)
+	}
+	return s
+}
+
+// termsOf returns the terms of a sum, or s itself as the only term.
+func termsOf(s *sym) []*sym {
+	if s.kind == symSum {
+		return s.args
+	}
+	return []*sym{s}
+}
+
+// distribute multiplies every term of a by every term of b and collects
+// like terms.
+func distribute(a, b []*sym) []*sym {
+	if len(a)*len(b) > maxSymbolicTerms*maxSymbolicTerms/100 {
+		symFail("expansion would have more than %d terms", maxSymbolicTerms)
+	}
+	products := make([]*sym, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			products = append(products, symMul(x, y))
+		}
+	}
+	return termsOf(symAdd(products...))
+}
+
+// differentiate returns the derivative of s with respect to x.
+func differentiate(s *sym, x string) *sym {
+	if !s.contains(x) {
+		return symInt(0)
+	}
+	switch s.kind {
+	case symVariable:
+		return symInt(1)
+	case symSum:
+		terms := make([]*sym, len(s.args))
+		for i, t := range s.args {
+			terms[i] = differentiate(t, x)
+		}
+		return symAdd(terms...)
+	case symProduct:
+		// The product rule: differentiate one factor at a time.
+		var terms []*sym
+		for i, f := range s.args {
+			if !f.contains(x) {
+				continue
+			}
+			factors := slices.Clone(s.args)
+			factors[i] = differentiate(f, x)
+			terms = append(terms, symMul(factors...))
+		}
+		return symAdd(terms...)
+	case symPower:
+		base, exp := s.args[0], s.args[1]
+		switch {
+		case !exp.contains(x):
+			// d/dx u^n = n*u^(n-1)*u'
+			return symMul(exp, symPow(base, symAdd(exp, symInt(-1))), differentiate(base, x))
+		case !base.contains(x):
+			// d/dx a^v = a^v*ln(a)*v'
+			return symMul(s, symCall("ln", base), differentiate(exp, x))
+		}
+		// d/dx u^v = u^v*(v'*ln(u) + v*u'/u)
+		return symMul(s, symAdd(
+			symMul(differentiate(exp, x), symCall("ln", base)),
+			symMul(exp, differentiate(base, x), symPow(base, symInt(-1)))))
+	case symFunction:
+		return differentiateCall(s, x)
+	}
+	return symInt(0)
+}
+
+// differentiateCall applies the chain rule to a function call.
+func differentiateCall(s *sym, x string) *sym {
+	u := s.args[0]
+	half := symRat(big.NewRat(1, 2))
+	var outer *sym
+	switch s.name {
+	case "sin":
+		outer = symCall("cos", u)
+	case "cos":
+		outer = symMul(symInt(-1), symCall("sin", u))
+	case "tan":
+		outer = symPow(symCall("cos", u), symInt(-2))
+	case "asin":
+		outer = symPow(symAdd(symInt(1), symMul(symInt(-1), symPow(u, symInt(2)))), symMul(symInt(-1), half))
+	case "acos":
+		outer = symMul(symInt(-1), symPow(symAdd(symInt(1), symMul(symInt(-1), symPow(u, symInt(2)))), symMul(symInt(-1), half)))
+	case "atan":
+		outer = symPow(symAdd(symInt(1), symPow(u, symInt(2))), symInt(-1))
+	case "sinh":
+		outer = symCall("cosh", u)
+	case "cosh":
+		outer = symCall("sinh", u)
+	case "tanh":
+		outer = symAdd(symInt(1), symMul(symInt(-1), symPow(s, symInt(2))))
+	case "exp":
+		outer = s
+	case "ln":
+		outer = symPow(u, symInt(-1))
+	case "log":
+		outer = symPow(symMul(u, symCall("ln", symInt(10))), symInt(-1))
+	case "log2":
+		outer = symPow(symMul(u, symCall("ln", symInt(2))), symInt(-1))
+	case "abs":
+		outer = symMul(u, symPow(s, symInt(-1)))
+	case "atan2":
+		// d/dx atan2(y, x) = (x*y' - y*x')/(x^2 + y^2)
+		y, v := s.args[0], s.args[1]
+		return symMul(
+			symAdd(symMul(v, differentiate(y, x)), symMul(symInt(-1), y, differentiate(v, x))),
+			symPow(symAdd(symPow(v, symInt(2)), symPow(y, symInt(2))), symInt(-1)))
+	default:
+		symFail("%s cannot be differentiated symbolically", s.name)
+	}
+	return symMul(outer, differentiate(u, x))
+}
+
+// polynomialTerm is a term of a polynomial: a rational coefficient times
+// whole-number powers of atoms, which are variables or function calls.
+type polynomialTerm struct {
+	coeff *big.Rat
+	exps  map[string]int
+}
+
+// factor writes s as a product of a rational constant, a monomial and
+// polynomials with integer coefficients. Polynomials in one atom have all
+// factors of degree one pulled out by the rational root theorem; so do
+// homogeneous polynomials in two, such as x^2 - y^2. It returns the
+// product, its factors and a note on what may remain.
+func factor(s *sym) (*sym, []*sym, string) {
+	e := expand(s)
+	atoms := map[string]*sym{}
+	var terms []polynomialTerm
+	for _, t := range termsOf(e) {
+		coeff, rest := splitCoefficient(t)
+		pt := polynomialTerm{coeff, map[string]int{}}
+		var factors []*sym
+		if !rest.isInt(1) {
+			factors = []*sym{rest}
+			if rest.kind == symProduct {
+				factors = rest.args
+			}
+		}
+		for _, f := range factors {
+			base, exp := f, symInt(1)
+			if f.kind == symPower {
+				base, exp = f.args[0], f.args[1]
+			}
+			if !exp.isNumber() || !exp.num.IsInt() || exp.num.Sign() < 0 || !exp.num.Num().IsInt64() ||
+				base.kind == symSum || base.isNumber() {
+				symFail("factor needs a polynomial, but %s is not a polynomial term", t)
+			}
+			key := base.String()
+			atoms[key] = base
+			pt.exps[key] += int(exp.num.Num().Int64())
+		}
+		terms = append(terms, pt)
+	}
+	if len(terms) == 1 {
+		return e, []*sym{e}, ""
+	}
+
+	// The content: the gcd of the numerators over the lcm of the
+	// denominators, signed so that the leading coefficient is positive.
+	g, l := new(big.Int), big.NewInt(1)
+	for _, t := range terms {
+		g.GCD(nil, nil, g, new(big.Int).Abs(t.coeff.Num()))
+		l.Mul(l, new(big.Int).Quo(t.coeff.Denom(), new(big.Int).GCD(nil, nil, l, t.coeff.Denom())))
+	}
+	content := new(big.Rat).SetFrac(g, l)
+	if terms[0].coeff.Sign() < 0 {
+		content.Neg(content)
+	}
+
+	// The common monomial: the least power of each atom.
+	common := map[string]int{}
+	for key := range atoms {
+		common[key] = terms[0].exps[key]
+		for _, t := range terms[1:] {
+			common[key] = min(common[key], t.exps[key])
+		}
+	}
+	var names []string
+	for key := range atoms {
+		names = append(names, key)
+	}
+	slices.Sort(names)
+
+	var factors []*sym
+	if content.Cmp(big.NewRat(1, 1)) != 0 {
+		factors = append(factors, symRat(content))
+	}
+	var remaining []string
+	for _, key := range names {
+		if k := common[key]; k > 0 {
+			factors = append(factors, symPow(atoms[key], symInt(int64(k))))
+		}
+		for i := range terms {
+			terms[i].exps[key] -= common[key]
+		}
+		for _, t := range terms {
+			if t.exps[key] > 0 {
+				remaining = append(remaining, key)
+				break
+			}
+		}
+	}
+	for i := range terms {
+		terms[i].coeff = new(big.Rat).Quo(terms[i].coeff, content)
+	}
+
+	note := ""
+	switch {
+	case len(remaining) == 1:
+		linear, rest, n, complete := factorUnivariate(terms, atoms[remaining[0]], nil, remaining[0])
+		factors = append(factors, linear...)
+		if rest != nil {
+			factors = append(factors, rest)
+			note = irreducibleNote(n, complete)
+		}
+	case len(remaining) == 2 && homogeneous(terms):
+		linear, rest, n, complete := factorUnivariate(terms, atoms[remaining[0]], atoms[remaining[1]], remaining[0])
+		factors = append(factors, linear...)
+		if rest != nil {
+			factors = append(factors, rest)
+			note = irreducibleNote(n, complete)
+		}
+	default:
+		var polyTerms []*sym
+		for _, t := range terms {
+			polyTerms = append(polyTerms, polynomialSym(t, atoms))
+		}
+		factors = append(factors, symAdd(polyTerms...))
+		if len(remaining) > 1 {
+			note = "only common factors are taken out of polynomials in several variables, unless they are homogeneous in two"
+		}
+	}
+
+	if len(factors) == 1 {
+		return factors[0], factors, note
+	}
+	return compound(symProduct, "", factors), factors, note
+}
+
+func irreducibleNote(degree int, complete bool) string {
+	if !complete {
+		return "the search for rational roots was skipped because a coefficient is above 2^40, so the remaining factor may still have rational roots"
+	}
+	if degree <= 3 {
+		return "the remaining factor is irreducible over the rationals"
+	}
+	return "the remaining factor has no rational roots but may still factor into polynomials of degree 2 or more"
+}
+
+// homogeneous reports whether all terms have the same total degree.
+func homogeneous(terms []polynomialTerm) bool {
+	degree := func(t polynomialTerm) int {
+		d := 0
+		for _, k := range t.exps {
+			d += k
+		}
+		return d
+	}
+	for _, t := range terms[1:] {
+		if degree(t) != degree(terms[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// polynomialSym builds the term t from its atoms.
+func polynomialSym(t polynomialTerm, atoms map[string]*sym) *sym {
+	factors := []*sym{symRat(t.coeff)}
+	for key, k := range t.exps {
+		factors = append(factors, symPow(atoms[key], symInt(int64(k))))
+	}
+	return symMul(factors...)
+}
+
+// factorUnivariate factors a primitive polynomial in the atom x, or a
+// homogeneous one in x and y, into linear factors q*x - p (or q*x - p*y)
+// and what remains. The polynomial is first split into square-free factors,
+// so that a repeated root is searched for once, among the divisors of the
+// small coefficients of its square-free factor. It returns the linear
+// factors, raised to their multiplicity, the remaining factor or nil, its
+// degree, and whether the search for its rational roots was complete.
+func factorUnivariate(terms []polynomialTerm, x, y *sym, xKey string) ([]*sym, *sym, int, bool) {
+	degree := 0
+	for _, t := range terms {
+		degree = max(degree, t.exps[xKey])
+	}
+	// coeffs[i] is the coefficient of x^i, times y^(degree-i) if homogeneous.
+	coeffs := make(ratPoly, degree+1)
+	for i := range coeffs {
+		coeffs[i] = new(big.Rat)
+	}
+	for _, t := range terms {
+		coeffs[t.exps[xKey]].Add(coeffs[t.exps[xKey]], t.coeff)
+	}
+
+	type root struct {
+		p, q *big.Int
+		mult int
+	}
+	var roots []root
+	complete := true
+	for _, f := range squareFree(coeffs) {
+		for g := f.poly.integral(); g.degree() > 0; {
+			p, q, ok := rationalRoot(g)
+			if !ok {
+				complete = complete && rootSearchable(g)
+				break
+			}
+			r := new(big.Rat).SetFrac(p, q)
+			roots = append(roots, root{p, q, f.multiplicity})
+			g = ratPoly(deflate(g, r)).integral()
+			// Dividing by x - p/q leaves rational coefficients; dividing by
+			// q*x - p keeps them whole (Gauss's lemma).
+			for range f.multiplicity {
+				coeffs = deflate(coeffs, r)
+				for _, c := range coeffs {
+					c.Quo(c, new(big.Rat).SetInt(q))
+				}
+			}
+		}
+	}
+
+	var factors []*sym
+	for _, r := range roots {
+		constant := symRat(new(big.Rat).SetInt(new(big.Int).Neg(r.p)))
+		if y != nil {
+			constant = symMul(constant, y)
+		}
+		linear := symAdd(symMul(symRat(new(big.Rat).SetInt(r.q)), x), constant)
+		factors = append(factors, symPow(linear, symInt(int64(r.mult))))
+	}
+	slices.SortFunc(factors, func(a, b *sym) int { return strings.Compare(a.String(), b.String()) })
+
+	// What is left after dividing a primitive polynomial by its linear
+	// factors is 1 or a polynomial of degree 2 or more, unless the search
+	// was cut short.
+	if len(coeffs) == 1 {
+		return factors, nil, 0, true
+	}
+	var rest []*sym
+	n := len(coeffs) - 1
+	for i, c := range coeffs {
+		term := symMul(symRat(c), symPow(x, symInt(int64(i))))
+		if y != nil {
+			term = symMul(term, symPow(y, symInt(int64(n-i))))
+		}
+		rest = append(rest, term)
+	}
+	return factors, symAdd(rest...), n, complete
+}
+
+// rationalRoot finds a rational root p/q of the polynomial with integer
+// coefficients coeffs (lowest degree first), trying the divisors p of the
+// constant term and q of the leading coefficient. A zero constant term
+// gives the root 0.
+func rationalRoot(coeffs []*big.Rat) (*big.Int, *big.Int, bool) {
+	a0, an := coeffs[0].Num(), coeffs[len(coeffs)-1].Num()
+	if a0.Sign() == 0 {
+		return big.NewInt(0), big.NewInt(1), true
+	}
+	ps, ok := divisors(a0)
+	if !ok {
+		return nil, nil, false
+	}
+	qs, ok := divisors(an)
+	if !ok {
+		return nil, nil, false
+	}
+	for _, q := range qs {
+		for _, p := range ps {
+			if new(big.Int).GCD(nil, nil, p, q).Cmp(big.NewInt(1)) != 0 {
+				continue
+			}
+			for _, sign := range []int64{1, -1} {
+				r := new(big.Rat).SetFrac(new(big.Int).Mul(p, big.NewInt(sign)), q)
+				if hornerRat(coeffs, r).Sign() == 0 {
+					return r.Num(), r.Denom(), true
+				}
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// rootSearchable reports whether rationalRoot tries every candidate for
+// coeffs, which it cannot when the constant or leading coefficient is too
+// large to factor.
+func rootSearchable(coeffs []*big.Rat) bool {
+	limit := big.NewInt(maxRootCandidate)
+	return new(big.Int).Abs(coeffs[0].Num()).Cmp(limit) <= 0 &&
+		new(big.Int).Abs(coeffs[len(coeffs)-1].Num()).Cmp(limit) <= 0
+}
+
+// divisors returns the positive divisors of n in increasing order, or false
+// if |n| is too large to factor by trial division.
+func divisors(n *big.Int) ([]*big.Int, bool) {
+	a := new(big.Int).Abs(n)
+	if a.Cmp(big.NewInt(maxRootCandidate)) > 0 {
+		return nil, false
+	}
+	v := a.Int64()
+	var small, large []*big.Int
+	for d := int64(1); d*d <= v; d++ {
+		if v%d == 0 {
+			small = append(small, big.NewInt(d))
+			if d*d != v {
+				large = append(large, big.NewInt(v/d))
+			}
+		}
+	}
+	slices.Reverse(large)
+	return append(small, large...), true
+}
+
+// hornerRat evaluates the polynomial coeffs (lowest degree first) at r.
+func hornerRat(coeffs []*big.Rat, r *big.Rat) *big.Rat {
+	v := new(big.Rat)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		v.Mul(v, r).Add(v, coeffs[i])
+	}
+	return v
+}
+
+// deflate divides the polynomial coeffs by x - r, which must divide it.
+func deflate(coeffs []*big.Rat, r *big.Rat) []*big.Rat {
+	n := len(coeffs) - 1
+	q := make([]*big.Rat, n)
+	carry := new(big.Rat)
+	for i := n; i >= 1; i-- {
+		carry = new(big.Rat).Add(coeffs[i], new(big.Rat).Mul(carry, r))
+		q[i-1] = carry
+	}
+	return q
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const defaultBitWidth = 32
+
+var (
+	bitsOperations = []interface{}{
+		"convert", "and", "or", "xor", "not",
+		"shift-left", "shift-right", "rotate-left", "rotate-right",
+		"popcount", "decode",
+	}
+	bitWidths = []interface{}{8, 16, 32, 64, 128}
+	// ieeeFormats gives the exponent and fraction sizes of the IEEE-754
+	// binary interchange formats by width.
+	ieeeFormats = map[int]struct {
+		name               string
+		exponent, fraction uint
+	}{
+		16:  {"binary16 (half)", 5, 10},
+		32:  {"binary32 (single)", 8, 23},
+		64:  {"binary64 (double)", 11, 52},
+		128: {"binary128 (quadruple)", 15, 112},
+	}
+)
+
+// BitsParams defines the parameters for the bits tool.
+type BitsParams struct {
+	Operation string `json:"operation" jsonschema:"'convert', 'and', 'or', 'xor', 'not', 'shift-left', 'shift-right', 'rotate-left', 'rotate-right', 'popcount' or 'decode' (two's complement and IEEE-754 readings of the bits)"`
+	Value     string `json:"value" jsonschema:"the integer, in base (default: decimal, or hexadecimal, octal or binary with a 0x, 0o or 0b prefix); may be negative, and '_' may separate digits"`
+	Other     string `json:"other,omitempty" jsonschema:"and, or and xor: the second operand, in the same base as value"`
+	Amount    int    `json:"amount,omitempty" jsonschema:"shifts and rotates: the number of bit positions, up to width"`
+	Width     int    `json:"width,omitempty" jsonschema:"word size in bits: 8, 16, 32 (default), 64 or 128"`
+	Signed    bool   `json:"signed,omitempty" jsonschema:"treat the word as a signed two's-complement integer: shift-right is then arithmetic, and conversions to to_base keep the sign"`
+	Base      int    `json:"base,omitempty" jsonschema:"base of value and other, 2 to 36"`
+	ToBase    int    `json:"to_base,omitempty" jsonschema:"also write the result in this base, 2 to 36"`
+}
+
+func (p BitsParams) Validate() error {
+	binary := p.Operation == "and" || p.Operation == "or" || p.Operation == "xor"
+	moves := strings.HasPrefix(p.Operation, "shift-") || strings.HasPrefix(p.Operation, "rotate-")
+	width := p.Width
+	if width == 0 {
+		width = defaultBitWidth
+	}
+	word := func(value interface{}) error {
+		_, err := parseWord(value.(string), p.Base, width)
+		return err
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Operation, validation.Required, validation.In(bitsOperations...)),
+		validation.Field(&p.Width, validation.In(bitWidths...)),
+		validation.Field(&p.Base, validation.Min(2), validation.Max(36)),
+		validation.Field(&p.Value, validation.Required, validation.By(word)),
+		validation.Field(&p.Other,
+			validation.When(binary, validation.Required.Error("is required for "+p.Operation)),
+			validation.When(!binary, validation.Empty.Error("only applies to and, or and xor")),
+			validation.When(p.Other != "", validation.By(word)),
+		),
+		validation.Field(&p.Amount,
+			validation.When(!moves, validation.Empty.Error("only applies to shifts and rotates")),
+			validation.Min(0), validation.Max(width),
+		),
+		validation.Field(&p.ToBase, validation.Min(2), validation.Max(36)),
+		validation.Field(&p.Signed,
+			validation.When(p.Operation == "decode", validation.Empty.Error("does not apply to decode, which gives both readings")),
+		),
+	)
+}
+
+// parseWord parses an integer in base, or with a 0x, 0o or 0b prefix when
+// no base is given, and returns its bit pattern in a word of width bits.
+// Values from -2^(width-1) to 2^width - 1 fit; negative values are stored
+// in two's complement.
+func parseWord(s string, base, width int) (*big.Int, error) {
+	text := strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+	sign, digits := "", text
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	lower := strings.ToLower(digits)
+	prefixes := map[string]int{"0x": 16, "0o": 8, "0b": 2}
+	if b, ok := prefixes[lower[:min(2, len(lower))]]; ok && len(lower) > 2 && (base == 0 || base == b) {
+		digits, base = digits[2:], b
+	}
+	if base == 0 {
+		base = 10
+	}
+	n, ok := new(big.Int).SetString(sign+digits, base)
+	if !ok || strings.ContainsAny(digits, "+-") {
+		return nil, fmt.Errorf("%q is not a base-%d integer", s, base)
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(width))
+	low := new(big.Int).Neg(new(big.Int).Rsh(limit, 1))
+	if n.Cmp(low) < 0 || n.Cmp(limit) >= 0 {
+		return nil, fmt.Errorf("%s does not fit in %d bits, which hold %s to %s", s, width, low, new(big.Int).Sub(limit, big.NewInt(1)))
+	}
+	if n.Sign() < 0 {
+		n.Add(n, limit)
+	}
+	return n, nil
+}
+
+// IEEEFloat is the IEEE-754 reading of a word.
+type IEEEFloat struct {
+	Format   string `json:"format" jsonschema:"the interchange format"`
+	Class    string `json:"class" jsonschema:"'zero', 'subnormal', 'normal', 'infinity' or 'nan'"`
+	Sign     int    `json:"sign" jsonschema:"the sign bit"`
+	Exponent int    `json:"exponent" jsonschema:"the unbiased exponent (the biased field minus the bias)"`
+	Fraction string `json:"fraction" jsonschema:"the fraction field in hexadecimal"`
+	Value    string `json:"value" jsonschema:"the number, as the shortest decimal that identifies it in this format"`
+}
+
+// BitsResult defines the result for the bits tool.
+type BitsResult struct {
+	Width     int        `json:"width" jsonschema:"word size in bits"`
+	Unsigned  string     `json:"unsigned" jsonschema:"the result word as an unsigned decimal integer"`
+	Signed    string     `json:"signed" jsonschema:"the result word as a signed two's-complement decimal integer"`
+	Hex       string     `json:"hex" jsonschema:"the word in hexadecimal, padded to width"`
+	Octal     string     `json:"octal" jsonschema:"the word in octal"`
+	Binary    string     `json:"binary" jsonschema:"the word in binary, padded to width"`
+	Converted string     `json:"converted,omitempty" jsonschema:"the result in to_base"`
+	Count     *int       `json:"count,omitempty" jsonschema:"popcount: the number of set bits"`
+	Float     *IEEEFloat `json:"float,omitempty" jsonschema:"decode: the IEEE-754 reading of the word, for widths 16 to 128"`
+	Note      string     `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handleBits(ctx context.Context, req *mcp.CallToolRequest, param BitsParams) (*mcp.CallToolResult, BitsResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			BitsResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	result := runBits(param)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: formatBitsResult(result)}},
+	}, result, nil
+}
+
+// runBits performs the operation on words of the requested width. The
+// parameters must already have passed Validate.
+func runBits(param BitsParams) BitsResult {
+	width := param.Width
+	if width == 0 {
+		width = defaultBitWidth
+	}
+	w := uint(width)
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), w), big.NewInt(1))
+	x, _ := parseWord(param.Value, param.Base, width)
+	var y *big.Int
+	if param.Other != "" {
+		y, _ = parseWord(param.Other, param.Base, width)
+	}
+
+	z := new(big.Int)
+	var note string
+	var count *int
+	var float *IEEEFloat
+	n := uint(param.Amount)
+	switch param.Operation {
+	case "convert":
+		z.Set(x)
+	case "and":
+		z.And(x, y)
+	case "or":
+		z.Or(x, y)
+	case "xor":
+		z.Xor(x, y)
+	case "not":
+		z.Xor(x, mask)
+	case "shift-left":
+		z.Lsh(x, n)
+		if z.Cmp(mask) > 0 {
+			note = fmt.Sprintf("set bits were shifted out of the %d-bit word", width)
+		}
+		z.And(z, mask)
+	case "shift-right":
+		if param.Signed {
+			z.Rsh(signedWord(x, w), n).And(z, mask)
+		} else {
+			z.Rsh(x, n)
+		}
+	case "rotate-left", "rotate-right":
+		n %= w
+		if param.Operation == "rotate-right" {
+			n = (w - n) % w
+		}
+		z.Or(new(big.Int).Lsh(x, n), new(big.Int).Rsh(x, w-n)).And(z, mask)
+	case "popcount":
+		c := 0
+		for i := range width {
+			c += int(x.Bit(i))
+		}
+		z.Set(x)
+		count = &c
+	case "decode":
+		z.Set(x)
+		if _, ok := ieeeFormats[width]; ok {
+			float = decodeIEEE(x, width)
+		} else {
+			note = "IEEE-754 defines no 8-bit format; use width 16, 32, 64 or 128 for the floating-point reading"
+		}
+	}
+
+	result := BitsResult{
+		Width:    width,
+		Unsigned: z.String(),
+		Signed:   signedWord(z, w).String(),
+		Hex:      "0x" + wordDigits(z, 16, (width+3)/4),
+		Octal:    "0o" + strings.ToUpper(z.Text(8)),
+		Binary:   "0b" + wordDigits(z, 2, width),
+		Count:    count,
+		Float:    float,
+		Note:     note,
+	}
+	if param.ToBase != 0 {
+		if param.Signed {
+			result.Converted = strings.ToUpper(signedWord(z, w).Text(param.ToBase))
+		} else {
+			result.Converted = strings.ToUpper(z.Text(param.ToBase))
+		}
+	}
+	return result
+}
+
+// signedWord reads the word x of width w as a two's-complement integer.
+func signedWord(x *big.Int, w uint) *big.Int {
+	if x.Bit(int(w)-1) == 0 {
+		return new(big.Int).Set(x)
+	}
+	return new(big.Int).Sub(x, new(big.Int).Lsh(big.NewInt(1), w))
+}
+
+// wordDigits writes x in base with leading zeros to the given length, in
+// upper case.
+func wordDigits(x *big.Int, base, length int) string {
+	digits := strings.ToUpper(x.Text(base))
+	return strings.Repeat("0", max(0, length-len(digits))) + digits
+}
+
+// decodeIEEE reads the word x as an IEEE-754 binary floating-point number
+// of the given width.
+func decodeIEEE(x *big.Int, width int) *IEEEFloat {
+	format := ieeeFormats[width]
+	fractionMask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), format.fraction), big.NewInt(1))
+	fraction := new(big.Int).And(x, fractionMask)
+	biased := int(new(big.Int).Rsh(x, format.fraction).Int64() & (1<<format.exponent - 1))
+	sign := int(x.Bit(width - 1))
+	bias := 1<<(format.exponent-1) - 1
+	f := &IEEEFloat{
+		Format:   format.name,
+		Sign:     sign,
+		Exponent: biased - bias,
+		Fraction: "0x" + strings.ToUpper(fraction.Text(16)),
+	}
+
+	minus := ""
+	if sign == 1 {
+		minus = "-"
+	}
+	switch {
+	case biased == 1<<format.exponent-1 && fraction.Sign() == 0:
+		f.Class, f.Value = "infinity", minus+"Inf"
+		return f
+	case biased == 1<<format.exponent-1:
+		f.Class, f.Value = "nan", "NaN"
+		quiet := fraction.Bit(int(format.fraction)-1) == 1
+		if !quiet {
+			f.Value = "NaN (signaling)"
+		}
+		return f
+	case biased == 0 && fraction.Sign() == 0:
+		f.Class, f.Value = "zero", minus+"0"
+		return f
+	case biased == 0:
+		// Subnormals have no implicit leading 1 and the exponent of the
+		// smallest normal number.
+		f.Class, f.Exponent = "subnormal", 1-bias
+	default:
+		f.Class = "normal"
+		fraction.SetBit(fraction, int(format.fraction), 1)
+	}
+	// value = fraction · 2^(exponent - fraction bits), exact at this
+	// precision; Text with -1 digits gives the shortest decimal that rounds
+	// back to it.
+	v := new(big.Float).SetPrec(format.fraction + 1).SetInt(fraction)
+	v.SetMantExp(v, f.Exponent-int(format.fraction))
+	if sign == 1 {
+		v.Neg(v)
+	}
+	f.Value = v.Text('g', -1)
+	return f
+}
+
+// formatBitsResult renders the result as text, with binary digits grouped
+// by four.
+func formatBitsResult(r BitsResult) string {
+	digits := strings.TrimPrefix(r.Binary, "0b")
+	var groups []string
+	for i := len(digits); i > 0; i -= 4 {
+		groups = append([]string{digits[max(0, i-4):i]}, groups...)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Width: %d bits\nUnsigned: %s\nSigned: %s\nHex: %s\nOctal: %s\nBinary: 0b%s",
+		r.Width, r.Unsigned, r.Signed, r.Hex, r.Octal, strings.Join(groups, "_"))
+	if r.Converted != "" {
+		fmt.Fprintf(&b, "\nConverted: %s", r.Converted)
+	}
+	if r.Count != nil {
+		fmt.Fprintf(&b, "\nSet bits: %d", *r.Count)
+	}
+	if r.Float != nil {
+		fmt.Fprintf(&b, "\nIEEE-754 %s: %s (%s, sign %d, exponent %d, fraction %s)",
+			r.Float.Format, r.Float.Value, r.Float.Class, r.Float.Sign, r.Float.Exponent, r.Float.Fraction)
+	}
+	if r.Note != "" {
+		fmt.Fprintf(&b, "\nNote: %s", r.Note)
+	}
+	return b.String()
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxNumericDerivativeOrder bounds the order of derivatives; higher
+	// differences lose too many digits to cancellation.
+	maxNumericDerivativeOrder = 4
+	// maxIntegrationEvaluations bounds max_evaluations.
+	maxIntegrationEvaluations     = 1_000_000
+	defaultCalculusTolerance      = 1e-10
+	defaultIntegrationEvaluations = 100_000
+	derivativeSteps               = 10
+	// A limit is extrapolated from at least minLimitSteps and at most
+	// limitSteps samples, each halving the distance to the point.
+	minLimitSteps = 8
+	limitSteps    = 24
+)
+
+var (
+	calculusOperations = []interface{}{"integrate", "derivative", "limit"}
+	integrationMethods = []interface{}{"gauss-kronrod", "simpson"}
+	limitDirections    = []interface{}{"both", "left", "right"}
+	infinityNames      = map[string]float64{"inf": 1, "+inf": 1, "infinity": 1, "+infinity": 1, "∞": 1, "+∞": 1, "-inf": -1, "-infinity": -1, "-∞": -1}
+)
+
+// CalculusParams defines the parameters for the calculus tool.
+type CalculusParams struct {
+	Expression     string      `json:"expression" jsonschema:"expression in one variable, e.g. exp(-x^2) or sin(x)/x"`
+	Operation      string      `json:"operation" jsonschema:"'integrate' (definite integral over [lower, upper]), 'derivative' (at point) or 'limit' (as the variable approaches point)"`
+	Variable       string      `json:"variable,omitempty" jsonschema:"the variable (default: the only variable of expression)"`
+	Lower          interface{} `json:"lower,omitempty" jsonschema:"integrate: lower bound, as a number, a constant expression such as \"pi/2\", or \"-inf\""`
+	Upper          interface{} `json:"upper,omitempty" jsonschema:"integrate: upper bound, as a number, a constant expression, or \"inf\""`
+	Point          interface{} `json:"point,omitempty" jsonschema:"derivative and limit: the point, as a number or a constant expression; limits also accept \"inf\" and \"-inf\""`
+	Order          int         `json:"order,omitempty" jsonschema:"derivative: order of the derivative, 1 to 4 (default: 1)"`
+	Direction      string      `json:"direction,omitempty" jsonschema:"limit: 'both' (default), 'left' or 'right'"`
+	Method         string      `json:"method,omitempty" jsonschema:"integrate: 'gauss-kronrod' (default, adaptive 15-point Gauss–Kronrod) or 'simpson' (adaptive Simpson)"`
+	Tolerance      *float64    `json:"tolerance,omitempty" jsonschema:"integrate: requested error, absolute or relative to the result (default: 1e-10)"`
+	MaxEvaluations int         `json:"max_evaluations,omitempty" jsonschema:"integrate: limit on evaluations of the integrand (default: 100000)"`
+}
+
+func (p CalculusParams) Validate() error {
+	integrate := p.Operation == "integrate"
+	bound := func(allowInfinite bool) validation.RuleFunc {
+		return func(value interface{}) error {
+			_, err := calculusValue(value, allowInfinite)
+			return err
+		}
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Expression, validation.Required, validation.Length(1, maxSymbolicLength)),
+		validation.Field(&p.Operation, validation.Required, validation.In(calculusOperations...)),
+		validation.Field(&p.Variable, validation.Match(identifierPattern).Error("must be a variable name")),
+		validation.Field(&p.Lower,
+			validation.When(integrate, validation.NotNil.Error("is required to integrate"), validation.By(bound(true))),
+			validation.When(!integrate, validation.Nil.Error("only applies to integrate")),
+		),
+		validation.Field(&p.Upper,
+			validation.When(integrate, validation.NotNil.Error("is required to integrate"), validation.By(bound(true))),
+			validation.When(!integrate, validation.Nil.Error("only applies to integrate")),
+		),
+		validation.Field(&p.Point,
+			validation.When(!integrate, validation.NotNil.Error("is required for derivative and limit"), validation.By(bound(p.Operation == "limit"))),
+			validation.When(integrate, validation.Nil.Error("does not apply to integrate; use lower and upper")),
+		),
+		validation.Field(&p.Order,
+			validation.When(p.Operation != "derivative", validation.Empty.Error("only applies to derivative")),
+			validation.Min(0), validation.Max(maxNumericDerivativeOrder),
+		),
+		validation.Field(&p.Direction,
+			validation.When(p.Operation != "limit", validation.Empty.Error("only applies to limit")),
+			validation.In(limitDirections...),
+		),
+		validation.Field(&p.Method,
+			validation.When(!integrate, validation.Empty.Error("only applies to integrate")),
+			validation.In(integrationMethods...),
+		),
+		validation.Field(&p.Tolerance,
+			validation.When(!integrate, validation.Nil.Error("only applies to integrate")),
+			validation.By(func(value interface{}) error {
+				if p.Tolerance != nil && !(*p.Tolerance > 0 && *p.Tolerance < 1) {
+					return errors.New("must be between 0 and 1")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.MaxEvaluations,
+			validation.When(!integrate, validation.Empty.Error("only applies to integrate")),
+			validation.Min(0), validation.Max(maxIntegrationEvaluations),
+		),
+	)
+}
+
+// calculusValue reads a bound or point: a JSON number, a constant
+// expression, or an infinity when allowInfinite is set.
+func calculusValue(value interface{}, allowInfinite bool) (float64, error) {
+	var v float64
+	switch value := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		v = value
+	case string:
+		text := strings.ToLower(strings.TrimSpace(value))
+		if sign, ok := infinityNames[text]; ok {
+			if !allowInfinite {
+				return 0, errors.New("must be finite")
+			}
+			return math.Inf(int(sign)), nil
+		}
+		node, err := parseExpression(text)
+		if err != nil {
+			return 0, err
+		}
+		if vars := exprVariables(node); len(vars) > 0 {
+			return 0, fmt.Errorf("must be a constant, but uses %s", strings.Join(vars, ", "))
+		}
+		if v, err = evalExpr(node, nil); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, errors.New("must be a number or a string")
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, errors.New("must be a finite number")
+	}
+	return v, nil
+}
+
+// CalculusResult defines the result for the calculus tool.
+type CalculusResult struct {
+	Value       float64 `json:"value" jsonschema:"the integral, derivative or limit"`
+	Error       float64 `json:"error" jsonschema:"estimated absolute error of value"`
+	Evaluations int     `json:"evaluations" jsonschema:"number of evaluations of the expression"`
+	Method      string  `json:"method" jsonschema:"how value was computed"`
+	Note        string  `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handleCalculus(ctx context.Context, req *mcp.CallToolRequest, param CalculusParams) (*mcp.CallToolResult, CalculusResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			CalculusResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	node, err := parseExpression(param.Expression)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Invalid expression: %v", err)),
+			CalculusResult{}, fmt.Errorf("invalid expression: %v", err)
+	}
+	result, err := runCalculus(node, param)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			CalculusResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: formatCalculusResult(result)}},
+	}, result, nil
+}
+
+// calculusFunction evaluates an expression in x and counts evaluations.
+// Points where the expression is undefined give NaN; the first is kept for
+// diagnostics.
+type calculusFunction struct {
+	node        *exprNode
+	x           string
+	evaluations int
+	undefined   *float64
+}
+
+func (f *calculusFunction) at(v float64) float64 {
+	f.evaluations++
+	y, err := evalExpr(f.node, map[string]float64{f.x: v})
+	if err != nil || math.IsNaN(y) {
+		if f.undefined == nil {
+			f.undefined = &v
+		}
+		return math.NaN()
+	}
+	return y
+}
+
+// runCalculus dispatches the operation after choosing the variable.
+func runCalculus(node *exprNode, param CalculusParams) (CalculusResult, error) {
+	vars := exprVariables(node)
+	x := param.Variable
+	switch {
+	case x == "" && len(vars) == 1:
+		x = vars[0]
+	case x == "" && len(vars) == 0:
+		x = "x"
+	case x == "":
+		return CalculusResult{}, fmt.Errorf("the expression has %d variables (%s); choose one with variable", len(vars), strings.Join(vars, ", "))
+	case len(vars) > 1 || len(vars) == 1 && vars[0] != x:
+		return CalculusResult{}, fmt.Errorf("the expression has variables other than %s (%s); only one variable is supported", x, strings.Join(vars, ", "))
+	}
+	f := &calculusFunction{node: node, x: x}
+
+	var result CalculusResult
+	var err error
+	switch param.Operation {
+	case "integrate":
+		lower, _ := calculusValue(param.Lower, true)
+		upper, _ := calculusValue(param.Upper, true)
+		result, err = integrate(f, lower, upper, param)
+	case "derivative":
+		point, _ := calculusValue(param.Point, false)
+		order := max(param.Order, 1)
+		result, err = derivative(f, point, order)
+	default:
+		point, _ := calculusValue(param.Point, true)
+		direction := param.Direction
+		if direction == "" {
+			direction = "both"
+		}
+		result, err = limit(f, point, direction)
+	}
+	result.Evaluations = f.evaluations
+	return result, err
+}
+
+// integrate computes the integral of f over [lower, upper]. Infinite
+// bounds are mapped to a finite range by a change of variable, and
+// reversed bounds negate the integral.
+func integrate(f *calculusFunction, lower, upper float64, param CalculusParams) (CalculusResult, error) {
+	if math.IsInf(lower, 0) && lower == upper {
+		return CalculusResult{}, errors.New("lower and upper are the same infinity")
+	}
+	if lower == upper {
+		return CalculusResult{Method: "empty interval"}, nil
+	}
+	sign := 1.0
+	if lower > upper {
+		lower, upper, sign = upper, lower, -1
+	}
+	tol := defaultCalculusTolerance
+	if param.Tolerance != nil {
+		tol = *param.Tolerance
+	}
+	maxEvaluations := param.MaxEvaluations
+	if maxEvaluations == 0 {
+		maxEvaluations = defaultIntegrationEvaluations
+	}
+
+	infinite := math.IsInf(lower, 0) || math.IsInf(upper, 0)
+	g, a, b := infiniteSubstitution(f.at, lower, upper)
+	var r integration
+	var method string
+	if param.Method == "simpson" {
+		if infinite {
+			return CalculusResult{}, errors.New("simpson evaluates the integrand at the ends of the interval, so it cannot integrate to infinity; use gauss-kronrod")
+		}
+		r = integrateSimpson(g, a, b, tol, maxEvaluations)
+		method = fmt.Sprintf("adaptive Simpson's rule, %d subinterval%s", r.segments, plural(r.segments))
+	} else {
+		r = integrateGaussKronrod(g, a, b, tol, maxEvaluations)
+		method = fmt.Sprintf("adaptive 15-point Gauss–Kronrod, %d subinterval%s", r.segments, plural(r.segments))
+	}
+	if infinite {
+		method += ", after substituting " + substitutionText(f.x, lower, upper)
+	}
+
+	if f.undefined != nil || math.IsNaN(r.value) {
+		at := "somewhere in the interval"
+		if f.undefined != nil {
+			at = fmt.Sprintf("at %s = %g", f.x, *f.undefined)
+		}
+		if param.Method == "simpson" {
+			return CalculusResult{}, fmt.Errorf("the integrand is undefined %s; if this is an end of the interval, use gauss-kronrod, which does not evaluate the ends", at)
+		}
+		return CalculusResult{}, fmt.Errorf("the integrand is undefined %s", at)
+	}
+	if math.IsInf(r.value, 0) {
+		return CalculusResult{}, errors.New("the integral overflows; it is probably divergent")
+	}
+	if !r.converged {
+		worst := r.worst
+		if infinite {
+			worst = substitutedPoint(worst, lower, upper)
+		}
+		return CalculusResult{}, fmt.Errorf("the integral did not reach the tolerance %g after %d evaluations: the estimate is %.15g with estimated error %.3g, largest near %s = %.6g; the integrand may be singular, divergent or strongly oscillating there",
+			tol, f.evaluations, sign*r.value, r.err, f.x, worst)
+	}
+	return CalculusResult{Value: sign * r.value, Error: r.err, Method: method}, nil
+}
+
+// substitutionText describes the change of variable of
+// infiniteSubstitution.
+func substitutionText(x string, lower, upper float64) string {
+	switch {
+	case math.IsInf(lower, -1) && math.IsInf(upper, 1):
+		return x + " = t/(1 - t^2)"
+	case math.IsInf(upper, 1) && lower == 0:
+		return x + " = t/(1 - t)"
+	case math.IsInf(upper, 1):
+		return fmt.Sprintf("%s = %g + t/(1 - t)", x, lower)
+	case upper == 0:
+		return x + " = -(1 - t)/t"
+	default:
+		return fmt.Sprintf("%s = %g - (1 - t)/t", x, upper)
+	}
+}
+
+// substitutedPoint maps t back to x under infiniteSubstitution.
+func substitutedPoint(t, lower, upper float64) float64 {
+	switch {
+	case math.IsInf(lower, -1) && math.IsInf(upper, 1):
+		return t / (1 - t*t)
+	case math.IsInf(upper, 1):
+		return lower + t/(1-t)
+	default:
+		return upper - (1-t)/t
+	}
+}
+
+// derivative computes the order-n derivative of f at x by Ridders' method:
+// central differences with shrinking steps, extrapolated to step 0. The
+// first step is shrunk until f is defined around x.
+func derivative(f *calculusFunction, x float64, n int) (CalculusResult, error) {
+	h := 0.5 * max(1, math.Abs(x))
+	for range 8 {
+		f.undefined = nil
+		value, err, _ := extrapolate(func(h float64) float64 {
+			return centralDifference(f.at, x, h, n)
+		}, h, 1.4, 2, 1, derivativeSteps)
+		if f.undefined == nil && !math.IsNaN(value) {
+			if math.IsInf(value, 0) || math.IsInf(err, 0) {
+				return CalculusResult{}, fmt.Errorf("the derivative at %s = %g does not exist or is infinite", f.x, x)
+			}
+			result := CalculusResult{
+				Value:  value,
+				Error:  err,
+				Method: "Ridders' extrapolation of central differences",
+			}
+			if err > 1e-6*max(1, math.Abs(value)) {
+				result.Note = fmt.Sprintf("the differences did not settle; the expression may not be smooth at %s = %g", f.x, x)
+			}
+			return result, nil
+		}
+		h /= 10
+	}
+	return CalculusResult{}, fmt.Errorf("the expression is undefined at or next to %s = %g", f.x, x)
+}
+
+// limit computes the limit of f as x approaches a from the given side by
+// Richardson extrapolation of f(a ± h) as h halves; infinite points use
+// f(±1/h). A two-sided limit requires both sides to agree.
+func limit(f *calculusFunction, a float64, direction string) (CalculusResult, error) {
+	if math.IsInf(a, 0) {
+		if direction != "both" {
+			return CalculusResult{}, errors.New("direction does not apply to limits at infinity")
+		}
+		side := math.Copysign(1, a)
+		return oneSidedLimit(f, a, func(h float64) float64 { return side / h }, "", 1)
+	}
+	h0 := 0.5 * max(1, math.Abs(a))
+	left := func() (CalculusResult, error) {
+		return oneSidedLimit(f, a, func(h float64) float64 { return a - h }, "from the left", h0)
+	}
+	right := func() (CalculusResult, error) {
+		return oneSidedLimit(f, a, func(h float64) float64 { return a + h }, "from the right", h0)
+	}
+	switch direction {
+	case "left":
+		return left()
+	case "right":
+		return right()
+	}
+	l, err := left()
+	if err != nil {
+		return CalculusResult{}, err
+	}
+	r, err := right()
+	if err != nil {
+		return CalculusResult{}, err
+	}
+	gap := math.Abs(l.Value - r.Value)
+	if gap > 10*(l.Error+r.Error)+1e-9*max(1, math.Abs(l.Value), math.Abs(r.Value)) {
+		return CalculusResult{}, fmt.Errorf("the limit does not exist: it is %.15g from the left and %.15g from the right", l.Value, r.Value)
+	}
+	result := CalculusResult{
+		Value:  (l.Value + r.Value) / 2,
+		Error:  max(l.Error, r.Error, gap/2),
+		Method: "Richardson extrapolation from both sides",
+		Note:   l.Note,
+	}
+	if r.Note != "" {
+		result.Note = strings.TrimPrefix(result.Note+"; ", "; ") + r.Note
+	}
+	return result, nil
+}
+
+// oneSidedLimit extrapolates f(point(h)) to h = 0.
+func oneSidedLimit(f *calculusFunction, a float64, point func(h float64) float64, side string, h0 float64) (CalculusResult, error) {
+	f.undefined = nil
+	value, err, samples := extrapolate(func(h float64) float64 {
+		return f.at(point(h))
+	}, h0, 2, 1, minLimitSteps, limitSteps)
+	where := fmt.Sprintf("%s → %g", f.x, a)
+	switch {
+	case math.IsInf(a, 1):
+		where = f.x + " → ∞"
+	case math.IsInf(a, -1):
+		where = f.x + " → -∞"
+	}
+	if side != "" {
+		where += " " + side
+	}
+	if f.undefined != nil {
+		return CalculusResult{}, fmt.Errorf("the expression is undefined at %s = %g, on the way to %s", f.x, *f.undefined, where)
+	}
+	n := len(samples)
+	last, closest := samples[n-1], point(h0/math.Pow(2, float64(n-1)))
+	settled := err <= 1e-3*max(1, math.Abs(value))
+	growing := true
+	for i := n - 4; i < n; i++ {
+		growing = growing && math.Abs(samples[i]) > 1.5*math.Abs(samples[i-1])
+	}
+	switch {
+	case math.IsInf(last, 0) || math.IsInf(value, 0) || growing && !settled:
+		sign := "+∞"
+		if last < 0 {
+			sign = "-∞"
+		}
+		return CalculusResult{}, fmt.Errorf("the expression grows without bound as %s (%.6g at %s = %.6g); the limit is %s or does not exist", where, last, f.x, closest, sign)
+	case !settled:
+		return CalculusResult{}, fmt.Errorf("the values do not settle as %s (%.6g, %.6g, %.6g at %s = %.6g, %.6g, %.6g), so the limit probably does not exist",
+			where, samples[n-3], samples[n-2], last, f.x, point(h0/math.Pow(2, float64(n-3))), point(h0/math.Pow(2, float64(n-2))), closest)
+	}
+	result := CalculusResult{Value: value, Error: err, Method: "Richardson extrapolation " + strings.TrimSpace(side)}
+	if math.IsInf(a, 0) {
+		result.Method = fmt.Sprintf("Richardson extrapolation in t = 1/%s", f.x)
+	}
+	if err > 1e-6*max(1, math.Abs(value)) {
+		result.Note = fmt.Sprintf("the values converge slowly as %s; the error estimate is only rough", where)
+	}
+	return result, nil
+}
+
+// formatCalculusResult renders the result as text.
+func formatCalculusResult(r CalculusResult) string {
+	text := fmt.Sprintf("Result: %.15g ± %.2g\nMethod: %s\nEvaluations: %d", r.Value, r.Error, r.Method, r.Evaluations)
+	if r.Note != "" {
+		text += "\nNote: " + r.Note
+	}
+	return text
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RandomChoiceParams defines the parameters for the random-choice tool.
+type RandomChoiceParams struct {
+	Items   []string  `json:"items" jsonschema:"the items to choose from"`
+	Weights []float64 `json:"weights,omitempty" jsonschema:"relative weight of each item, one per item (default: all equal); items with weight 0 are never chosen"`
+	Count   int       `json:"count,omitempty" jsonschema:"number of items to choose (default: 1)"`
+	Unique  bool      `json:"unique,omitempty" jsonschema:"choose without replacement so that no item is picked twice"`
+	Seed    *int64    `json:"seed,omitempty" jsonschema:"reseed this session's random stream for reproducible results; later calls without a seed continue the sequence"`
+	Secure  bool      `json:"secure,omitempty" jsonschema:"draw from the cryptographically secure generator (crypto/rand) instead of the session stream; cannot be seeded"`
+}
+
+func (p RandomChoiceParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Items,
+			validation.Required,
+			validation.Length(1, maxRandomCount),
+		),
+		validation.Field(&p.Weights,
+			validation.When(p.Weights != nil, validation.Length(len(p.Items), len(p.Items)).Error("must have one weight per item")),
+			validation.By(func(value interface{}) error {
+				if p.Weights == nil {
+					return nil
+				}
+				total := 0.0
+				for i, w := range p.Weights {
+					if math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+						return fmt.Errorf("weight %d must be a finite number of at least 0", i+1)
+					}
+					total += w
+				}
+				if total == 0 || math.IsInf(total, 0) {
+					return errors.New("must have a finite, positive sum")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Count,
+			validation.Min(0),
+			validation.Max(maxRandomCount),
+			validation.By(func(value interface{}) error {
+				if !p.Unique {
+					return nil
+				}
+				available := len(p.Items)
+				if p.Weights != nil {
+					available = 0
+					for _, w := range p.Weights {
+						if w > 0 {
+							available++
+						}
+					}
+				}
+				if max(p.Count, 1) > available {
+					return fmt.Errorf("cannot choose %d distinct items from %d with positive weight", max(p.Count, 1), available)
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Seed,
+			validation.When(p.Secure, validation.Nil.Error("cannot be used with secure")),
+		),
+	)
+}
+
+// RandomChoiceResult defines the result for the random-choice tool.
+type RandomChoiceResult struct {
+	Choice   string   `json:"choice" jsonschema:"the first chosen item"`
+	Choices  []string `json:"choices" jsonschema:"all chosen items in the order they were drawn"`
+	Indices  []int    `json:"indices" jsonschema:"0-based positions of the chosen items in items"`
+	Seed     *int64   `json:"seed,omitempty" jsonschema:"seed of this session's random stream; pass it back to reproduce the sequence; omitted in secure mode"`
+	Sequence int      `json:"sequence,omitempty" jsonschema:"number of calls drawing from the stream since it was seeded, including this one; omitted in secure mode"`
+}
+
+func handleRandomChoice(ctx context.Context, req *mcp.CallToolRequest, param RandomChoiceParams) (*mcp.CallToolResult, RandomChoiceResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			RandomChoiceResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	count := max(param.Count, 1)
+	var indices []int
+	seed, sequence, source, err := randomDraw(req.Session, param.Seed, param.Secure, func(src randomSource) error {
+		var err error
+		switch {
+		case param.Weights == nil && param.Unique:
+			indices, err = partialShuffle(src, len(param.Items), count)
+		case param.Weights == nil:
+			indices = make([]int, count)
+			for i := range indices {
+				if indices[i], err = src.uniform(0, len(param.Items)-1); err != nil {
+					break
+				}
+			}
+		case param.Unique:
+			indices = weightedSampleWithoutReplacement(src, param.Weights, count)
+		default:
+			indices = weightedSample(src, param.Weights, count)
+		}
+		return err
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Generation error: %v", err)),
+			RandomChoiceResult{}, fmt.Errorf("generation error: %v", err)
+	}
+
+	result := RandomChoiceResult{Indices: indices, Seed: seed, Sequence: sequence}
+	result.Choices = make([]string, len(indices))
+	for i, k := range indices {
+		result.Choices[i] = param.Items[k]
+	}
+	result.Choice = result.Choices[0]
+
+	text := fmt.Sprintf("Chose: %s (%s)", result.Choice, source)
+	if param.Count > 0 {
+		text = fmt.Sprintf("Chose %d items from %d (%s): %s", len(result.Choices), len(param.Items), source, formatItems(result.Choices, maxListedNumbers))
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// partialShuffle returns the first count positions of a uniformly random
+// permutation of [0, n), found by running count steps of Fisher–Yates.
+func partialShuffle(src randomSource, n, count int) ([]int, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := range count {
+		k, err := src.uniform(i, n-1)
+		if err != nil {
+			return nil, err
+		}
+		perm[i], perm[k] = perm[k], perm[i]
+	}
+	return perm[:count], nil
+}
+
+// weightedSample draws count positions with replacement, each with
+// probability proportional to its weight, by binary search on the running
+// sums of the weights.
+func weightedSample(src randomSource, weights []float64, count int) []int {
+	cumulative := make([]float64, len(weights))
+	total, last := 0.0, 0
+	for i, w := range weights {
+		total += w
+		cumulative[i] = total
+		if w > 0 {
+			last = i
+		}
+	}
+	indices := make([]int, count)
+	for i := range indices {
+		u := src.rand.Float64() * total
+		// The first position whose running sum exceeds u; zero weights never
+		// raise the sum and so are never picked.
+		// Rounding can push u up to total, which the last positive weight
+		// then absorbs.
+		k := sort.Search(len(cumulative), func(j int) bool { return cumulative[j] > u })
+		indices[i] = min(k, last)
+	}
+	return indices
+}
+
+// weightedSampleWithoutReplacement draws count distinct positions as if one
+// at a time, each with probability proportional to its weight among those
+// not yet drawn. It uses the keys u^(1/w) of Efraimidis and Spirakis (2006):
+// the positions with the largest keys, in descending order of key, have
+// exactly that distribution. Keys are compared as log(u)/w to avoid
+// underflow for small weights.
+func weightedSampleWithoutReplacement(src randomSource, weights []float64, count int) []int {
+	type keyed struct {
+		index int
+		key   float64
+	}
+	keys := make([]keyed, 0, len(weights))
+	for i, w := range weights {
+		if w > 0 {
+			u := 1 - src.rand.Float64() // in (0, 1], so log(u) is finite
+			keys = append(keys, keyed{i, math.Log(u) / w})
+		}
+	}
+	slices.SortStableFunc(keys, func(a, b keyed) int {
+		switch {
+		case a.key > b.key:
+			return -1
+		case a.key < b.key:
+			return 1
+		}
+		return 0
+	})
+	indices := make([]int, count)
+	for i := range indices {
+		indices[i] = keys[i].index
+	}
+	return indices
+}
+
+// ShuffleParams defines the parameters for the shuffle tool.
+type ShuffleParams struct {
+	Items  []string `json:"items" jsonschema:"the items to shuffle"`
+	Seed   *int64   `json:"seed,omitempty" jsonschema:"reseed this session's random stream for reproducible results; later calls without a seed continue the sequence"`
+	Secure bool     `json:"secure,omitempty" jsonschema:"draw from the cryptographically secure generator (crypto/rand) instead of the session stream; cannot be seeded"`
+}
+
+func (p ShuffleParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Items,
+			validation.Required,
+			validation.Length(1, maxRandomCount),
+		),
+		validation.Field(&p.Seed,
+			validation.When(p.Secure, validation.Nil.Error("cannot be used with secure")),
+		),
+	)
+}
+
+// ShuffleResult defines the result for the shuffle tool.
+type ShuffleResult struct {
+	Items    []string `json:"items" jsonschema:"the items in shuffled order"`
+	Order    []int    `json:"order" jsonschema:"0-based position in the input of each shuffled item"`
+	Seed     *int64   `json:"seed,omitempty" jsonschema:"seed of this session's random stream; pass it back to reproduce the sequence; omitted in secure mode"`
+	Sequence int      `json:"sequence,omitempty" jsonschema:"number of calls drawing from the stream since it was seeded, including this one; omitted in secure mode"`
+}
+
+func handleShuffle(ctx context.Context, req *mcp.CallToolRequest, param ShuffleParams) (*mcp.CallToolResult, ShuffleResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			ShuffleResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	var order []int
+	seed, sequence, source, err := randomDraw(req.Session, param.Seed, param.Secure, func(src randomSource) error {
+		var err error
+		order, err = partialShuffle(src, len(param.Items), len(param.Items))
+		return err
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Generation error: %v", err)),
+			ShuffleResult{}, fmt.Errorf("generation error: %v", err)
+	}
+
+	result := ShuffleResult{Order: order, Seed: seed, Sequence: sequence}
+	result.Items = make([]string, len(order))
+	for i, k := range order {
+		result.Items[i] = param.Items[k]
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Shuffled %d items (%s): %s", len(order), source, formatItems(result.Items, maxListedNumbers))}},
+	}, result, nil
+}
+
+// formatItems lists up to limit items, noting how many more there are.
+func formatItems(items []string, limit int) string {
+	text := strings.Join(items[:min(len(items), limit)], ", ")
+	if len(items) > limit {
+		text += fmt.Sprintf(", ... (%d more)", len(items)-limit)
+	}
+	return text
+}
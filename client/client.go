@@ -65,14 +65,90 @@ func main() {
 	log.Println("=== Testing Calculate Tool ===")
 	testCalculateTool(ctx, session)
 
+	// Test evaluate tool
+	log.Println("\n=== Testing Evaluate Tool ===")
+	testEvaluateTool(ctx, session)
+
+	// Test scientific tool
+	log.Println("\n=== Testing Scientific Tool ===")
+	testScientificTool(ctx, session)
+
+	// Test statistics tool
+	log.Println("\n=== Testing Statistics Tool ===")
+	testStatisticsTool(ctx, session)
+
+	// Test matrix tool
+	log.Println("\n=== Testing Matrix Tool ===")
+	testMatrixTool(ctx, session)
+
+	// Test convert-units tool
+	log.Println("\n=== Testing Convert Units Tool ===")
+	testConvertUnitsTool(ctx, session)
+
+	// Test currency tools
+	log.Println("\n=== Testing Currency Tools ===")
+	testCurrencyTools(ctx, session)
+
 	// Test generate-random-number tool
 	log.Println("\n=== Testing Generate Random Number Tool ===")
 	testGenerateRandomNumber(ctx, session)
 
+	// Test generate-random-token tool
+	log.Println("\n=== Testing Generate Random Token Tool ===")
+	testGenerateRandomToken(ctx, session)
+
+	// Test random choice, shuffle and dice tools
+	log.Println("\n=== Testing Random Choice, Shuffle and Dice Tools ===")
+	testRandomChoiceTools(ctx, session)
+
+	// Test distribution tool
+	log.Println("\n=== Testing Distribution Tool ===")
+	testDistributionTool(ctx, session)
+
+	// Test symbolic tool
+	log.Println("\n=== Testing Symbolic Tool ===")
+	testSymbolicTool(ctx, session)
+
+	// Test solve tool
+	log.Println("\n=== Testing Solve Tool ===")
+	testSolveTool(ctx, session)
+
+	// Test calculus tool
+	log.Println("\n=== Testing Calculus Tool ===")
+	testCalculusTool(ctx, session)
+
+	// Test complex tool
+	log.Println("\n=== Testing Complex Tool ===")
+	testComplexTool(ctx, session)
+
+	// Test number theory tool
+	log.Println("\n=== Testing Number Theory Tool ===")
+	testNumberTheoryTool(ctx, session)
+
+	// Test bits tool
+	log.Println("\n=== Testing Bits Tool ===")
+	testBitsTool(ctx, session)
+
+	// Test datetime tool
+	log.Println("\n=== Testing Datetime Tool ===")
+	testDateTimeTool(ctx, session)
+
+	// Test finance tool
+	log.Println("\n=== Testing Finance Tool ===")
+	testFinanceTool(ctx, session)
+
+	// Test percent and ratio tool
+	log.Println("\n=== Testing Percent and Ratio Tool ===")
+	testPercentRatioTool(ctx, session)
+
 	// Test math constants resource
 	log.Println("\n=== Testing Math Constants Resource ===")
 	testMathConstants(ctx, session)
 
+	// Test unit registry resource
+	log.Println("\n=== Testing Unit Registry Resource ===")
+	testUnitRegistry(ctx, session)
+
 	// Test prompts
 	log.Println("\n=== Testing Prompts ===")
 	testPrompts(ctx, session)
@@ -104,13 +180,22 @@ func findServerBinary() string {
 func testCalculateTool(ctx context.Context, session *mcp.ClientSession) {
 	tests := []struct {
 		operation string
-		num1      float32
-		num2      float32
+		num1      float64
+		num2      float64
+		precision string
 	}{
-		{"add", 10, 5},
-		{"subtract", 10, 5},
-		{"multiply", 10, 5},
-		{"divide", 10, 5},
+		{"add", 10, 5, ""},
+		{"subtract", 10, 5, ""},
+		{"multiply", 10, 5, ""},
+		{"divide", 10, 5, ""},
+		{"add", 16777217, 1, ""},
+		{"divide", 1, 3, "bigfloat"},
+		{"add", 0.1, 0.2, "rational"},
+		{"add", 0.1, 0.2, "decimal"},
+		{"modulo", -7, 3, ""},
+		{"integer-divide", -7, 2, ""},
+		{"power", 2, 10, ""},
+		{"percent-of", 15, 80, ""},
 	}
 
 	for _, test := range tests {
@@ -122,6 +207,9 @@ func testCalculateTool(ctx context.Context, session *mcp.ClientSession) {
 				"num2":      test.num2,
 			},
 		}
+		if test.precision != "" {
+			param.Arguments.(map[string]any)["precision"] = test.precision
+		}
 
 		res, err := session.CallTool(ctx, &param)
 		if err != nil {
@@ -141,6 +229,261 @@ func testCalculateTool(ctx context.Context, session *mcp.ClientSession) {
 			log.Printf("  %s: %s", test.operation, c.(*mcp.TextContent).Text)
 		}
 	}
+
+	listTests := []struct {
+		operation string
+		operands  []float64
+	}{
+		{"sum", []float64{0.1, 0.2, 0.3}},
+		{"average", []float64{2, 4, 4, 4, 5, 5, 7, 9}},
+		{"max", []float64{3, -1, 2}},
+		{"gcd", []float64{12, 18, 30}},
+		{"lcm", []float64{4, 6, 10}},
+		{"gcd", []float64{12}}, // should fail: gcd needs two operands
+	}
+
+	for _, test := range listTests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name: "calculate",
+			Arguments: map[string]any{
+				"operation": test.operation,
+				"operands":  test.operands,
+			},
+		})
+		if err != nil {
+			log.Printf("  Error calling calculate (%s): %v", test.operation, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s%v: %s", test.operation, test.operands, c.(*mcp.TextContent).Text)
+		}
+	}
+
+	textTests := []struct {
+		operands  []string
+		locale    string
+		precision string
+	}{
+		{[]string{"1,234.5", "½"}, "", "rational"},
+		{[]string{"1.234,5", "3/4"}, "de", "rational"},
+		{[]string{"0x1F", "1e-9"}, "", "rational"},
+		{[]string{"0.1", "2/10"}, "", "float64"},
+		{[]string{"15%", "12abc"}, "", "rational"}, // should fail at position 3
+	}
+
+	for _, test := range textTests {
+		args := map[string]any{
+			"operation":     "add",
+			"operands_text": test.operands,
+			"precision":     test.precision,
+		}
+		if test.locale != "" {
+			args["locale"] = test.locale
+		}
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "calculate", Arguments: args})
+		if err != nil {
+			log.Printf("  Error calling calculate (%q): %v", test.operands, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  add%q: %s", test.operands, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testEvaluateTool(ctx context.Context, session *mcp.ClientSession) {
+	expressions := []string{
+		"(3+4)*2^5/sqrt(7)",
+		"-2^2 + 2*pi",
+		"max(1, 5, 3) % 4",
+	}
+
+	for _, expression := range expressions {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "evaluate",
+			Arguments: map[string]any{"expression": expression},
+		})
+		if err != nil {
+			log.Printf("  Error evaluating %q: %v", expression, err)
+			continue
+		}
+
+		if res.IsError {
+			log.Printf("  Evaluate (%s) returned error", expression)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", expression, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testScientificTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"sin(30°)", map[string]any{"function": "sin", "x": 30, "angle_unit": "degrees"}},
+		{"log2(1024)", map[string]any{"function": "log2", "x": 1024}},
+		{"cube root of -27", map[string]any{"function": "root", "x": -27, "y": 3}},
+		{"factorial(10)", map[string]any{"function": "factorial", "x": 10}},
+		{"asin(2) (domain error)", map[string]any{"function": "asin", "x": 2}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "scientific",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testStatisticsTool(ctx context.Context, session *mcp.ClientSession) {
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "statistics",
+		Arguments: map[string]any{
+			"data":        []any{2, 4, 4, 4, 5, 5, "7", "9"},
+			"percentiles": []float64{10, 90},
+		},
+	})
+	if err != nil {
+		log.Printf("  Error calling statistics: %v", err)
+		return
+	}
+
+	if res.IsError {
+		log.Printf("  Statistics returned error")
+		return
+	}
+
+	for _, c := range res.Content {
+		log.Printf("  %s", c.(*mcp.TextContent).Text)
+	}
+}
+
+func testMatrixTool(ctx context.Context, session *mcp.ClientSession) {
+	a := [][]float64{{4, 7}, {2, 6}}
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"multiply", map[string]any{"operation": "multiply", "a": a, "b": [][]float64{{1, 0}, {0, 1}}}},
+		{"determinant", map[string]any{"operation": "determinant", "a": a}},
+		{"inverse", map[string]any{"operation": "inverse", "a": a}},
+		{"solve", map[string]any{"operation": "solve", "a": a, "vector": []float64{1, 2}}},
+		{"qr", map[string]any{"operation": "qr", "a": a}},
+		{"singular inverse", map[string]any{"operation": "inverse", "a": [][]float64{{1, 2}, {2, 4}}}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "matrix",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s:\n%s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testConvertUnitsTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		value    any
+		from, to string
+	}{
+		{60, "mph", "m/s"},
+		{"1,500", "m", "km"},
+		{1, "kWh", "J"},
+		{100, "degC", "degF"},
+		{1, "kg·m/s²", "N"},
+		{1, "kg", "m"},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name: "convert-units",
+			Arguments: map[string]any{
+				"value": test.value,
+				"from":  test.from,
+				"to":    test.to,
+			},
+		})
+		if err != nil {
+			log.Printf("  Error converting %s to %s: %v", test.from, test.to, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s -> %s: %s", test.from, test.to, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testCurrencyTools(ctx context.Context, session *mcp.ClientSession) {
+	// set-currency-rates is only registered when the server has an admin
+	// token; otherwise the conversions use the server's RATES_FILE.
+	if token := os.Getenv("CURRENCY_ADMIN_TOKEN"); token == "" {
+		log.Println("  Skipping set-currency-rates: CURRENCY_ADMIN_TOKEN is not set")
+	} else {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name: "set-currency-rates",
+			Arguments: map[string]any{
+				"base":  "USD",
+				"as_of": "2026-10-01T00:00:00Z",
+				"rates": map[string]string{"EUR": "0.92", "JPY": "149.30", "KWD": "0.3071"},
+				"token": token,
+			},
+		})
+		if err != nil {
+			log.Printf("  Error setting currency rates: %v", err)
+			return
+		}
+		for _, c := range res.Content {
+			log.Printf("  set-currency-rates: %s", c.(*mcp.TextContent).Text)
+		}
+	}
+
+	conversions := []struct {
+		amount   string
+		from, to string
+	}{
+		{"100", "EUR", "JPY"},
+		{"19.99", "USD", "KWD"},
+	}
+	for _, conversion := range conversions {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name: "convert-currency",
+			Arguments: map[string]any{
+				"amount_text": conversion.amount,
+				"from":        conversion.from,
+				"to":          conversion.to,
+			},
+		})
+		if err != nil {
+			log.Printf("  Error converting %s to %s: %v", conversion.from, conversion.to, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s", c.(*mcp.TextContent).Text)
+		}
+	}
 }
 
 func testGenerateRandomNumber(ctx context.Context, session *mcp.ClientSession) {
@@ -175,6 +518,68 @@ func testGenerateRandomNumber(ctx context.Context, session *mcp.ClientSession) {
 				"distribution": "exponential",
 			},
 		},
+		{
+			name: "seeded",
+			args: map[string]any{
+				"min":  1,
+				"max":  100,
+				"seed": 42,
+			},
+		},
+		{
+			name: "seeded again (same number)",
+			args: map[string]any{
+				"min":  1,
+				"max":  100,
+				"seed": 42,
+			},
+		},
+		{
+			name: "batch of 100 with histogram",
+			args: map[string]any{
+				"count":        100,
+				"distribution": "normal",
+				"histogram":    true,
+				"bins":         5,
+			},
+		},
+		{
+			name: "poisson",
+			args: map[string]any{
+				"distribution": "poisson",
+				"lambda":       4,
+				"count":        10,
+			},
+		},
+		{
+			name: "truncated normal (float)",
+			args: map[string]any{
+				"distribution": "normal",
+				"mean":         0,
+				"stddev":       1,
+				"min":          -1,
+				"max":          1,
+				"output":       "float",
+				"count":        5,
+			},
+		},
+		{
+			name: "beta without alpha (should fail)",
+			args: map[string]any{
+				"distribution": "beta",
+				"beta":         2,
+			},
+		},
+		{
+			name: "lottery draw (unique, sorted)",
+			args: map[string]any{
+				"min":    1,
+				"max":    49,
+				"count":  6,
+				"unique": true,
+				"sorted": true,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -200,6 +605,395 @@ func testGenerateRandomNumber(ctx context.Context, session *mcp.ClientSession) {
 	}
 }
 
+func testGenerateRandomToken(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{
+			name: "default (32 hex characters)",
+			args: map[string]any{},
+		},
+		{
+			name: "base64url",
+			args: map[string]any{"format": "base64url", "length": 22},
+		},
+		{
+			name: "uuidv4",
+			args: map[string]any{"format": "uuidv4"},
+		},
+		{
+			name: "uuidv7",
+			args: map[string]any{"format": "uuidv7"},
+		},
+		{
+			name: "passphrase",
+			args: map[string]any{"format": "passphrase", "length": 5},
+		},
+		{
+			name: "digits",
+			args: map[string]any{"format": "string", "length": 8, "alphabet": "0123456789"},
+		},
+		{
+			name: "repeated alphabet (should fail)",
+			args: map[string]any{"format": "string", "alphabet": "aab"},
+		},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "generate-random-token",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		if res.IsError {
+			log.Printf("  %s returned error: %s", test.name, res.Content[0].(*mcp.TextContent).Text)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testRandomChoiceTools(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+	}{
+		{"weighted choice", "random-choice", map[string]any{"items": []string{"common", "rare", "epic"}, "weights": []float64{80, 15, 5}, "count": 5, "seed": 7}},
+		{"draw without replacement", "random-choice", map[string]any{"items": []string{"alice", "bob", "carol", "dave"}, "count": 2, "unique": true}},
+		{"shuffle", "shuffle", map[string]any{"items": []string{"1", "2", "3", "4", "5"}, "seed": 7}},
+		{"4d6 keep highest 3, plus 2", "roll-dice", map[string]any{"notation": "4d6kh3+2", "seed": 7}},
+		{"advantage", "roll-dice", map[string]any{"notation": "2d20kh1"}},
+		{"bad notation (should fail)", "roll-dice", map[string]any{"notation": "4d6x"}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      test.tool,
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testDistributionTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"standard normal cdf at 1.96 (expect 0.9750021048517795)", map[string]any{"distribution": "normal", "function": "cdf", "x": 1.96}},
+		{"standard normal quantile at 0.975 (expect 1.959963984540054)", map[string]any{"distribution": "normal", "function": "quantile", "probability": 0.975}},
+		{"Poisson(4) 95th percentile (expect 8)", map[string]any{"distribution": "poisson", "function": "quantile", "probability": 0.95, "lambda": 4}},
+		{"Binomial(10, 0.3) P(X ≤ 3) (expect 0.6496107184)", map[string]any{"distribution": "binomial", "function": "cdf", "x": 3, "n": 10, "p": 0.3}},
+		{"Student t, df=10, quantile at 0.975 (expect 2.228138851986274)", map[string]any{"distribution": "student-t", "function": "quantile", "probability": 0.975, "df": 10}},
+		{"chi-squared, df=2, survival at 3 (expect e^-1.5 = 0.22313016014843)", map[string]any{"distribution": "chi-squared", "function": "survival", "x": 3, "df": 2}},
+		{"beta without beta parameter (should fail)", map[string]any{"distribution": "beta", "function": "cdf", "x": 0.5, "alpha": 2}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "distribution",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testSymbolicTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"expand (x + 1)^3 (expect x^3 + 3*x^2 + 3*x + 1)", map[string]any{"expression": "(x + 1)^3", "operation": "expand"}},
+		{"factor x^3 - 6*x^2 + 11*x - 6 (expect (x - 1)*(x - 2)*(x - 3))", map[string]any{"expression": "x^3 - 6*x^2 + 11*x - 6", "operation": "factor"}},
+		{"factor x^2 - y^2 (expect (x + y)*(x - y))", map[string]any{"expression": "x^2 - y^2", "operation": "factor"}},
+		{"d/dx x^2*sin(x) (expect x^2*cos(x) + 2*x*sin(x))", map[string]any{"expression": "x^2*sin(x)", "operation": "differentiate"}},
+		{"simplify x + 2*x - x*x/x (expect 2*x)", map[string]any{"expression": "x + 2*x - x*x/x", "operation": "simplify"}},
+		{"substitute x=3, y=1/3 into x^2 + 2*x*y (expect 11)", map[string]any{"expression": "x^2 + 2*x*y", "operation": "substitute", "values": map[string]string{"x": "3", "y": "1/3"}}},
+		{"differentiate x*y without variable (should fail)", map[string]any{"expression": "x*y", "operation": "differentiate"}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "symbolic",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testSolveTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"3x^2 - 5x + 2 = 0 (expect 2/3 and 1)", map[string]any{"equation": "3x^2 - 5x + 2 = 0"}},
+		{"x^3 - 3x + 1 = 0 (expect three real roots, 2*cos(2*pi/9) ≈ 1.532)", map[string]any{"equation": "x^3 - 3x + 1 = 0"}},
+		{"x^4 + 1 = 0 (expect ±sqrt(2)/2 ± sqrt(2)/2*i)", map[string]any{"equation": "x^4 + 1 = 0"}},
+		{"cos(x) = x in [0, 1] (expect 0.739085133215161)", map[string]any{"equation": "cos(x) = x", "min": 0, "max": 1}},
+		{"2x + y = 3, x - y = 0 (expect x = 1, y = 1)", map[string]any{"equations": []string{"2x + y = 3", "x - y = 0"}}},
+		{"Newton on x^3 - 2x + 2 from 0 (should fail: cycles between 0 and 1)", map[string]any{"equation": "x^3 - 2x + 2", "method": "newton", "guess": 0}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "solve",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testCalculusTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"∫ exp(-x^2) over the real line (expect sqrt(pi) ≈ 1.772453850905516)", map[string]any{"expression": "exp(-x^2)", "operation": "integrate", "lower": "-inf", "upper": "inf"}},
+		{"∫ 1/sqrt(x) from 0 to 1 (expect 2)", map[string]any{"expression": "1/sqrt(x)", "operation": "integrate", "lower": 0, "upper": 1}},
+		{"∫ sin(x) from 0 to pi by Simpson (expect 2)", map[string]any{"expression": "sin(x)", "operation": "integrate", "lower": 0, "upper": "pi", "method": "simpson"}},
+		{"second derivative of exp(x) at 1 (expect e)", map[string]any{"expression": "exp(x)", "operation": "derivative", "point": 1, "order": 2}},
+		{"limit of sin(x)/x at 0 (expect 1)", map[string]any{"expression": "sin(x)/x", "operation": "limit", "point": 0}},
+		{"limit of (1 + 1/x)^x at infinity (expect e)", map[string]any{"expression": "(1 + 1/x)^x", "operation": "limit", "point": "inf"}},
+		{"limit of abs(x)/x at 0 (should fail: sides differ)", map[string]any{"expression": "abs(x)/x", "operation": "limit", "point": 0}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "calculus",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testComplexTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"(3+4i) + (1-2i) (expect 4+2i)", map[string]any{"operation": "add", "a": "3+4i", "b": map[string]any{"re": 1, "im": -2}}},
+		{"1 / i (expect -i)", map[string]any{"operation": "divide", "a": 1, "b": "i"}},
+		{"argument of 3+4i in degrees (expect 53.13)", map[string]any{"operation": "argument", "a": "3+4i", "angle_unit": "degrees"}},
+		{"rectangular 2∠90° (expect 2i)", map[string]any{"operation": "rectangular", "modulus": 2, "argument": 90, "angle_unit": "degrees"}},
+		{"exp(πi) (expect -1)", map[string]any{"operation": "exp", "a": "3.141592653589793i"}},
+		{"i^i (expect 0.2079)", map[string]any{"operation": "pow", "a": "i", "b": "i"}},
+		{"log(0) (should fail)", map[string]any{"operation": "log", "a": 0}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "complex",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testNumberTheoryTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"is 2^127 - 1 prime (expect probably prime)", map[string]any{"operation": "is-prime", "n": "170141183460469231731687303715884105727"}},
+		{"factorize 2^64 + 1 (expect 274177 × 67280421310721)", map[string]any{"operation": "factorize", "n": "18446744073709551617"}},
+		{"extended gcd of 240 and 46 (expect 2 = 240·(-9) + 46·47)", map[string]any{"operation": "extended-gcd", "numbers": []string{"240", "46"}}},
+		{"4^13 mod 497 (expect 445)", map[string]any{"operation": "mod-pow", "n": "4", "exponent": "13", "modulus": "497"}},
+		{"totient of 36 (expect 12)", map[string]any{"operation": "totient", "n": "36"}},
+		{"next prime after 10^20 (expect 100000000000000000039)", map[string]any{"operation": "next-prime", "n": "100000000000000000000"}},
+		{"inverse of 6 mod 9 (should fail: gcd is 3)", map[string]any{"operation": "mod-inverse", "n": "6", "modulus": "9"}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "number-theory",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testBitsTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"-1 as a 16-bit word (expect 0xFFFF)", map[string]any{"operation": "convert", "value": "-1", "width": 16}},
+		{"zz in base 36 to base 2 (expect 10100001111)", map[string]any{"operation": "convert", "value": "zz", "base": 36, "to_base": 2}},
+		{"0b1100 AND 0b1010 (expect 0b0000_1000)", map[string]any{"operation": "and", "value": "0b1100", "other": "0b1010", "width": 8}},
+		{"-128 >> 3, arithmetic (expect -16)", map[string]any{"operation": "shift-right", "value": "-128", "amount": 3, "width": 8, "signed": true}},
+		{"rotate 0x81 left by 1 (expect 0x03)", map[string]any{"operation": "rotate-left", "value": "0x81", "amount": 1, "width": 8}},
+		{"popcount of 0xF0F0 (expect 8)", map[string]any{"operation": "popcount", "value": "0xF0F0", "width": 16}},
+		{"decode 0x3F800000 (expect 1 as binary32)", map[string]any{"operation": "decode", "value": "0x3F800000"}},
+		{"256 in 8 bits (should fail)", map[string]any{"operation": "convert", "value": "256", "width": 8}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "bits",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testDateTimeTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"90 business days after 2026-03-01 (expect 2026-07-03)", map[string]any{"operation": "add-business-days", "start": "2026-03-01", "days": 90}},
+		{"business days in May 2026 with a holiday (expect 20)", map[string]any{"operation": "business-days", "start": "2026-05-01", "end": "2026-06-01", "holidays": []string{"2026-05-25"}}},
+		{"2026-01-31 + P1M (expect 2026-02-28)", map[string]any{"operation": "add", "start": "2026-01-31", "duration": "P1M"}},
+		{"2026-03-28T12:00 + 24h in Berlin (expect 13:00 CEST)", map[string]any{"operation": "add", "start": "2026-03-28T12:00", "duration": "24h", "timezone": "Europe/Berlin"}},
+		{"difference 2024-01-31 to 2026-03-01 (expect P2Y1M1D)", map[string]any{"operation": "difference", "start": "2024-01-31", "end": "2026-03-01"}},
+		{"09:00 New York in Kolkata (expect 18:30)", map[string]any{"operation": "convert", "start": "2026-07-01T09:00", "timezone": "America/New_York", "to_timezone": "Asia/Kolkata"}},
+		{"ISO week of 2027-01-01 (expect 2026-W53-5)", map[string]any{"operation": "iso-week", "start": "2027-01-01"}},
+		{"unknown time zone (should fail)", map[string]any{"operation": "convert", "start": "now", "to_timezone": "Mars/Olympus"}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "datetime",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testFinanceTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"10000 at 5% monthly for 10 years (expect 16470.09)", map[string]any{"operation": "compound-interest", "present_value": 10000, "rate": 0.05, "years": 10, "periods_per_year": 12}},
+		{"present value of 10000 in 10 years at 5% (expect 6139.13)", map[string]any{"operation": "present-value", "future_value": 10000, "rate": 0.05, "periods": 10, "periods_per_year": 1}},
+		{"mortgage payment, 200000 at 6.5% over 30 years (expect 1264.14)", map[string]any{"operation": "payment", "present_value": "200000", "rate": 0.065, "periods": 360}},
+		{"amortization of 1000 at 12% over 6 months", map[string]any{"operation": "amortization", "present_value": 1000, "rate": 0.12, "periods": 6}},
+		{"NPV at 10% (expect -21.04)", map[string]any{"operation": "npv", "rate": 0.1, "cash_flows": []any{-1000, 300, 400, 500}}},
+		{"IRR (expect 8.896%)", map[string]any{"operation": "irr", "cash_flows": []any{-1000, 300, 400, 500}}},
+		{"XIRR (expect 37.336%)", map[string]any{"operation": "xirr", "cash_flows": []any{-10000, 2750, 4250, 3250, 2750}, "dates": []string{"2008-01-01", "2008-03-01", "2008-10-30", "2009-02-15", "2009-04-01"}}},
+		{"IRR without an outflow (should fail)", map[string]any{"operation": "irr", "cash_flows": []any{100, 200}}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "finance",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
+func testPercentRatioTool(ctx context.Context, session *mcp.ClientSession) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"20% of 150 (expect 30)", map[string]any{"operation": "percent-of", "value": 150, "percent": 20}},
+		{"percent change from 80 to 100 (expect +25%)", map[string]any{"operation": "percent-change", "from": 80, "to": 100}},
+		{"add 20% VAT to 19.99 (expect 23.99)", map[string]any{"operation": "add-percent", "value": "19.99", "percent": 20, "scale": 2}},
+		{"reverse 20% VAT from 120 (expect 100)", map[string]any{"operation": "reverse-percent", "value": 120, "percent": 20}},
+		{"split 100 as 3:2:1 (expect 50.00 + 33.33 + 16.67)", map[string]any{"operation": "allocate", "value": 100, "ratio": []any{3, 2, 1}}},
+		{"simplify 1.5:3 (expect 1:2)", map[string]any{"operation": "simplify-ratio", "ratio": []any{1.5, 3}}},
+		{"3.14159 to 3 significant figures (expect 3.14)", map[string]any{"operation": "round", "value": 3.14159, "significant": 3}},
+		{"percent change from zero (should fail)", map[string]any{"operation": "percent-change", "from": 0, "to": 10}},
+	}
+
+	for _, test := range tests {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "percent-and-ratio",
+			Arguments: test.args,
+		})
+		if err != nil {
+			log.Printf("  Error (%s): %v", test.name, err)
+			continue
+		}
+
+		for _, c := range res.Content {
+			log.Printf("  %s: %s", test.name, c.(*mcp.TextContent).Text)
+		}
+	}
+}
+
 func testMathConstants(ctx context.Context, session *mcp.ClientSession) {
 	// Test reading all constants
 	res, err := session.ReadResource(ctx, &mcp.ReadResourceParams{
@@ -231,6 +1025,20 @@ func testMathConstants(ctx context.Context, session *mcp.ClientSession) {
 	}
 }
 
+func testUnitRegistry(ctx context.Context, session *mcp.ClientSession) {
+	for _, uri := range []string{"units://registry/mph", "units://registry/kg·m/s²"} {
+		res, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+		if err != nil {
+			log.Printf("  Error reading %s: %v", uri, err)
+			continue
+		}
+
+		if len(res.Contents) > 0 {
+			log.Printf("  %s = %s", uri, res.Contents[0].Text)
+		}
+	}
+}
+
 func testPrompts(ctx context.Context, session *mcp.ClientSession) {
 	// Test calculation explanation prompt
 	log.Println("  Testing calculation-explanation prompt:")
@@ -250,6 +1058,41 @@ func testPrompts(ctx context.Context, session *mcp.ClientSession) {
 		}
 	}
 
+	for _, level := range []string{"elementary", "high-school", "university"} {
+		log.Printf("\n  Testing calculation-explanation prompt (%s):", level)
+		res, err = session.GetPrompt(ctx, &mcp.GetPromptParams{
+			Name: "calculation-explanation",
+			Arguments: map[string]string{
+				"expression": "503 - 278 + 127 / 4 * (2 + 1)",
+				"level":      level,
+			},
+		})
+		if err != nil {
+			log.Printf("    Error: %v", err)
+			continue
+		}
+		for _, msg := range res.Messages {
+			log.Printf("    %s: %s", msg.Role, msg.Content.(*mcp.TextContent).Text)
+		}
+	}
+
+	log.Println("\n  Testing calculation-explanation prompt with a malformed number:")
+	res, err = session.GetPrompt(ctx, &mcp.GetPromptParams{
+		Name: "calculation-explanation",
+		Arguments: map[string]string{
+			"operation": "add",
+			"num1":      "12abc",
+			"num2":      "3",
+		},
+	})
+	if err != nil {
+		log.Printf("    Error: %v", err)
+	} else {
+		for _, msg := range res.Messages {
+			log.Printf("    %s: %s", msg.Role, msg.Content.(*mcp.TextContent).Text)
+		}
+	}
+
 	// Test generate-random-number-prompt
 	log.Println("\n  Testing generate-random-number-prompt:")
 	res, err = session.GetPrompt(ctx, &mcp.GetPromptParams{
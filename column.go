@@ -0,0 +1,445 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Written ("column") methods for the calculation-explanation prompt. Each
+// works on the decimal digits of non-negative operands and returns a layout
+// for a monospaced block plus the sentences describing it. Sentences for the
+// individual columns are only produced when narrate is set.
+
+const (
+	// maxColumnDigits bounds the digits of an operand worked in columns.
+	maxColumnDigits = 15
+	// maxColumnFraction bounds the fractional digits of such an operand.
+	maxColumnFraction = 6
+	// maxMultiplierDigits bounds the partial products of long multiplication.
+	maxMultiplierDigits = 6
+	// maxDivisorDigits bounds the divisor of long division.
+	maxDivisorDigits = 12
+	// maxDivisionDecimals bounds the decimal places long division works out.
+	maxDivisionDecimals = 8
+)
+
+// columnNumber is a non-negative decimal number as its digits before and
+// after the decimal point.
+type columnNumber struct {
+	whole, frac string
+}
+
+// toColumnNumber returns the decimal digits of |x|, and false if x has too
+// many digits to be worked by hand.
+func toColumnNumber(x float64) (columnNumber, bool) {
+	if math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x) >= 1e15 {
+		return columnNumber{}, false
+	}
+	s := strconv.FormatFloat(math.Abs(x), 'f', -1, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > maxColumnFraction || len(whole)+len(frac) > maxColumnDigits {
+		return columnNumber{}, false
+	}
+	return columnNumber{whole, frac}, true
+}
+
+func (n columnNumber) String() string {
+	if n.frac == "" {
+		return n.whole
+	}
+	return n.whole + "." + n.frac
+}
+
+// digits returns n times 10^frac as a digit string; frac must not be less
+// than the fractional digits of n.
+func (n columnNumber) digits(frac int) string {
+	return n.whole + n.frac + strings.Repeat("0", frac-len(n.frac))
+}
+
+// fromDigits places a decimal point frac digits from the right of the digit
+// string s and drops redundant zeros.
+func fromDigits(s string, frac int) columnNumber {
+	if len(s) <= frac {
+		s = strings.Repeat("0", frac-len(s)+1) + s
+	}
+	n := columnNumber{strings.TrimLeft(s[:len(s)-frac], "0"), strings.TrimRight(s[len(s)-frac:], "0")}
+	if n.whole == "" {
+		n.whole = "0"
+	}
+	return n
+}
+
+// withPoint renders the digit string s with a decimal point frac digits from
+// the right. Unlike fromDigits it keeps trailing zeros, so that the columns
+// of a layout line up, and drops leading zeros other than one before the
+// point.
+func withPoint(s string, frac int) string {
+	if len(s) <= frac {
+		s = strings.Repeat("0", frac-len(s)+1) + s
+	}
+	whole := strings.TrimLeft(s[:len(s)-frac], "0")
+	if whole == "" {
+		whole = "0"
+	}
+	if frac == 0 {
+		return whole
+	}
+	return whole + "." + s[len(s)-frac:]
+}
+
+// placeName names the decimal place k digits left of the ones (k = 0) or,
+// for negative k, right of the decimal point.
+func placeName(k int) string {
+	names := []string{"ones", "tens", "hundreds", "thousands", "ten-thousands", "hundred-thousands", "millions"}
+	fractions := []string{"tenths", "hundredths", "thousandths", "ten-thousandths", "hundred-thousandths", "millionths"}
+	switch {
+	case k >= 0 && k < len(names):
+		return names[k]
+	case k < 0 && -k <= len(fractions):
+		return fractions[-k-1]
+	}
+	return fmt.Sprintf("10^%d", k)
+}
+
+// rightAlign pads each row on the left to the width of the widest.
+func rightAlign(rows []string) []string {
+	width := 0
+	for _, r := range rows {
+		width = max(width, len([]rune(r)))
+	}
+	out := make([]string, len(rows))
+	for i, r := range rows {
+		out[i] = strings.Repeat(" ", width-len([]rune(r))) + r
+	}
+	return out
+}
+
+// stackedLayout lays out a column sum or difference: an optional row of
+// carries, the operands with the operator in front of the second, a rule
+// and the result.
+func stackedLayout(carries, a, op, b, result string) string {
+	rows := rightAlign([]string{carries, a, op + " " + b, result})
+	rule := strings.Repeat("-", len([]rune(rows[0])))
+	rows = []string{strings.TrimRight(rows[0], " "), rows[1], rows[2], rule, rows[3]}
+	if strings.TrimSpace(carries) == "" {
+		rows = rows[1:]
+	}
+	return strings.Join(rows, "\n")
+}
+
+// padDigits returns the digits of a and b scaled to frac fractional digits
+// and padded with leading zeros to the same length.
+func padDigits(a, b columnNumber, frac int) (string, string) {
+	da, db := a.digits(frac), b.digits(frac)
+	n := max(len(da), len(db))
+	return strings.Repeat("0", n-len(da)) + da, strings.Repeat("0", n-len(db)) + db
+}
+
+// columnAddition adds a and b column by column from the right, carrying
+// tens to the next column.
+func columnAddition(a, b columnNumber, narrate bool) (string, []string) {
+	frac := max(len(a.frac), len(b.frac))
+	da, db := padDigits(a, b, frac)
+	n := len(da)
+
+	sum := make([]byte, n)
+	// carries[i+1] sits above column i; carries[0] is left of every digit.
+	carries := []byte(strings.Repeat(" ", n+1))
+	var steps []string
+	carry := 0
+	for i := n - 1; i >= 0; i-- {
+		x, y := int(da[i]-'0'), int(db[i]-'0')
+		s := x + y + carry
+		sum[i] = byte('0' + s%10)
+		if narrate {
+			text := fmt.Sprintf("%s: %d + %d", capitalize(placeName(n-1-i-frac)), x, y)
+			if carry > 0 {
+				text += " + 1 carried"
+			}
+			text += fmt.Sprintf(" = %d. Write %d", s, s%10)
+			if s >= 10 {
+				text += fmt.Sprintf(" and carry 1 to the %s", placeName(n-i-frac))
+			}
+			steps = append(steps, text+".")
+		}
+		carry = s / 10
+		if carry > 0 && i > 0 {
+			carries[i] = '1'
+		}
+	}
+	result := string(sum)
+	if carry > 0 {
+		result = "1" + result
+		if narrate {
+			steps = append(steps, fmt.Sprintf("Write the carried 1 in the %s place.", placeName(n-frac)))
+		}
+	}
+
+	row := string(carries)
+	if frac > 0 {
+		row = row[:len(row)-frac] + " " + row[len(row)-frac:]
+	}
+	return stackedLayout(row, withPoint(da, frac), "+", withPoint(db, frac), withPoint(result, frac)), steps
+}
+
+// columnSubtraction subtracts b from a, which must not be smaller, column by
+// column from the right, borrowing from the next column when a digit is too
+// small.
+func columnSubtraction(a, b columnNumber, narrate bool) (string, []string) {
+	frac := max(len(a.frac), len(b.frac))
+	da, db := padDigits(a, b, frac)
+	n := len(da)
+
+	diff := make([]byte, n)
+	var steps []string
+	borrow := false
+	for i := n - 1; i >= 0; i-- {
+		d, y := int(da[i]-'0'), int(db[i]-'0')
+		place, next := capitalize(placeName(n-1-i-frac)), placeName(n-i-frac)
+		var text string
+		if borrow && d == 0 {
+			// A zero cannot lend, so it borrows in turn and lends from 10.
+			text = fmt.Sprintf("%s: the 0 has to lend 1, so it borrows 1 from the %s and becomes 10 - 1 = 9. 9 - %d = %d.", place, next, y, 9-y)
+			diff[i] = byte('0' + 9 - y)
+		} else {
+			x := d
+			if borrow {
+				x--
+				text = fmt.Sprintf("%s: after lending 1 the %d is now %d. ", place, d, x)
+			} else {
+				text = place + ": "
+			}
+			borrow = x < y
+			if borrow {
+				text += fmt.Sprintf("%d is smaller than %d, so borrow 1 from the %s: %d - %d = %d.", x, y, next, x+10, y, x+10-y)
+				x += 10
+			} else {
+				text += fmt.Sprintf("%d - %d = %d.", x, y, x-y)
+			}
+			diff[i] = byte('0' + x - y)
+		}
+		if narrate && !(i == 0 && d == 0 && y == 0 && !borrow) {
+			steps = append(steps, text)
+		}
+	}
+	return stackedLayout("", withPoint(da, frac), "-", withPoint(db, frac), withPoint(string(diff), frac)), steps
+}
+
+// longMultiplication multiplies a by each digit of b, shifting each partial
+// product one place further left, and adds the partial products. Decimal
+// points are ignored until the end. It reports false when b has too many
+// digits for the layout to stay readable.
+func longMultiplication(a, b columnNumber, narrate bool) (string, []string, bool) {
+	da, db := strings.TrimLeft(a.whole+a.frac, "0"), strings.TrimLeft(b.whole+b.frac, "0")
+	if da == "" || db == "" || len(db) > maxMultiplierDigits {
+		return "", nil, false
+	}
+	frac := len(a.frac) + len(b.frac)
+	x, _ := new(big.Int).SetString(da, 10)
+
+	var steps []string
+	if frac > 0 {
+		steps = append(steps, fmt.Sprintf("Ignore the decimal points for now and multiply %s by %s.", da, db))
+	}
+	var partials []string
+	total := new(big.Int)
+	for i := len(db) - 1; i >= 0; i-- {
+		shift := len(db) - 1 - i
+		digit := int64(db[i] - '0')
+		p := new(big.Int).Mul(x, big.NewInt(digit))
+		total.Add(total, new(big.Int).Mul(p, pow10(shift)))
+		row := p.String() + strings.Repeat("0", shift)
+		partials = append(partials, row)
+		if narrate {
+			text := fmt.Sprintf("Multiply %s by the %d in the %s place: %s × %d = %s", da, digit, placeName(shift), da, digit, p)
+			if shift > 0 && digit > 0 {
+				text += fmt.Sprintf(", and write %d zero%s after it to make %s", shift, plural(shift), row)
+			}
+			steps = append(steps, text+".")
+		}
+	}
+
+	rows := append([]string{da, "× " + db}, partials...)
+	if len(partials) > 1 {
+		rows = append(rows, total.String())
+		if narrate {
+			steps = append(steps, fmt.Sprintf("Add the partial products: %s = %s.", strings.Join(partials, " + "), total))
+		}
+	}
+	rows = rightAlign(rows)
+	rule := strings.Repeat("-", len([]rune(rows[0])))
+	layout := append([]string{rows[0], rows[1], rule}, rows[2:2+len(partials)]...)
+	if len(partials) > 1 {
+		layout = append(layout, rule, rows[len(rows)-1])
+	}
+	if frac > 0 {
+		steps = append(steps, fmt.Sprintf("%s has %d decimal place%s and %s has %d, so the product has %d: %s.",
+			a, len(a.frac), plural(len(a.frac)), b, len(b.frac), frac, fromDigits(total.String(), frac)))
+	}
+	return strings.Join(layout, "\n"), steps, true
+}
+
+// longDivision divides a by b, which must not be zero, writing one quotient
+// digit per digit of the dividend. A decimal divisor is first made whole by
+// moving both decimal points. With remainder set and a whole dividend, it
+// stops at the ones and leaves a remainder; otherwise it continues into
+// decimals until the division ends, a remainder repeats or
+// maxDivisionDecimals places have been written. It reports false when the
+// divisor has too many digits for the layout.
+func longDivision(a, b columnNumber, remainder, narrate bool) (string, []string, bool) {
+	shift := len(b.frac)
+	divisorText := strings.TrimLeft(b.whole+b.frac, "0")
+	if divisorText == "" || len(divisorText) > maxDivisorDigits {
+		return "", nil, false
+	}
+	divisor, _ := strconv.ParseInt(divisorText, 10, 64)
+
+	// Moving both decimal points shift places right keeps the quotient.
+	var steps []string
+	dividendFrac := max(0, len(a.frac)-shift)
+	dividend := a.digits(max(len(a.frac), shift))
+	whole := strings.TrimLeft(dividend[:len(dividend)-dividendFrac], "0")
+	if whole == "" {
+		whole = "0"
+	}
+	dividend = whole + dividend[len(dividend)-dividendFrac:]
+	if shift > 0 {
+		steps = append(steps, fmt.Sprintf("%s has %d decimal place%s, so multiply both numbers by %s to divide by a whole number: %s ÷ %s = %s ÷ %d.",
+			b, shift, plural(shift), pow10(shift), a, b, withPoint(dividend, dividendFrac), divisor))
+	}
+	wholeDigits := len(whole)
+
+	type divisionStep struct {
+		column                        int // dividend digit the step brings down
+		partial, digit, product, rest int64
+	}
+	var (
+		quotient  []byte
+		divSteps  []divisionStep
+		rest      int64
+		seen      = map[int64]int{}
+		repeating = -1
+	)
+	extend := !remainder || dividendFrac > 0
+	for i := 0; ; i++ {
+		if i == len(dividend) {
+			if !extend || rest == 0 || i-wholeDigits >= maxDivisionDecimals {
+				break
+			}
+			if at, ok := seen[rest]; ok {
+				repeating = at
+				break
+			}
+			seen[rest] = i
+			dividend += "0"
+		}
+		partial := rest*10 + int64(dividend[i]-'0')
+		digit := partial / divisor
+		rest = partial - digit*divisor
+		quotient = append(quotient, byte('0'+digit))
+		// Leading zeros of the quotient are not written, except for the
+		// ones digit.
+		if digit > 0 || len(divSteps) > 0 || i >= wholeDigits-1 {
+			divSteps = append(divSteps, divisionStep{i, partial, digit, digit * divisor, rest})
+		}
+	}
+	frac := len(dividend) - wholeDigits
+	q := fromDigits(string(quotient), frac)
+
+	for k, s := range divSteps {
+		if !narrate {
+			break
+		}
+		var text string
+		if s.column == wholeDigits {
+			if dividendFrac == 0 {
+				text = "Write a decimal point in the answer and carry on with zeros. "
+			} else {
+				text = "Write the decimal point in the answer above the one in the number. "
+			}
+		}
+		switch {
+		case k == 0 && s.column > 0:
+			text += fmt.Sprintf("%d does not go into %s, so start with %d. ", divisor, dividend[:s.column], s.partial)
+		case k > 0:
+			text += fmt.Sprintf("Bring down the %c to make %d. ", dividend[s.column], s.partial)
+		}
+		if s.digit == 0 {
+			text += fmt.Sprintf("%d does not go into %d, so write 0.", divisor, s.partial)
+		} else {
+			text += fmt.Sprintf("%d goes into %d %d time%s: %d × %d = %d and %d - %d = %d.",
+				divisor, s.partial, s.digit, plural(int(s.digit)), s.digit, divisor, s.product, s.partial, s.product, s.rest)
+		}
+		steps = append(steps, text)
+	}
+	switch {
+	case repeating >= 0:
+		steps = append(steps, fmt.Sprintf("The remainder %d has come up before, so the digits %s repeat forever: %s...", rest, quotient[repeating:], q))
+	case rest != 0 && !extend:
+		steps = append(steps, fmt.Sprintf("There are no more digits to bring down, so the answer is %s remainder %d.", q, rest))
+	case rest != 0:
+		steps = append(steps, fmt.Sprintf("Stop after %d decimal places: the answer is about %s.", frac, q))
+	}
+
+	// Lay out the quotient above the dividend, and each subtraction below
+	// the digits it uses.
+	shown := withPoint(dividend, frac)
+	col := func(i int) int {
+		if frac > 0 && i >= wholeDigits {
+			return i + 1
+		}
+		return i
+	}
+	top := []byte(strings.Repeat(" ", len(shown)))
+	for _, s := range divSteps {
+		top[col(s.column)] = quotient[s.column]
+	}
+	if frac > 0 {
+		top[wholeDigits] = '.'
+	}
+	prefix := fmt.Sprintf("%d ) ", divisor)
+	pad := strings.Repeat(" ", len(prefix))
+	at := func(text string, end int) string {
+		return pad + strings.Repeat(" ", max(0, end+1-len(text))) + text
+	}
+	lines := []string{
+		pad + strings.TrimRight(string(top), " "),
+		pad + strings.Repeat("-", len(shown)),
+		prefix + shown,
+	}
+	// Nothing is subtracted for a quotient digit of 0; the number brought
+	// down next shows the remainder.
+	for k, s := range divSteps {
+		partial := strconv.FormatInt(s.partial, 10)
+		end := col(s.column)
+		if k > 0 {
+			lines = append(lines, at(partial, end))
+		}
+		if s.digit > 0 {
+			lines = append(lines,
+				at(strconv.FormatInt(s.product, 10), end),
+				at(strings.Repeat("-", len(partial)), end))
+		}
+	}
+	if n := len(divSteps); n == 1 || n > 0 && divSteps[n-1].digit > 0 {
+		lines = append(lines, at(strconv.FormatInt(divSteps[n-1].rest, 10), col(divSteps[n-1].column)))
+	}
+	return strings.Join(lines, "\n"), steps, true
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
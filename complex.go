@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"regexp"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	complexOperations = []interface{}{
+		"add", "subtract", "multiply", "divide", "pow",
+		"conjugate", "modulus", "argument", "polar", "exp", "log", "sqrt",
+		"rectangular",
+	}
+	// unitImaginary matches an imaginary part written without a
+	// coefficient, such as the i of "3+i".
+	unitImaginary = regexp.MustCompile(`(^|[+-])i$`)
+)
+
+// complexBinary reports whether operation takes two operands.
+func complexBinary(operation string) bool {
+	switch operation {
+	case "add", "subtract", "multiply", "divide", "pow":
+		return true
+	}
+	return false
+}
+
+// ComplexParams defines the parameters for the complex tool.
+type ComplexParams struct {
+	Operation string      `json:"operation" jsonschema:"'add', 'subtract', 'multiply', 'divide' or 'pow' (a to the power b); 'conjugate', 'modulus', 'argument', 'polar', 'exp', 'log' or 'sqrt' of a; or 'rectangular' (the number with the given modulus and argument)"`
+	A         interface{} `json:"a,omitempty" jsonschema:"first operand: an object {\"re\": 3, \"im\": 4}, a string such as \"3+4i\", \"-2j\" or \"5∠53.13°\", or a real number"`
+	B         interface{} `json:"b,omitempty" jsonschema:"second operand of the two-operand operations, in the same forms as a"`
+	Modulus   *float64    `json:"modulus,omitempty" jsonschema:"rectangular: modulus of the number"`
+	Argument  *float64    `json:"argument,omitempty" jsonschema:"rectangular: argument of the number, in angle_unit"`
+	AngleUnit string      `json:"angle_unit,omitempty" jsonschema:"unit of arguments and of polar strings without °: 'radians' (default) or 'degrees'"`
+}
+
+func (p ComplexParams) Validate() error {
+	binary := complexBinary(p.Operation)
+	rectangular := p.Operation == "rectangular"
+	degrees := p.AngleUnit == "degrees"
+	operand := func(value interface{}) error {
+		_, err := complexValue(value, degrees)
+		return err
+	}
+	finite := func(value interface{}) error {
+		if v, ok := value.(*float64); ok && v != nil && (math.IsNaN(*v) || math.IsInf(*v, 0)) {
+			return errors.New("must be a finite number")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Operation, validation.Required, validation.In(complexOperations...)),
+		validation.Field(&p.A,
+			validation.When(!rectangular, validation.NotNil.Error("is required"), validation.By(operand)),
+			validation.When(rectangular, validation.Nil.Error("does not apply to rectangular; use modulus and argument")),
+		),
+		validation.Field(&p.B,
+			validation.When(binary, validation.NotNil.Error("is required for "+p.Operation), validation.By(operand)),
+			validation.When(!binary, validation.Nil.Error("only applies to add, subtract, multiply, divide and pow")),
+			validation.When(p.Operation == "divide", validation.By(func(value interface{}) error {
+				if b, err := complexValue(value, degrees); err == nil && b == 0 {
+					return errors.New("cannot divide by zero")
+				}
+				return nil
+			})),
+		),
+		validation.Field(&p.Modulus,
+			validation.When(rectangular, validation.NotNil.Error("is required for rectangular")),
+			validation.When(!rectangular, validation.Nil.Error("only applies to rectangular")),
+			validation.By(finite),
+			validation.By(func(value interface{}) error {
+				if p.Modulus != nil && *p.Modulus < 0 {
+					return errors.New("must not be negative")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Argument,
+			validation.When(rectangular, validation.NotNil.Error("is required for rectangular")),
+			validation.When(!rectangular, validation.Nil.Error("only applies to rectangular")),
+			validation.By(finite),
+		),
+		validation.Field(&p.AngleUnit, validation.In("radians", "degrees")),
+	)
+}
+
+// complexValue reads an operand: an object with re and im, a string in
+// rectangular form ("3+4i", "2-j") or polar form ("5∠0.9273", "5∠53.13°",
+// the angle in degrees when marked or when degrees is set), or a real
+// number.
+func complexValue(value interface{}, degrees bool) (complex128, error) {
+	var z complex128
+	switch value := value.(type) {
+	case float64:
+		z = complex(value, 0)
+	case map[string]interface{}:
+		if len(value) == 0 {
+			return 0, errors.New("needs re, im or both")
+		}
+		var re, im float64
+		for key, v := range value {
+			f, err := floatValue(v)
+			switch {
+			case key != "re" && key != "im":
+				return 0, fmt.Errorf("has unknown field %q; use re and im", key)
+			case err != nil:
+				return 0, fmt.Errorf("%s: %v", key, err)
+			case key == "re":
+				re = f
+			default:
+				im = f
+			}
+		}
+		z = complex(re, im)
+	case string:
+		var err error
+		if z, err = parseComplex(value, degrees); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, errors.New("must be an object with re and im, a string such as \"3+4i\", or a number")
+	}
+	if cmplx.IsNaN(z) || cmplx.IsInf(z) {
+		return 0, errors.New("must be finite")
+	}
+	return z, nil
+}
+
+// parseComplex parses a complex number in rectangular or polar form.
+// Spaces are ignored, j may be used for i, and the coefficient of i may be
+// omitted or joined to it with '*'.
+func parseComplex(text string, degrees bool) (complex128, error) {
+	s := strings.Join(strings.Fields(text), "")
+	if r, angle, polar := strings.Cut(s, "∠"); polar {
+		modulus, err := floatValue(r)
+		if err != nil {
+			return 0, fmt.Errorf("%q: the modulus: %v", text, err)
+		}
+		marked := strings.HasSuffix(angle, "°")
+		theta, err := floatValue(strings.TrimSuffix(angle, "°"))
+		if err != nil {
+			return 0, fmt.Errorf("%q: the angle: %v", text, err)
+		}
+		if marked || degrees {
+			theta *= math.Pi / 180
+		}
+		return cleanComplex(cmplx.Rect(modulus, theta)), nil
+	}
+	s = strings.ReplaceAll(s, "*i", "i")
+	if strings.HasSuffix(s, "j") {
+		s = strings.TrimSuffix(s, "j") + "i"
+	}
+	s = unitImaginary.ReplaceAllString(s, "${1}1i")
+	z, err := strconv.ParseComplex(s, 128)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a complex number; write it as 3+4i, 3-4j or 5∠53.13°", text)
+	}
+	return z, nil
+}
+
+// ComplexResult defines the result for the complex tool.
+type ComplexResult struct {
+	Re        float64 `json:"re" jsonschema:"real part of the result"`
+	Im        float64 `json:"im" jsonschema:"imaginary part of the result"`
+	Text      string  `json:"text" jsonschema:"the result as a string such as 3+4i"`
+	Modulus   float64 `json:"modulus" jsonschema:"modulus of the result"`
+	Argument  float64 `json:"argument" jsonschema:"argument of the result in angle_unit, in (-pi, pi] or (-180, 180]"`
+	AngleUnit string  `json:"angle_unit" jsonschema:"unit of argument"`
+	Note      string  `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handleComplex(ctx context.Context, req *mcp.CallToolRequest, param ComplexParams) (*mcp.CallToolResult, ComplexResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			ComplexResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	degrees := param.AngleUnit == "degrees"
+	a, _ := complexValue(param.A, degrees)
+	b, _ := complexValue(param.B, degrees)
+	z, note, err := applyComplex(param, a, b, degrees)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			ComplexResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	result := ComplexResult{
+		Re:        real(z),
+		Im:        imag(z),
+		Text:      formatComplex(z),
+		Modulus:   cmplx.Abs(z),
+		Argument:  complexArgument(z, degrees),
+		AngleUnit: "radians",
+		Note:      note,
+	}
+	polar := fmt.Sprintf("%.15g∠%.15g", result.Modulus, result.Argument)
+	if degrees {
+		result.AngleUnit = "degrees"
+		polar += "°"
+	}
+	text := "Result: " + result.Text
+	if param.Operation != "modulus" && param.Operation != "argument" {
+		text += "\nPolar: " + polar
+	}
+	if note != "" {
+		text += "\nNote: " + note
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// applyComplex performs the operation. Results of the transcendental
+// functions are cleaned of rounding noise, so that exp(πi) is -1 rather
+// than -1+1.2e-16i.
+func applyComplex(param ComplexParams, a, b complex128, degrees bool) (complex128, string, error) {
+	var z complex128
+	var note string
+	switch param.Operation {
+	case "add":
+		z = a + b
+	case "subtract":
+		z = a - b
+	case "multiply":
+		z = a * b
+	case "divide":
+		z = a / b
+	case "conjugate":
+		z = cmplx.Conj(a)
+	case "modulus":
+		z = complex(cmplx.Abs(a), 0)
+	case "argument":
+		if a == 0 {
+			return 0, "", errors.New("the argument of 0 is undefined")
+		}
+		z = complex(complexArgument(a, degrees), 0)
+	case "polar":
+		z = a
+	case "rectangular":
+		theta := *param.Argument
+		if degrees {
+			theta *= math.Pi / 180
+		}
+		z = cleanComplex(cmplx.Rect(*param.Modulus, theta))
+	case "exp":
+		z = cleanComplex(cmplx.Exp(a))
+	case "log":
+		if a == 0 {
+			return 0, "", errors.New("the logarithm of 0 is undefined")
+		}
+		z, note = cleanComplex(cmplx.Log(a)), "principal value, with imaginary part in (-pi, pi]"
+	case "sqrt":
+		z, note = cleanComplex(cmplx.Sqrt(a)), "principal square root, with non-negative real part"
+	case "pow":
+		if a == 0 && real(b) <= 0 && b != 0 {
+			return 0, "", errors.New("0 cannot be raised to a power whose real part is not positive")
+		}
+		z = cleanComplex(cmplx.Pow(a, b))
+		if imag(b) != 0 || real(b) != math.Trunc(real(b)) {
+			note = "principal value, exp(b*log(a))"
+		}
+	}
+	if cmplx.IsInf(z) || cmplx.IsNaN(z) {
+		return 0, "", fmt.Errorf("%s overflows", param.Operation)
+	}
+	return z, note, nil
+}
+
+// complexArgument returns the argument of z in radians or degrees.
+func complexArgument(z complex128, degrees bool) float64 {
+	theta := cmplx.Phase(z)
+	if degrees {
+		theta *= 180 / math.Pi
+	}
+	return theta
+}
+
+// cleanComplex sets a part of z to zero when it is below the rounding
+// error of the other.
+func cleanComplex(z complex128) complex128 {
+	re, im := real(z), imag(z)
+	if math.Abs(re) < 1e-15*math.Abs(im) {
+		re = 0
+	}
+	if math.Abs(im) < 1e-15*math.Abs(re) {
+		im = 0
+	}
+	return complex(re, im)
+}
+
+// formatComplex writes z as "3+4i", "-2i", "1-i" or "5", leaving out zero
+// parts and unit coefficients of i.
+func formatComplex(z complex128) string {
+	re, im := real(z), imag(z)
+	if im == 0 {
+		return fmt.Sprintf("%.15g", re+0)
+	}
+	imaginary := fmt.Sprintf("%+.15gi", im)
+	switch im {
+	case 1:
+		imaginary = "+i"
+	case -1:
+		imaginary = "-i"
+	}
+	if re == 0 {
+		return strings.TrimPrefix(imaginary, "+")
+	}
+	return fmt.Sprintf("%.15g", re) + imaginary
+}
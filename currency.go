@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// currencyCodePattern matches an ISO 4217 alphabetic code.
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currencyMinorUnits lists ISO 4217 currencies whose minor unit is not two
+// decimal places. Every other currency rounds to cents.
+var currencyMinorUnits = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"CLF": 4, "UYW": 4,
+}
+
+func minorUnits(code string) int {
+	if n, ok := currencyMinorUnits[code]; ok {
+		return n
+	}
+	return 2
+}
+
+// rateTable holds exchange rates relative to a base currency: one unit of
+// Base buys rates[code] units of code. It is safe for concurrent use.
+type rateTable struct {
+	mu     sync.RWMutex
+	base   string
+	asOf   time.Time
+	source string
+	rates  map[string]*big.Rat
+}
+
+// currencyRates is the rate table used by the currency tools. It starts
+// empty and is filled from RATES_FILE or the set-currency-rates tool.
+var currencyRates = &rateTable{}
+
+// replace installs a new set of rates. The base currency is added with a
+// rate of one if it is missing.
+func (t *rateTable) replace(base string, asOf time.Time, source string, rates map[string]*big.Rat) {
+	if _, ok := rates[base]; !ok {
+		rates[base] = big.NewRat(1, 1)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.base, t.asOf, t.source, t.rates = base, asOf, source, rates
+}
+
+// crossRate returns how many units of to one unit of from buys,
+// triangulating through the base currency.
+func (t *rateTable) crossRate(from, to string) (*big.Rat, string, time.Time, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.rates) == 0 {
+		return nil, "", time.Time{}, errors.New("no exchange rates are loaded; set RATES_FILE or use set-currency-rates")
+	}
+	fromRate, ok := t.rates[from]
+	if !ok {
+		return nil, "", time.Time{}, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := t.rates[to]
+	if !ok {
+		return nil, "", time.Time{}, fmt.Errorf("no exchange rate for %s", to)
+	}
+	return new(big.Rat).Quo(toRate, fromRate), t.base, t.asOf, nil
+}
+
+// rateFile is the JSON layout of a rate table.
+type rateFile struct {
+	Base  string                 `json:"base"`
+	AsOf  time.Time              `json:"as_of"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// loadRatesFile reads a rate table from a JSON or CSV file, chosen by the
+// file extension.
+//
+// JSON files look like {"base": "USD", "as_of": "2026-10-01T00:00:00Z",
+// "rates": {"EUR": 0.92, "JPY": "149.30"}}. CSV files have a header row
+// "base,currency,rate,as_of" and one row per quoted currency.
+func loadRatesFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var base string
+	var asOf time.Time
+	raw := make(map[string]string)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var file rateFile
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&file); err != nil {
+			return fmt.Errorf("parsing %s: %v", path, err)
+		}
+		base, asOf = file.Base, file.AsOf
+		for code, rate := range file.Rates {
+			raw[code] = rate.String()
+		}
+	case ".csv":
+		base, asOf, err = parseRatesCSV(bytes.NewReader(data), raw)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %v", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported rate file %s: expected .json or .csv", path)
+	}
+
+	rates, err := parseRates(base, raw)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %v", path, err)
+	}
+	currencyRates.replace(base, asOf, path, rates)
+	return nil
+}
+
+func parseRatesCSV(r io.Reader, raw map[string]string) (string, time.Time, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if len(records) < 2 {
+		return "", time.Time{}, errors.New("expected a header row and at least one rate")
+	}
+	header := strings.Join(records[0], ",")
+	if header != "base,currency,rate,as_of" {
+		return "", time.Time{}, fmt.Errorf("unexpected header %q, expected \"base,currency,rate,as_of\"", header)
+	}
+
+	var base string
+	var asOf time.Time
+	for i, record := range records[1:] {
+		if base == "" {
+			base = record[0]
+		} else if record[0] != base {
+			return "", time.Time{}, fmt.Errorf("row %d: base %s differs from %s", i+2, record[0], base)
+		}
+		raw[record[1]] = record[2]
+		if record[3] != "" {
+			t, err := time.Parse(time.RFC3339, record[3])
+			if err != nil {
+				return "", time.Time{}, fmt.Errorf("row %d: %v", i+2, err)
+			}
+			if t.After(asOf) {
+				asOf = t
+			}
+		}
+	}
+	return base, asOf, nil
+}
+
+// parseRates validates currency codes and converts rate strings to exact
+// rationals.
+func parseRates(base string, raw map[string]string) (map[string]*big.Rat, error) {
+	if !currencyCodePattern.MatchString(base) {
+		return nil, fmt.Errorf("invalid base currency %q", base)
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("no rates given")
+	}
+	rates := make(map[string]*big.Rat, len(raw))
+	for code, text := range raw {
+		if !currencyCodePattern.MatchString(code) {
+			return nil, fmt.Errorf("invalid currency code %q", code)
+		}
+		rate, err := parseDecimal(text)
+		if err != nil {
+			return nil, fmt.Errorf("rate for %s: %v", code, err)
+		}
+		if rate.Sign() <= 0 {
+			return nil, fmt.Errorf("rate for %s must be positive", code)
+		}
+		rates[code] = rate
+	}
+	if rate, ok := rates[base]; ok && rate.Cmp(big.NewRat(1, 1)) != 0 {
+		return nil, fmt.Errorf("rate for the base currency %s must be 1", base)
+	}
+	return rates, nil
+}
+
+// ConvertCurrencyParams defines the parameters for the convert-currency tool.
+type ConvertCurrencyParams struct {
+	Amount     float64 `json:"amount,omitempty" jsonschema:"amount to convert"`
+	AmountText string  `json:"amount_text,omitempty" jsonschema:"amount as a string such as \"1,234.50\", used instead of amount so no precision is lost in JSON"`
+	From       string  `json:"from" jsonschema:"ISO 4217 code of the source currency, e.g. USD"`
+	To         string  `json:"to" jsonschema:"ISO 4217 code of the target currency, e.g. EUR"`
+	Rounding   string  `json:"rounding,omitempty" jsonschema:"rounding mode to the target currency's minor unit: 'half-even' (default), 'half-up', 'down', 'up', 'ceiling' or 'floor'"`
+	Locale     string  `json:"locale,omitempty" jsonschema:"separators of amount_text: 'en' (default, 1,234.5), 'de' (1.234,5), 'fr' (1 234,5), 'ch' (1'234.5) or another language code"`
+}
+
+func (p ConvertCurrencyParams) Validate() error {
+	if err := checkLocale(p.Locale); err != nil {
+		return err
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.AmountText,
+			numberText(p.Locale),
+			validation.When(p.Amount != 0, validation.Empty.Error("cannot be combined with amount")),
+		),
+		validation.Field(&p.From, validation.Required, validation.Match(currencyCodePattern).Error("must be a three-letter ISO 4217 code")),
+		validation.Field(&p.To, validation.Required, validation.Match(currencyCodePattern).Error("must be a three-letter ISO 4217 code")),
+		validation.Field(&p.Rounding, validation.In(roundingModes...)),
+		validation.Field(&p.Locale, validation.When(p.AmountText == "", validation.Empty.Error("only applies to amount_text"))),
+	)
+}
+
+// ConvertCurrencyResult defines the result for the convert-currency tool.
+type ConvertCurrencyResult struct {
+	Result     float64 `json:"result" jsonschema:"converted amount"`
+	Amount     string  `json:"amount" jsonschema:"converted amount as a decimal string rounded to the target currency's minor unit"`
+	From       string  `json:"from" jsonschema:"source currency"`
+	To         string  `json:"to" jsonschema:"target currency"`
+	Rate       string  `json:"rate" jsonschema:"units of the target currency per unit of the source currency"`
+	Base       string  `json:"base" jsonschema:"base currency used for triangulation"`
+	AsOf       string  `json:"as_of,omitempty" jsonschema:"timestamp of the rate table (RFC 3339)"`
+	MinorUnits int     `json:"minor_units" jsonschema:"decimal places of the target currency"`
+}
+
+func handleConvertCurrency(ctx context.Context, req *mcp.CallToolRequest, param ConvertCurrencyParams) (*mcp.CallToolResult, ConvertCurrencyResult, error) {
+	param.From = strings.ToUpper(strings.TrimSpace(param.From))
+	param.To = strings.ToUpper(strings.TrimSpace(param.To))
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			ConvertCurrencyResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	amountText := param.AmountText
+	if amountText == "" {
+		amountText = exactDecimal(param.Amount)
+	}
+	amount, err := parseNumber(amountText, param.Locale)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: amount: %v", err)),
+			ConvertCurrencyResult{}, fmt.Errorf("invalid parameters: amount: %v", err)
+	}
+
+	rate, base, asOf, err := currencyRates.crossRate(param.From, param.To)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Conversion error: %v", err)),
+			ConvertCurrencyResult{}, fmt.Errorf("conversion error: %v", err)
+	}
+
+	rounding := param.Rounding
+	if rounding == "" {
+		rounding = "half-even"
+	}
+	digits := minorUnits(param.To)
+	unscaled := roundRat(new(big.Rat).Mul(amount, rate), digits, rounding)
+	text := formatScaled(unscaled, digits)
+	result, _ := new(big.Rat).SetFrac(unscaled, pow10(digits)).Float64()
+
+	var asOfText string
+	if !asOf.IsZero() {
+		asOfText = asOf.Format(time.RFC3339)
+	}
+	rateText := new(big.Float).SetPrec(128).SetRat(rate).Text('g', 12)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("%s %s = %s %s (rate %s)", amountText, param.From, text, param.To, rateText)}},
+	}, ConvertCurrencyResult{
+		Result:     saturate(result),
+		Amount:     text,
+		From:       param.From,
+		To:         param.To,
+		Rate:       rateText,
+		Base:       base,
+		AsOf:       asOfText,
+		MinorUnits: digits,
+	}, nil
+}
+
+// SetCurrencyRatesParams defines the parameters for the set-currency-rates
+// admin tool.
+type SetCurrencyRatesParams struct {
+	Base  string            `json:"base" jsonschema:"ISO 4217 code of the base currency"`
+	AsOf  string            `json:"as_of,omitempty" jsonschema:"timestamp of the rates in RFC 3339 format (default: now)"`
+	Rates map[string]string `json:"rates" jsonschema:"units of each currency per unit of the base currency, as decimal strings"`
+	Token string            `json:"token" jsonschema:"the admin token set in CURRENCY_ADMIN_TOKEN on the server"`
+}
+
+func (p SetCurrencyRatesParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Base, validation.Required, validation.Match(currencyCodePattern).Error("must be a three-letter ISO 4217 code")),
+		validation.Field(&p.AsOf, validation.Date(time.RFC3339)),
+		validation.Field(&p.Rates, validation.Required),
+		validation.Field(&p.Token, validation.By(func(value interface{}) error {
+			want := os.Getenv("CURRENCY_ADMIN_TOKEN")
+			if want == "" || subtle.ConstantTimeCompare([]byte(p.Token), []byte(want)) != 1 {
+				return errors.New("is missing or incorrect")
+			}
+			return nil
+		})),
+	)
+}
+
+// SetCurrencyRatesResult defines the result for the set-currency-rates tool.
+type SetCurrencyRatesResult struct {
+	Base       string `json:"base" jsonschema:"base currency of the new table"`
+	AsOf       string `json:"as_of" jsonschema:"timestamp of the new table"`
+	Currencies int    `json:"currencies" jsonschema:"number of currencies in the new table"`
+}
+
+func handleSetCurrencyRates(ctx context.Context, req *mcp.CallToolRequest, param SetCurrencyRatesParams) (*mcp.CallToolResult, SetCurrencyRatesResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			SetCurrencyRatesResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	rates, err := parseRates(param.Base, param.Rates)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: rates: %v", err)),
+			SetCurrencyRatesResult{}, fmt.Errorf("invalid parameters: rates: %v", err)
+	}
+	asOf := time.Now().UTC()
+	if param.AsOf != "" {
+		asOf, _ = time.Parse(time.RFC3339, param.AsOf)
+	}
+	currencyRates.replace(param.Base, asOf, "set-currency-rates", rates)
+	log.Printf("Currency rates replaced: base %s, %d currencies, as of %s", param.Base, len(rates), asOf.Format(time.RFC3339))
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Loaded %d rates against %s as of %s", len(rates), param.Base, asOf.Format(time.RFC3339))}},
+	}, SetCurrencyRatesResult{
+		Base:       param.Base,
+		AsOf:       asOf.Format(time.RFC3339),
+		Currencies: len(rates),
+	}, nil
+}
+
+func handleCurrencyRates(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	if uri != "rates://current" {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	currencyRates.mu.RLock()
+	codes := make([]string, 0, len(currencyRates.rates))
+	for code := range currencyRates.rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	rates := make(map[string]string, len(codes))
+	for _, code := range codes {
+		rates[code] = ratDecimalString(currencyRates.rates[code], maxRationalDigits)
+	}
+	table := map[string]any{
+		"base":   currencyRates.base,
+		"source": currencyRates.source,
+		"rates":  rates,
+	}
+	if !currencyRates.asOf.IsZero() {
+		table["as_of"] = currencyRates.asOf.Format(time.RFC3339)
+	}
+	currencyRates.mu.RUnlock()
+
+	data, err := json.MarshalIndent(table, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{URI: uri, Text: string(data), MIMEType: "application/json"},
+		},
+	}, nil
+}
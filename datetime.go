@@ -0,0 +1,874 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones must not depend on the host's zoneinfo
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxBusinessDays bounds the days of add-business-days.
+	maxBusinessDays = 100_000
+	// maxListedHolidays bounds the skipped holidays listed in a result.
+	maxListedHolidays = 100
+)
+
+var (
+	dateTimeOperations = []interface{}{
+		"add", "subtract", "difference", "convert", "iso-week",
+		"add-business-days", "business-days",
+	}
+	weekdayNames = []interface{}{
+		"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday",
+	}
+	// timestampLayouts are the forms of start and end, tried in order.
+	// Layouts without an offset are read in the time zone of the call.
+	timestampLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02 15:04",
+	}
+	isoWeekDate = regexp.MustCompile(`^(\d{4})-?W(\d{2})(?:-?([1-7]))?$`)
+	// isoDuration matches an ISO 8601 duration such as P1Y2M10DT2H30M.
+	isoDuration  = regexp.MustCompile(`^([+-])?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+	fixedOffset  = regexp.MustCompile(`^(?:UTC|GMT)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+	yearlyDate   = regexp.MustCompile(`^--(\d{2})-(\d{2})$`)
+	calendarName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// holidayCalendars holds the calendars loaded from HOLIDAYS_DIR at startup,
+// by file name without extension.
+var holidayCalendars = map[string]holidayCalendar{}
+
+// DateTimeParams defines the parameters for the datetime tool.
+type DateTimeParams struct {
+	Operation  string   `json:"operation" jsonschema:"'add' or 'subtract' a duration to or from start; 'difference' from start to end; 'convert' start to to_timezone; 'iso-week' of start; 'add-business-days' (the date days business days after start) or 'business-days' (the number of business days from start up to, not including, end)"`
+	Start      string   `json:"start" jsonschema:"a date or timestamp: 2026-03-01, 2026-03-01T14:30, 2026-03-01 14:30:00, RFC 3339 with an offset such as 2026-03-01T14:30:00+01:00, an ISO week date such as 2026-W09-7, or 'now'"`
+	End        string   `json:"end,omitempty" jsonschema:"difference and business-days: the second date or timestamp, in the same forms as start"`
+	Duration   string   `json:"duration,omitempty" jsonschema:"add and subtract: an ISO 8601 duration such as P1Y2M10DT2H30M or P3W, or a Go duration such as 1h30m; years, months, weeks and days move the date and keep the wall-clock time, hours, minutes and seconds are elapsed time"`
+	Days       *int     `json:"days,omitempty" jsonschema:"add-business-days: the number of business days, negative to count backwards"`
+	Timezone   string   `json:"timezone,omitempty" jsonschema:"IANA time zone such as Europe/Berlin, or an offset such as +05:30, for start and end without an offset and for the results (default: UTC)"`
+	ToTimezone string   `json:"to_timezone,omitempty" jsonschema:"convert: the time zone to convert to, in the same forms as timezone"`
+	Calendar   string   `json:"calendar,omitempty" jsonschema:"business days: a holiday calendar loaded from HOLIDAYS_DIR, named by its file name without extension"`
+	Holidays   []string `json:"holidays,omitempty" jsonschema:"business days: further holidays, as dates (2026-12-24) or yearly month-days (--12-25)"`
+	Weekend    []string `json:"weekend,omitempty" jsonschema:"business days: the weekdays that are not business days (default: saturday and sunday)"`
+}
+
+func (p DateTimeParams) Validate() error {
+	var needsEnd, needsDuration bool
+	switch p.Operation {
+	case "difference", "business-days":
+		needsEnd = true
+	case "add", "subtract":
+		needsDuration = true
+	}
+	business := p.Operation == "add-business-days" || p.Operation == "business-days"
+	loc, err := loadTimezone(p.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	timestamp := func(value interface{}) error {
+		_, _, _, err := parseTimestamp(value.(string), loc)
+		return err
+	}
+	timezone := func(value interface{}) error {
+		_, err := loadTimezone(value.(string))
+		return err
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Operation, validation.Required, validation.In(dateTimeOperations...)),
+		validation.Field(&p.Timezone, validation.By(timezone)),
+		validation.Field(&p.Start, validation.Required, validation.By(timestamp)),
+		validation.Field(&p.End,
+			validation.When(needsEnd, validation.Required.Error("is required for "+p.Operation)),
+			validation.When(!needsEnd, validation.Empty.Error("only applies to difference and business-days")),
+			validation.When(p.End != "", validation.By(timestamp)),
+		),
+		validation.Field(&p.Duration,
+			validation.When(needsDuration, validation.Required.Error("is required for "+p.Operation)),
+			validation.When(!needsDuration, validation.Empty.Error("only applies to add and subtract")),
+			validation.When(p.Duration != "", validation.By(func(value interface{}) error {
+				_, err := parseDuration(value.(string))
+				return err
+			})),
+		),
+		validation.Field(&p.Days,
+			validation.When(p.Operation == "add-business-days", validation.NotNil.Error("is required for add-business-days")),
+			validation.When(p.Operation != "add-business-days", validation.Nil.Error("only applies to add-business-days")),
+			validation.Min(-maxBusinessDays), validation.Max(maxBusinessDays),
+		),
+		validation.Field(&p.ToTimezone,
+			validation.When(p.Operation == "convert", validation.Required.Error("is required for convert")),
+			validation.When(p.Operation != "convert", validation.Empty.Error("only applies to convert")),
+			validation.By(timezone),
+		),
+		validation.Field(&p.Calendar,
+			validation.When(!business, validation.Empty.Error("only applies to add-business-days and business-days")),
+			validation.When(p.Calendar != "", validation.By(func(value interface{}) error {
+				_, err := lookupHolidayCalendar(value.(string))
+				return err
+			})),
+		),
+		validation.Field(&p.Holidays,
+			validation.When(!business, validation.Nil.Error("only applies to add-business-days and business-days")),
+			validation.Each(validation.Required, validation.By(func(value interface{}) error {
+				return holidayCalendar{}.add(value.(string), "")
+			})),
+		),
+		validation.Field(&p.Weekend,
+			validation.When(!business, validation.Nil.Error("only applies to add-business-days and business-days")),
+			validation.Length(0, 6).Error("must leave at least one business day in the week"),
+			validation.Each(validation.In(weekdayNames...).Error("must be a weekday name such as saturday")),
+		),
+	)
+}
+
+// loadTimezone returns the IANA time zone of the given name, a fixed zone
+// for an offset such as +05:30 or UTC-3, or UTC when name is empty.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if m := fixedOffset.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3] + strings.Repeat("0", 2-len(m[3])))
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("%q is not a UTC offset between -14:00 and +14:00", name)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(formatOffset(offset), offset), nil
+	}
+	if name == "Local" {
+		return nil, errors.New("\"Local\" is the server's time zone; name the zone, such as Europe/Berlin")
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q; use an IANA name such as America/New_York or an offset such as +05:30", name)
+	}
+	return loc, nil
+}
+
+// formatOffset writes an offset in seconds east of UTC as UTC+05:30.
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("UTC%s%02d:%02d", sign, offset/3600, offset/60%60)
+}
+
+// parseTimestamp reads a date or timestamp in one of timestampLayouts, an
+// ISO week date or "now". Dates alone are reported as dateOnly and read as
+// midnight UTC, so that date arithmetic is not disturbed by daylight saving
+// time; they are moved to midnight in loc where they meet times of day. Timestamps without an offset are read as wall-clock times in loc,
+// with a note when daylight saving time skips or repeats them.
+func parseTimestamp(s string, loc *time.Location) (t time.Time, dateOnly bool, note string, err error) {
+	text := strings.TrimSpace(s)
+	if strings.EqualFold(text, "now") {
+		return time.Now().In(loc).Truncate(time.Second), false, "", nil
+	}
+	if m := isoWeekDate.FindStringSubmatch(text); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		weekday := 1
+		if m[3] != "" {
+			weekday, _ = strconv.Atoi(m[3])
+		}
+		// Week 1 is the week with the year's first Thursday, and so
+		// with 4 January.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+		monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7)
+		t = monday.AddDate(0, 0, 7*(week-1)+weekday-1)
+		if y, w := t.ISOWeek(); y != year || w != week {
+			return time.Time{}, false, "", fmt.Errorf("%d has no ISO week %d", year, week)
+		}
+		return t, true, "", nil
+	}
+	if t, err := time.Parse(time.DateOnly, text); err == nil {
+		return t, true, "", nil
+	}
+	for _, layout := range timestampLayouts {
+		wall, err := time.Parse(layout, text)
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix(layout, "Z07:00") {
+			// Parse may name the offset after the server's zone; keep
+			// only the offset.
+			if _, offset := wall.Zone(); offset != 0 {
+				return wall.In(time.FixedZone(formatOffset(offset), offset)), false, "", nil
+			}
+			return wall.UTC(), false, "", nil
+		}
+		t, note := wallClock(wall, loc)
+		return t, false, note, nil
+	}
+	return time.Time{}, false, "", fmt.Errorf("%q is not a date or timestamp; write it as 2026-03-01, 2026-03-01T14:30, 2026-03-01T14:30:00+01:00 or 2026-W09-7", s)
+}
+
+// wallClock returns the time in loc with the date and clock of wall. It
+// explains wall-clock times that daylight saving time skips or repeats.
+func wallClock(wall time.Time, loc *time.Location) (time.Time, string) {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	if t.Hour() != wall.Hour() || t.Minute() != wall.Minute() {
+		// time.Date may resolve a skipped time backwards. Read the clock
+		// with the offset in force before the gap instead, which moves it
+		// forward by the length of the gap: 02:30 becomes 03:30 when the
+		// clocks jump from 02:00 to 03:00.
+		_, before := t.Add(-24 * time.Hour).Zone()
+		naive := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+		t = naive.Add(-time.Duration(before) * time.Second).In(loc)
+		return t, fmt.Sprintf("%s does not exist in %s, where the clocks go forward; %s was used",
+			wall.Format("2006-01-02 15:04"), loc, t.Format("15:04 MST"))
+	}
+	_, before := t.Add(-3 * time.Hour).Zone()
+	_, after := t.Add(3 * time.Hour).Zone()
+	if shift := time.Duration(before-after) * time.Second; shift > 0 {
+		for _, other := range []time.Time{t.Add(-shift), t.Add(shift)} {
+			if other.Hour() != t.Hour() || other.Minute() != t.Minute() {
+				continue
+			}
+			first, second := t, other
+			if other.Before(t) {
+				first, second = other, t
+			}
+			return first, fmt.Sprintf("%s happens twice in %s, where the clocks go back; the first, %s, was used rather than %s",
+				wall.Format("2006-01-02 15:04"), loc, first.Format("15:04 MST"), second.Format("15:04 MST"))
+		}
+	}
+	return t, ""
+}
+
+// calendarDuration is a duration with a calendar part, which moves the
+// date and keeps the wall-clock time, and an elapsed part.
+type calendarDuration struct {
+	years, months, days int
+	elapsed             time.Duration
+}
+
+// parseDuration reads an ISO 8601 duration such as -P1Y2M10DT2H30M or P3W,
+// or a Go duration such as 1h30m.
+func parseDuration(s string) (calendarDuration, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	m := isoDuration.FindStringSubmatch(text)
+	if m == nil {
+		elapsed, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return calendarDuration{}, fmt.Errorf("%q is not a duration; write it as P1Y2M10DT2H30M or 1h30m", s)
+		}
+		return calendarDuration{elapsed: elapsed}, nil
+	}
+	if strings.Join(m[2:], "") == "" || strings.HasSuffix(text, "T") {
+		return calendarDuration{}, fmt.Errorf("%q has no years, months, weeks, days, hours, minutes or seconds", s)
+	}
+	var fields [6]int
+	for i, digits := range m[2:8] {
+		if len(digits) > 8 {
+			return calendarDuration{}, fmt.Errorf("%q is too long", s)
+		}
+		fields[i], _ = strconv.Atoi(digits)
+	}
+	seconds := 0.0
+	if m[8] != "" {
+		seconds, _ = strconv.ParseFloat(strings.Replace(m[8], ",", ".", 1), 64)
+	}
+	total := float64(fields[4])*3600 + float64(fields[5])*60 + seconds
+	if total*1e9 > math.MaxInt64 {
+		return calendarDuration{}, fmt.Errorf("%q is too long: hours, minutes and seconds must stay below 290 years", s)
+	}
+	d := calendarDuration{
+		years:   fields[0],
+		months:  fields[1],
+		days:    7*fields[2] + fields[3],
+		elapsed: time.Duration(math.Round(total * 1e9)),
+	}
+	if m[1] == "-" {
+		d = d.negate()
+	}
+	return d, nil
+}
+
+func (d calendarDuration) negate() calendarDuration {
+	return calendarDuration{-d.years, -d.months, -d.days, -d.elapsed}
+}
+
+// addTo adds the duration to t in loc: first the years and months, with
+// the day clamped to the end of a shorter month, then the days, keeping
+// the wall-clock time, then the elapsed time.
+func (d calendarDuration) addTo(t time.Time, loc *time.Location) (time.Time, []string) {
+	var notes []string
+	if d.years != 0 || d.months != 0 || d.days != 0 {
+		wall := t.In(loc)
+		year, month, day := wall.Date()
+		if d.years != 0 || d.months != 0 {
+			months := int(month) - 1 + 12*d.years + d.months
+			year += months / 12
+			if months %= 12; months < 0 {
+				year, months = year-1, months+12
+			}
+			month = time.Month(months + 1)
+			if last := daysIn(year, month); day > last {
+				notes = append(notes, fmt.Sprintf("%s has no day %d; the last day of the month, %d, was used",
+					time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Format("January 2006"), day, last))
+				day = last
+			}
+		}
+		date := time.Date(year, month, day+d.days, wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+		var note string
+		if t, note = wallClock(date, loc); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	return t.Add(d.elapsed), notes
+}
+
+// daysIn returns the number of days in the month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// durationParts is the difference between two times in whole calendar
+// units and the elapsed rest.
+type durationParts struct {
+	negative            bool
+	years, months, days int
+	hours, minutes      int
+	seconds             float64
+	elapsed             time.Duration
+}
+
+// calendarDifference splits the time from a to b into whole years, months
+// and days of the calendar in loc and the elapsed rest, such that adding
+// them to the earlier time with addTo gives the later one.
+func calendarDifference(a, b time.Time, loc *time.Location) durationParts {
+	var parts durationParts
+	if b.Before(a) {
+		a, b, parts.negative = b, a, true
+	}
+	a, b = a.In(loc), b.In(loc)
+	months := 12*(b.Year()-a.Year()) + int(b.Month()-a.Month())
+	step, _ := calendarDuration{months: months}.addTo(a, loc)
+	for months > 0 && step.After(b) {
+		months--
+		step, _ = calendarDuration{months: months}.addTo(a, loc)
+	}
+	days := int(dateOf(b).Sub(dateOf(step)).Hours() / 24)
+	next, _ := calendarDuration{months: months, days: days}.addTo(a, loc)
+	for days > 0 && next.After(b) {
+		days--
+		next, _ = calendarDuration{months: months, days: days}.addTo(a, loc)
+	}
+	rest := b.Sub(next)
+	parts.years, parts.months, parts.days = months/12, months%12, days
+	parts.hours, parts.minutes = int(rest/time.Hour), int(rest%time.Hour/time.Minute)
+	parts.seconds = (rest % time.Minute).Seconds()
+	parts.elapsed = b.Sub(a)
+	return parts
+}
+
+// dateOf returns the date of t at midnight UTC.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// String writes the parts as an ISO 8601 duration such as P1Y2M3DT4H5M6S.
+func (p durationParts) String() string {
+	var b strings.Builder
+	if p.negative {
+		b.WriteString("-")
+	}
+	b.WriteString("P")
+	for _, u := range []struct {
+		n    int
+		unit string
+	}{{p.years, "Y"}, {p.months, "M"}, {p.days, "D"}} {
+		if u.n != 0 {
+			fmt.Fprintf(&b, "%d%s", u.n, u.unit)
+		}
+	}
+	if p.hours != 0 || p.minutes != 0 || p.seconds != 0 {
+		b.WriteString("T")
+		if p.hours != 0 {
+			fmt.Fprintf(&b, "%dH", p.hours)
+		}
+		if p.minutes != 0 {
+			fmt.Fprintf(&b, "%dM", p.minutes)
+		}
+		if p.seconds != 0 {
+			fmt.Fprintf(&b, "%gS", p.seconds)
+		}
+	}
+	if strings.HasSuffix(b.String(), "P") {
+		return "PT0S"
+	}
+	return b.String()
+}
+
+// holidayCalendar holds holidays by date, and yearly holidays by month and
+// day, with their names.
+type holidayCalendar struct {
+	dates  map[string]string
+	yearly map[string]string
+}
+
+// add adds a holiday given as a date (2026-12-24) or a yearly month-day
+// (--12-25). It only checks the date when the calendar has no maps.
+func (c holidayCalendar) add(date, name string) error {
+	if m := yearlyDate.FindStringSubmatch(date); m != nil {
+		month, _ := strconv.Atoi(m[1])
+		day, _ := strconv.Atoi(m[2])
+		if month < 1 || month > 12 || day < 1 || day > daysIn(2000, time.Month(month)) {
+			return fmt.Errorf("%q is not a month and day", date)
+		}
+		if c.yearly != nil {
+			c.yearly[m[1]+"-"+m[2]] = name
+		}
+		return nil
+	}
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return fmt.Errorf("%q is not a date such as 2026-12-24 or a yearly month-day such as --12-25", date)
+	}
+	if c.dates != nil {
+		c.dates[date] = name
+	}
+	return nil
+}
+
+// holiday returns the name of the holiday on the date, if it is one.
+func (c holidayCalendar) holiday(date time.Time) (string, bool) {
+	if name, ok := c.dates[date.Format(time.DateOnly)]; ok {
+		return name, true
+	}
+	name, ok := c.yearly[date.Format("01-02")]
+	return name, ok
+}
+
+// holidayEntry is one holiday of a JSON holiday file.
+type holidayEntry struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// loadHolidayCalendars loads every .json and .csv file in dir as a holiday
+// calendar named by its file name without extension, and returns the
+// names loaded. Files that fail to parse are skipped and reported in the
+// error.
+//
+// JSON files look like {"holidays": [{"date": "2026-04-03", "name": "Good
+// Friday"}, {"date": "--12-25", "name": "Christmas Day"}]}. CSV files have
+// a header row "date,name" and one row per holiday.
+func loadHolidayCalendars(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	var errs []error
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".csv") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		name := strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		if !calendarName.MatchString(name) {
+			errs = append(errs, fmt.Errorf("%s: calendar names may only use letters, digits, '-' and '_'", path))
+			continue
+		}
+		calendar, err := loadHolidayFile(path, ext)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %v", path, err))
+			continue
+		}
+		holidayCalendars[name] = calendar
+		names = append(names, name)
+	}
+	if len(names) == 0 && len(errs) == 0 {
+		return nil, fmt.Errorf("no .json or .csv holiday files in %s", dir)
+	}
+	slices.Sort(names)
+	return names, errors.Join(errs...)
+}
+
+func loadHolidayFile(path, ext string) (holidayCalendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return holidayCalendar{}, err
+	}
+	var entries []holidayEntry
+	if ext == ".json" {
+		var file struct {
+			Holidays []holidayEntry `json:"holidays"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return holidayCalendar{}, err
+		}
+		entries = file.Holidays
+	} else {
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return holidayCalendar{}, err
+		}
+		if len(records) == 0 || strings.Join(records[0], ",") != "date,name" {
+			return holidayCalendar{}, errors.New("expected the header \"date,name\"")
+		}
+		for _, record := range records[1:] {
+			entries = append(entries, holidayEntry{Date: record[0], Name: record[1]})
+		}
+	}
+	if len(entries) == 0 {
+		return holidayCalendar{}, errors.New("no holidays given")
+	}
+	calendar := holidayCalendar{dates: map[string]string{}, yearly: map[string]string{}}
+	for i, entry := range entries {
+		if err := calendar.add(strings.TrimSpace(entry.Date), strings.TrimSpace(entry.Name)); err != nil {
+			return holidayCalendar{}, fmt.Errorf("holiday %d: %v", i+1, err)
+		}
+	}
+	return calendar, nil
+}
+
+// lookupHolidayCalendar returns the loaded calendar of the given name.
+func lookupHolidayCalendar(name string) (holidayCalendar, error) {
+	if calendar, ok := holidayCalendars[strings.ToLower(name)]; ok {
+		return calendar, nil
+	}
+	if len(holidayCalendars) == 0 {
+		return holidayCalendar{}, errors.New("no holiday calendars are loaded; set HOLIDAYS_DIR")
+	}
+	loaded := make([]string, 0, len(holidayCalendars))
+	for name := range holidayCalendars {
+		loaded = append(loaded, name)
+	}
+	slices.Sort(loaded)
+	return holidayCalendar{}, fmt.Errorf("unknown calendar %q; the loaded calendars are %s", name, strings.Join(loaded, ", "))
+}
+
+// businessWeek decides which dates are business days: those that are
+// neither on the weekend nor holidays.
+type businessWeek struct {
+	weekend  [7]bool
+	calendar holidayCalendar
+	extra    holidayCalendar
+}
+
+// businessDay reports whether date is a business day, and the name of the
+// holiday that makes a weekday not one.
+func (w businessWeek) businessDay(date time.Time) (bool, string) {
+	if w.weekend[date.Weekday()] {
+		return false, ""
+	}
+	if name, ok := w.calendar.holiday(date); ok {
+		return false, holidayLabel(date, name)
+	}
+	if name, ok := w.extra.holiday(date); ok {
+		return false, holidayLabel(date, name)
+	}
+	return true, ""
+}
+
+func holidayLabel(date time.Time, name string) string {
+	if name == "" {
+		return date.Format(time.DateOnly)
+	}
+	return date.Format(time.DateOnly) + " " + name
+}
+
+// newBusinessWeek builds the business week of the parameters. They must
+// already have passed Validate.
+func newBusinessWeek(param DateTimeParams) businessWeek {
+	var w businessWeek
+	if param.Weekend == nil {
+		w.weekend[time.Saturday], w.weekend[time.Sunday] = true, true
+	}
+	for _, day := range param.Weekend {
+		w.weekend[slices.Index(weekdayNames, interface{}(day))] = true
+	}
+	if param.Calendar != "" {
+		w.calendar, _ = lookupHolidayCalendar(param.Calendar)
+	}
+	w.extra = holidayCalendar{dates: map[string]string{}, yearly: map[string]string{}}
+	for _, date := range param.Holidays {
+		w.extra.add(date, "")
+	}
+	return w
+}
+
+// ISOWeek is a date in the ISO 8601 week calendar.
+type ISOWeek struct {
+	Year      int    `json:"year" jsonschema:"the ISO week-numbering year, which can differ from the calendar year near 1 January"`
+	Week      int    `json:"week" jsonschema:"the week, 1 to 53; week 1 holds the year's first Thursday"`
+	Weekday   int    `json:"weekday" jsonschema:"the day of the week, 1 (Monday) to 7 (Sunday)"`
+	DayOfYear int    `json:"day_of_year" jsonschema:"the day of the calendar year, 1 to 366"`
+	Text      string `json:"text" jsonschema:"the week date, such as 2026-W09-7"`
+}
+
+// DurationParts is a duration split into calendar and clock units.
+type DurationParts struct {
+	Years   int     `json:"years"`
+	Months  int     `json:"months"`
+	Days    int     `json:"days"`
+	Hours   int     `json:"hours"`
+	Minutes int     `json:"minutes"`
+	Seconds float64 `json:"seconds"`
+}
+
+// DateTimeResult defines the result for the datetime tool.
+type DateTimeResult struct {
+	Result       string         `json:"result,omitempty" jsonschema:"the resulting date, or timestamp in RFC 3339"`
+	Timezone     string         `json:"timezone,omitempty" jsonschema:"the time zone of result, with its abbreviation and UTC offset"`
+	Weekday      string         `json:"weekday,omitempty" jsonschema:"the day of the week of result"`
+	Duration     string         `json:"duration,omitempty" jsonschema:"difference: the ISO 8601 duration from start to end"`
+	Parts        *DurationParts `json:"parts,omitempty" jsonschema:"difference: the duration in calendar units; negative durations have every part negative"`
+	TotalSeconds *float64       `json:"total_seconds,omitempty" jsonschema:"difference: the elapsed seconds from start to end"`
+	TotalDays    *float64       `json:"total_days,omitempty" jsonschema:"difference: the elapsed time in days of 24 hours"`
+	ISOWeek      *ISOWeek       `json:"iso_week,omitempty" jsonschema:"iso-week: the ISO week date of start"`
+	BusinessDays *int           `json:"business_days,omitempty" jsonschema:"business-days: the number of business days, negative when end is before start"`
+	Holidays     []string       `json:"holidays,omitempty" jsonschema:"business days: the holidays on weekdays that were skipped"`
+	Note         string         `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handleDateTime(ctx context.Context, req *mcp.CallToolRequest, param DateTimeParams) (*mcp.CallToolResult, DateTimeResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			DateTimeResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	result, text, err := runDateTime(param)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			DateTimeResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// runDateTime performs the operation and returns the result with its text
+// form. The parameters must already have passed Validate.
+func runDateTime(param DateTimeParams) (DateTimeResult, string, error) {
+	loc, _ := loadTimezone(param.Timezone)
+	start, startDate, note, _ := parseTimestamp(param.Start, loc)
+	var notes []string
+	if note != "" {
+		notes = append(notes, note)
+	}
+	switch {
+	case !startDate && param.Timezone != "":
+		start = start.In(loc)
+	case !startDate:
+		loc = start.Location()
+	}
+
+	var result DateTimeResult
+	var text string
+	switch param.Operation {
+	case "add", "subtract":
+		d, _ := parseDuration(param.Duration)
+		if param.Operation == "subtract" {
+			d = d.negate()
+		}
+		dateOnly := startDate && d.elapsed == 0
+		switch {
+		case dateOnly:
+			loc = time.UTC
+		case startDate:
+			start, _ = wallClock(start, loc)
+		}
+		t, addNotes := d.addTo(start, loc)
+		notes = append(notes, addNotes...)
+		if err := describeTime(&result, t, dateOnly); err != nil {
+			return DateTimeResult{}, "", err
+		}
+		sign := "+"
+		if param.Operation == "subtract" {
+			sign = "-"
+		}
+		text = fmt.Sprintf("%s %s %s = %s (%s)", strings.TrimSpace(param.Start), sign, strings.TrimSpace(param.Duration), result.Result, result.Weekday)
+
+	case "difference":
+		end, endDate, note, _ := parseTimestamp(param.End, loc)
+		if note != "" {
+			notes = append(notes, note)
+		}
+		switch {
+		case startDate && endDate:
+			loc = time.UTC
+		case startDate:
+			start, _ = wallClock(start, loc)
+		case endDate:
+			end, _ = wallClock(end, loc)
+		}
+		parts := calendarDifference(start, end, loc)
+		sign := 1
+		if parts.negative {
+			sign = -1
+		}
+		result.Duration = parts.String()
+		result.Parts = &DurationParts{
+			Years:   sign * parts.years,
+			Months:  sign * parts.months,
+			Days:    sign * parts.days,
+			Hours:   sign * parts.hours,
+			Minutes: sign * parts.minutes,
+			Seconds: float64(sign) * parts.seconds,
+		}
+		seconds := float64(sign) * parts.elapsed.Seconds()
+		days := seconds / 86400
+		result.TotalSeconds, result.TotalDays = &seconds, &days
+		text = fmt.Sprintf("From %s to %s: %s (%s days, %s seconds)", strings.TrimSpace(param.Start), strings.TrimSpace(param.End),
+			result.Duration, strconv.FormatFloat(days, 'f', -1, 64), strconv.FormatFloat(seconds, 'f', -1, 64))
+
+	case "convert":
+		to, _ := loadTimezone(param.ToTimezone)
+		if startDate {
+			start, _ = wallClock(start, loc)
+			notes = append(notes, fmt.Sprintf("start has no time of day; midnight in %s was converted", loc))
+		}
+		if err := describeTime(&result, start.In(to), false); err != nil {
+			return DateTimeResult{}, "", err
+		}
+		text = fmt.Sprintf("%s = %s (%s, %s)", start.Format(time.RFC3339Nano), result.Result, result.Weekday, result.Timezone)
+
+	case "iso-week":
+		year, week := start.ISOWeek()
+		weekday := (int(start.Weekday())+6)%7 + 1
+		result.ISOWeek = &ISOWeek{
+			Year:      year,
+			Week:      week,
+			Weekday:   weekday,
+			DayOfYear: start.YearDay(),
+			Text:      fmt.Sprintf("%04d-W%02d-%d", year, week, weekday),
+		}
+		describeTime(&result, start, startDate)
+		text = fmt.Sprintf("%s is %s, %s of ISO week %d of %d (day %d of the year)",
+			result.Result, result.ISOWeek.Text, result.Weekday, week, year, result.ISOWeek.DayOfYear)
+
+	case "add-business-days":
+		w := newBusinessWeek(param)
+		days := *param.Days
+		step := 1
+		if days < 0 {
+			step = -1
+		}
+		wall := start.In(loc)
+		date := dateOf(wall)
+		if business, _ := w.businessDay(date); days == 0 && !business {
+			notes = append(notes, "start is not a business day; the next business day was used")
+			days = 1
+		}
+		var skipped []string
+		for remaining := days * step; remaining > 0; {
+			date = date.AddDate(0, 0, step)
+			if date.Year() < 1 || date.Year() > 9999 {
+				return DateTimeResult{}, "", errors.New("the result is outside the years 1 to 9999")
+			}
+			business, holiday := w.businessDay(date)
+			if business {
+				remaining--
+			} else if holiday != "" {
+				skipped = append(skipped, holiday)
+			}
+		}
+		t := date
+		if !startDate {
+			var note string
+			t, note = wallClock(time.Date(date.Year(), date.Month(), date.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC), loc)
+			if note != "" {
+				notes = append(notes, note)
+			}
+		}
+		describeTime(&result, t, startDate)
+		result.Holidays, notes = listHolidays(skipped, notes)
+		text = fmt.Sprintf("%d business day%s from %s is %s (%s)", *param.Days, plural(max(*param.Days, -*param.Days)), strings.TrimSpace(param.Start), result.Result, result.Weekday)
+		if len(result.Holidays) > 0 {
+			text += "\nSkipped holidays: " + strings.Join(result.Holidays, ", ")
+		}
+
+	case "business-days":
+		w := newBusinessWeek(param)
+		end, _, _, _ := parseTimestamp(param.End, loc)
+		from, to := dateOf(start.In(loc)), dateOf(end.In(loc))
+		sign := 1
+		if to.Before(from) {
+			from, to, sign = to, from, -1
+		}
+		count := 0
+		var skipped []string
+		for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+			business, holiday := w.businessDay(date)
+			if business {
+				count++
+			} else if holiday != "" {
+				skipped = append(skipped, holiday)
+			}
+		}
+		count *= sign
+		result.BusinessDays = &count
+		result.Holidays, notes = listHolidays(skipped, notes)
+		text = fmt.Sprintf("%d business day%s from %s up to %s", count, plural(max(count, -count)), from.Format(time.DateOnly), to.Format(time.DateOnly))
+		if sign < 0 {
+			text = fmt.Sprintf("%d business day%s from %s back to %s", count, plural(max(count, -count)), to.Format(time.DateOnly), from.Format(time.DateOnly))
+		}
+		if len(result.Holidays) > 0 {
+			text += "\nSkipped holidays: " + strings.Join(result.Holidays, ", ")
+		}
+	}
+
+	result.Note = strings.Join(notes, "; ")
+	if result.Note != "" {
+		text += "\nNote: " + result.Note
+	}
+	return result, text, nil
+}
+
+// describeTime fills in the result, weekday and time zone of t.
+func describeTime(result *DateTimeResult, t time.Time, dateOnly bool) error {
+	if t.Year() < 1 || t.Year() > 9999 {
+		return errors.New("the result is outside the years 1 to 9999")
+	}
+	result.Weekday = t.Weekday().String()
+	if dateOnly {
+		result.Result = t.Format(time.DateOnly)
+		return nil
+	}
+	result.Result = t.Format(time.RFC3339Nano)
+	abbreviation, offset := t.Zone()
+	result.Timezone = t.Location().String()
+	if abbreviation != result.Timezone {
+		result.Timezone = fmt.Sprintf("%s (%s, %s)", t.Location(), abbreviation, formatOffset(offset))
+	}
+	return nil
+}
+
+// listHolidays caps the list of skipped holidays at maxListedHolidays.
+func listHolidays(skipped, notes []string) ([]string, []string) {
+	if len(skipped) > maxListedHolidays {
+		notes = append(notes, fmt.Sprintf("%d more holidays were skipped", len(skipped)-maxListedHolidays))
+		skipped = skipped[:maxListedHolidays]
+	}
+	return skipped, notes
+}
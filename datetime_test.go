@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddToAcrossDaylightSavingTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	tests := []struct {
+		name  string
+		start time.Time
+		want  string
+		note  bool
+	}{
+		// 2026-03-08 02:30 is skipped; the clocks jump from 02:00 to 03:00.
+		{"gap", time.Date(2026, 3, 7, 2, 30, 0, 0, loc), "2026-03-08T03:30:00-04:00", true},
+		// 2026-11-01 01:30 happens twice; the first, in EDT, is used.
+		{"overlap", time.Date(2026, 10, 31, 1, 30, 0, 0, loc), "2026-11-01T01:30:00-04:00", true},
+		{"ordinary day", time.Date(2026, 3, 9, 2, 30, 0, 0, loc), "2026-03-10T02:30:00-04:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notes := calendarDuration{days: 1}.addTo(tt.start, loc)
+			if s := got.Format(time.RFC3339); s != tt.want {
+				t.Errorf("addTo = %s, want %s", s, tt.want)
+			}
+			if (len(notes) > 0) != tt.note {
+				t.Errorf("notes = %q, want a note: %v", notes, tt.note)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultDivideScale is the number of fractional digits kept by a
+	// decimal-mode division when the caller does not give a scale.
+	defaultDivideScale = 20
+	// maxDecimalScale bounds the scale a caller may request.
+	maxDecimalScale = 100
+	// maxDecimalExponent bounds the exponent accepted in decimal strings so
+	// that "1e999999999" cannot be used to exhaust memory.
+	maxDecimalExponent = 1000
+)
+
+// roundingModes lists the rounding rules accepted by the decimal mode.
+var roundingModes = []interface{}{"half-even", "half-up", "down", "up", "ceiling", "floor"}
+
+// decimalPattern matches a plain decimal number with an optional exponent.
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+// parseDecimal parses s as an exact decimal number.
+func parseDecimal(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return nil, fmt.Errorf("invalid decimal number %q", s)
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil || exp > maxDecimalExponent || exp < -maxDecimalExponent {
+			return nil, fmt.Errorf("exponent of %q is out of range", s)
+		}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal number %q", s)
+	}
+	return r, nil
+}
+
+// roundRat rounds r to scale fractional digits using the given rounding mode
+// and returns the result as an unscaled integer, i.e. r*10^scale rounded.
+func roundRat(r *big.Rat, scale int, mode string) *big.Int {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	q, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+
+	sign := int64(scaled.Sign())
+	// Compare the discarded fraction against one half: 2|rem| vs denom.
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(scaled.Denom())
+
+	awayFromZero := false
+	switch mode {
+	case "down":
+	case "up":
+		awayFromZero = true
+	case "ceiling":
+		awayFromZero = sign > 0
+	case "floor":
+		awayFromZero = sign < 0
+	case "half-up":
+		awayFromZero = cmp >= 0
+	default: // half-even
+		awayFromZero = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	}
+	if awayFromZero {
+		q.Add(q, big.NewInt(sign))
+	}
+	return q
+}
+
+// formatScaled renders unscaled*10^-scale as a decimal string with exactly
+// scale fractional digits.
+func formatScaled(unscaled *big.Int, scale int) string {
+	digits := new(big.Int).Abs(unscaled).String()
+	sign := ""
+	if unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if scale <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// calculateDecimal applies operation to the exact operands. The
+// exact result is rounded to scale digits with the given rounding mode. A nil
+// scale rounds quotients (divide and average) to defaultDivideScale digits
+// and keeps other results exact, unless their decimal expansion does not
+// terminate within twice maxDecimalScale digits, in which case they are
+// rounded like quotients.
+func calculateDecimal(operation string, operands []*big.Rat, scale *int, rounding string) (string, *big.Rat, error) {
+	exact, err := calculateExact(operation, operands)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if scale == nil && operation != "divide" && operation != "average" {
+		if digits, ok := terminatingDigits(exact); ok && digits <= maxDecimalScale*2 {
+			return ratDecimalString(exact, maxDecimalScale*2), exact, nil
+		}
+	}
+	digits := defaultDivideScale
+	if scale != nil {
+		digits = *scale
+	}
+	unscaled := roundRat(exact, digits, rounding)
+	rounded := new(big.Rat).SetFrac(unscaled, pow10(digits))
+	return formatScaled(unscaled, digits), rounded, nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	maxDice          = 1000
+	maxDieSides      = 1_000_000
+	maxDiceModifier  = 1_000_000_000
+	maxDiceNotation  = 256
+	defaultDieSides  = 100 // for d%
+	diceNotationHelp = "use terms such as 4d6kh3, d20, 2d8 or 3 joined by + and -"
+)
+
+// RollDiceParams defines the parameters for the roll-dice tool.
+type RollDiceParams struct {
+	Notation string `json:"notation" jsonschema:"dice notation: terms NdS (N dice with S sides; N defaults to 1, d% is d100) or whole numbers joined by + and -; a dice term may end in khK/klK to keep the K highest/lowest rolls or dhK/dlK to drop them, e.g. 4d6kh3+2"`
+	Seed     *int64 `json:"seed,omitempty" jsonschema:"reseed this session's random stream for reproducible results; later calls without a seed continue the sequence"`
+	Secure   bool   `json:"secure,omitempty" jsonschema:"draw from the cryptographically secure generator (crypto/rand) instead of the session stream; cannot be seeded"`
+}
+
+func (p RollDiceParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Notation,
+			validation.Required,
+			validation.RuneLength(1, maxDiceNotation),
+			validation.By(func(value interface{}) error {
+				_, err := parseDiceNotation(p.Notation)
+				return err
+			}),
+		),
+		validation.Field(&p.Seed,
+			validation.When(p.Secure, validation.Nil.Error("cannot be used with secure")),
+		),
+	)
+}
+
+// DiceRoll reports the rolls of one dice term.
+type DiceRoll struct {
+	Notation string `json:"notation" jsonschema:"the dice term, e.g. 4d6kh3"`
+	Rolls    []int  `json:"rolls" jsonschema:"every die rolled, in order"`
+	Kept     []bool `json:"kept" jsonschema:"whether each roll counts towards the total"`
+	Subtotal int    `json:"subtotal" jsonschema:"sum of the kept rolls, negated for a term after -"`
+}
+
+// RollDiceResult defines the result for the roll-dice tool.
+type RollDiceResult struct {
+	Total    int        `json:"total" jsonschema:"the result of the roll"`
+	Dice     []DiceRoll `json:"dice" jsonschema:"the rolls of each dice term"`
+	Modifier int        `json:"modifier" jsonschema:"sum of the constant terms"`
+	Min      int        `json:"min" jsonschema:"lowest possible total"`
+	Max      int        `json:"max" jsonschema:"highest possible total"`
+	Seed     *int64     `json:"seed,omitempty" jsonschema:"seed of this session's random stream; pass it back to reproduce the sequence; omitted in secure mode"`
+	Sequence int        `json:"sequence,omitempty" jsonschema:"number of calls drawing from the stream since it was seeded, including this one; omitted in secure mode"`
+}
+
+// diceTerm is one term of dice notation. A constant term has count 0.
+type diceTerm struct {
+	text     string
+	sign     int
+	count    int
+	sides    int
+	constant int
+	// keep is the number of rolls kept, highest first unless lowest is set.
+	keep   int
+	lowest bool
+}
+
+// parseDiceNotation splits notation into its terms. Whitespace is ignored and
+// letters are case-insensitive.
+func parseDiceNotation(notation string) ([]diceTerm, error) {
+	var terms []diceTerm
+	s := strings.ToLower(notation)
+	pos := 0
+	skipSpace := func() {
+		for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t') {
+			pos++
+		}
+	}
+	// number reads an unsigned integer, returning -1 if there is none.
+	number := func(limit int, what string) (int, error) {
+		start := pos
+		for pos < len(s) && s[pos] >= '0' && s[pos] <= '9' {
+			pos++
+		}
+		if start == pos {
+			return -1, nil
+		}
+		n, err := strconv.Atoi(s[start:pos])
+		if err != nil || n > limit {
+			return 0, fmt.Errorf("%s %s at position %d exceeds %d", what, s[start:pos], start+1, limit)
+		}
+		return n, nil
+	}
+	unexpected := func() error {
+		if pos >= len(s) {
+			return fmt.Errorf("unexpected end of notation at position %d; %s", pos+1, diceNotationHelp)
+		}
+		r, _ := utf8.DecodeRuneInString(s[pos:])
+		return fmt.Errorf("unexpected %q at position %d; %s", r, pos+1, diceNotationHelp)
+	}
+
+	totalDice := 0
+	for {
+		skipSpace()
+		term := diceTerm{sign: 1}
+		if len(terms) > 0 {
+			if pos >= len(s) {
+				break
+			}
+			switch s[pos] {
+			case '+':
+			case '-':
+				term.sign = -1
+			default:
+				return nil, unexpected()
+			}
+			pos++
+			skipSpace()
+		} else if pos < len(s) && s[pos] == '-' {
+			term.sign = -1
+			pos++
+			skipSpace()
+		}
+		start := pos
+
+		n, err := number(maxDiceModifier, "number")
+		if err != nil {
+			return nil, err
+		}
+		if pos >= len(s) || s[pos] != 'd' {
+			if n < 0 {
+				return nil, unexpected()
+			}
+			term.constant = n
+			term.text = s[start:pos]
+			terms = append(terms, term)
+			continue
+		}
+
+		// A dice term: [count] d sides [keep/drop].
+		term.count = n
+		if n < 0 {
+			term.count = 1
+		}
+		if term.count < 1 {
+			return nil, fmt.Errorf("number of dice at position %d must be at least 1", start+1)
+		}
+		pos++
+		sidesAt := pos
+		if pos < len(s) && s[pos] == '%' {
+			term.sides = defaultDieSides
+			pos++
+		} else {
+			if term.sides, err = number(maxDieSides, "number of sides"); err != nil {
+				return nil, err
+			}
+			if term.sides < 0 {
+				return nil, fmt.Errorf("expected number of sides after 'd' at position %d", sidesAt+1)
+			}
+			if term.sides < 1 {
+				return nil, fmt.Errorf("number of sides at position %d must be at least 1", sidesAt+1)
+			}
+		}
+		term.keep = term.count
+
+		if pos < len(s) && (s[pos] == 'k' || s[pos] == 'd') {
+			modifierAt := pos
+			drop := s[pos] == 'd'
+			pos++
+			switch {
+			case pos < len(s) && s[pos] == 'h':
+				pos++
+			case pos < len(s) && s[pos] == 'l':
+				term.lowest = true
+				pos++
+			case drop:
+				// A bare d drops the lowest rolls, as in 4d6d1.
+				term.lowest = true
+			}
+			k, err := number(maxDice, "number of dice")
+			if err != nil {
+				return nil, err
+			}
+			if k < 0 {
+				return nil, fmt.Errorf("expected a number of dice to keep or drop at position %d", pos+1)
+			}
+			if k > term.count {
+				return nil, fmt.Errorf("cannot keep or drop %d of %d dice at position %d", k, term.count, modifierAt+1)
+			}
+			term.keep = k
+			if drop {
+				// Dropping the k lowest keeps the count-k highest.
+				term.keep = term.count - k
+				term.lowest = !term.lowest
+			}
+		}
+		term.text = s[start:pos]
+
+		totalDice += term.count
+		if totalDice > maxDice {
+			return nil, fmt.Errorf("notation rolls more than %d dice", maxDice)
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil, errors.New("notation is empty")
+	}
+	return terms, nil
+}
+
+// roll rolls the dice of t and marks the ones that count.
+func (t diceTerm) roll(src randomSource) (DiceRoll, error) {
+	result := DiceRoll{Notation: t.text, Rolls: make([]int, t.count), Kept: make([]bool, t.count)}
+	for i := range result.Rolls {
+		v, err := src.uniform(1, t.sides)
+		if err != nil {
+			return DiceRoll{}, err
+		}
+		result.Rolls[i] = v
+	}
+	// Rank the rolls from the best to keep to the worst, breaking ties by
+	// position so that the earlier of two equal rolls is kept.
+	order := make([]int, t.count)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if t.lowest {
+			return result.Rolls[a] - result.Rolls[b]
+		}
+		return result.Rolls[b] - result.Rolls[a]
+	})
+	for _, i := range order[:t.keep] {
+		result.Kept[i] = true
+		result.Subtotal += result.Rolls[i]
+	}
+	result.Subtotal *= t.sign
+	return result, nil
+}
+
+func handleRollDice(ctx context.Context, req *mcp.CallToolRequest, param RollDiceParams) (*mcp.CallToolResult, RollDiceResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			RollDiceResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	terms, _ := parseDiceNotation(param.Notation)
+	var result RollDiceResult
+	seed, sequence, source, err := randomDraw(req.Session, param.Seed, param.Secure, func(src randomSource) error {
+		for _, t := range terms {
+			if t.count == 0 {
+				continue
+			}
+			roll, err := t.roll(src)
+			if err != nil {
+				return err
+			}
+			result.Dice = append(result.Dice, roll)
+		}
+		return nil
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Generation error: %v", err)),
+			RollDiceResult{}, fmt.Errorf("generation error: %v", err)
+	}
+	result.Seed, result.Sequence = seed, sequence
+
+	var lines []string
+	for _, t := range terms {
+		lo, hi := t.constant, t.constant
+		if t.count > 0 {
+			lo, hi = t.keep, t.keep*t.sides
+		}
+		if t.sign < 0 {
+			lo, hi = -hi, -lo
+		}
+		result.Min += lo
+		result.Max += hi
+		if t.count == 0 {
+			result.Modifier += t.sign * t.constant
+		}
+	}
+	result.Total = result.Modifier
+	for _, d := range result.Dice {
+		result.Total += d.Subtotal
+		rolls := make([]string, len(d.Rolls))
+		for i, v := range d.Rolls {
+			rolls[i] = strconv.Itoa(v)
+			if !d.Kept[i] {
+				rolls[i] = "(" + rolls[i] + ")"
+			}
+		}
+		lines = append(lines, fmt.Sprintf("\n  %s: %s = %d", d.Notation, formatItems(rolls, maxListedNumbers), d.Subtotal))
+	}
+
+	text := fmt.Sprintf("Rolled %s = %d (%s)", strings.Join(strings.Fields(param.Notation), ""), result.Total, source)
+	if result.Modifier != 0 {
+		lines = append(lines, fmt.Sprintf("\n  modifier: %+d", result.Modifier))
+	}
+	text += strings.Join(lines, "")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
@@ -0,0 +1,563 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxSafeInteger is the largest integer every float64 below it represents
+// exactly; integer output and integer bounds are limited to ±maxSafeInteger.
+const maxSafeInteger = 1 << 53
+
+// maxTruncationAttempts bounds how often a value is redrawn because it fell
+// outside min and max before sampling gives up.
+const maxTruncationAttempts = 1000
+
+var distributionNames = []interface{}{
+	"uniform", "normal", "exponential", "poisson", "binomial", "geometric",
+	"gamma", "beta", "log-normal", "weibull", "triangular", "zipf",
+}
+
+// distributionParameters lists the parameters each distribution requires and
+// those it accepts optionally.
+var distributionParameters = map[string]struct{ required, optional []string }{
+	"uniform":     {},
+	"normal":      {optional: []string{"mean", "stddev"}},
+	"exponential": {optional: []string{"lambda"}},
+	"poisson":     {required: []string{"lambda"}},
+	"binomial":    {required: []string{"n", "p"}},
+	"geometric":   {required: []string{"p"}},
+	"gamma":       {required: []string{"alpha", "beta"}},
+	"beta":        {required: []string{"alpha", "beta"}},
+	"log-normal":  {required: []string{"mu", "sigma"}},
+	"weibull":     {required: []string{"shape", "scale"}},
+	"triangular":  {optional: []string{"mode"}},
+	"zipf":        {required: []string{"exponent", "n"}},
+}
+
+// rangeDistribution reports whether the distribution takes its support from
+// min and max, which default to 1 and 100. That holds for uniform and
+// triangular, and for normal and exponential when their own parameters are
+// omitted; every other distribution is unbounded unless min or max is given.
+func rangeDistribution(p GenerateRandomNumberParams) bool {
+	switch p.distribution() {
+	case "uniform", "triangular":
+		return true
+	case "normal":
+		return p.Mean == nil
+	case "exponential":
+		return p.Lambda == nil
+	}
+	return false
+}
+
+func (p GenerateRandomNumberParams) distribution() string {
+	if p.Distribution == "" {
+		return "uniform"
+	}
+	return p.Distribution
+}
+
+// numbers maps the JSON names of the real parameters to their values.
+func (p GenerateRandomNumberParams) numbers() map[string]interface{} {
+	return map[string]interface{}{
+		"min": p.Min, "max": p.Max,
+		"mean": p.Mean, "stddev": p.StdDev, "lambda": p.Lambda, "p": p.P,
+		"alpha": p.Alpha, "beta": p.Beta, "mu": p.Mu, "sigma": p.Sigma,
+		"shape": p.Shape, "scale": p.Scale, "mode": p.Mode, "exponent": p.Exponent,
+	}
+}
+
+// distributionArgs maps parameter names to their values, nil where they
+// are not given. The numbers must already have passed checkNumbers.
+func (p GenerateRandomNumberParams) distributionArgs() map[string]*float64 {
+	args := make(map[string]*float64)
+	for k, v := range p.numbers() {
+		if k != "min" && k != "max" {
+			args[k], _ = optionalFloat(v)
+		}
+	}
+	args["n"] = nil
+	if p.N != nil {
+		n := float64(*p.N)
+		args["n"] = &n
+	}
+	return args
+}
+
+// bounds returns the truncation bounds, infinite where none apply.
+func (p GenerateRandomNumberParams) bounds() (float64, float64) {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if rangeDistribution(p) {
+		lo, hi = 1, 100
+	}
+	if v, _ := optionalFloat(p.Min); v != nil {
+		lo = *v
+	}
+	if v, _ := optionalFloat(p.Max); v != nil {
+		hi = *v
+	}
+	return lo, hi
+}
+
+// checkDistribution validates the distribution parameters: that each is
+// used by the distribution, that required ones are present and that their
+// values are in range.
+func checkDistribution(p GenerateRandomNumberParams) error {
+	name := p.distribution()
+	spec, ok := distributionParameters[name]
+	if !ok {
+		return nil // reported by validation.In
+	}
+	args := p.distributionArgs()
+	names := make([]string, 0, len(args))
+	for k := range args {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	for _, k := range names {
+		if v := args[k]; v != nil {
+			if !slices.Contains(spec.required, k) && !slices.Contains(spec.optional, k) {
+				return fmt.Errorf("%s is not a parameter of the %s distribution", k, name)
+			}
+			if math.IsNaN(*v) || math.IsInf(*v, 0) {
+				return fmt.Errorf("%s must be a finite number", k)
+			}
+		}
+	}
+	for _, k := range spec.required {
+		if args[k] == nil {
+			return fmt.Errorf("the %s distribution requires %s", name, k)
+		}
+	}
+
+	positive := func(k string) error {
+		if v := args[k]; v != nil && *v <= 0 {
+			return fmt.Errorf("%s must be greater than 0", k)
+		}
+		return nil
+	}
+	var err error
+	switch name {
+	case "normal":
+		if (p.Mean == nil) != (p.StdDev == nil) {
+			return errors.New("mean and stddev must be given together")
+		}
+		err = positive("stddev")
+	case "exponential", "poisson":
+		err = positive("lambda")
+	case "binomial":
+		if prob := *args["p"]; prob < 0 || prob > 1 {
+			return errors.New("p must be between 0 and 1")
+		}
+		if *p.N < 0 || *p.N > maxSafeInteger {
+			return errors.New("n must be between 0 and 2^53")
+		}
+	case "geometric":
+		if prob := *args["p"]; prob <= 0 || prob > 1 {
+			return errors.New("p must be greater than 0 and at most 1")
+		}
+	case "gamma", "beta":
+		err = errors.Join(positive("alpha"), positive("beta"))
+	case "log-normal":
+		err = positive("sigma")
+	case "weibull":
+		err = errors.Join(positive("shape"), positive("scale"))
+	case "triangular":
+		lo, hi := p.bounds()
+		if mode := args["mode"]; mode != nil && (*mode < lo || *mode > hi) {
+			return fmt.Errorf("mode must lie between min (%g) and max (%g)", lo, hi)
+		}
+	case "zipf":
+		if *args["exponent"] <= 1 {
+			return errors.New("exponent must be greater than 1")
+		}
+		if *p.N < 1 {
+			return errors.New("n must be at least 1")
+		}
+	}
+	return err
+}
+
+// distributionSpec is a validated distribution with its parameters resolved.
+type distributionSpec struct {
+	name string
+	// params holds the resolved parameters, including derived defaults.
+	params map[string]float64
+	// lo and hi are the truncation bounds; values outside are redrawn.
+	lo, hi float64
+	// sampler returns a function drawing untruncated values from r.
+	sampler func(r *rand.Rand) func() float64
+}
+
+// resolveDistribution turns validated parameters into a distributionSpec,
+// deriving defaults for the range-based forms of normal, exponential and
+// triangular.
+func resolveDistribution(p GenerateRandomNumberParams) distributionSpec {
+	lo, hi := p.bounds()
+	spec := distributionSpec{name: p.distribution(), params: map[string]float64{}, lo: lo, hi: hi}
+	arg := func(k string) float64 {
+		v := *p.distributionArgs()[k]
+		spec.params[k] = v
+		return v
+	}
+	fixed := func(f func(r *rand.Rand) float64) func(r *rand.Rand) func() float64 {
+		return func(r *rand.Rand) func() float64 {
+			return func() float64 { return f(r) }
+		}
+	}
+
+	switch spec.name {
+	case "uniform":
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return lo + r.Float64()*(hi-lo) })
+	case "normal":
+		mean, stddev := (lo+hi)/2, (hi-lo)/6 // ~99.7% within range
+		if p.Mean != nil {
+			mean, stddev = arg("mean"), arg("stddev")
+		}
+		spec.params["mean"], spec.params["stddev"] = mean, stddev
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return mean + stddev*r.NormFloat64() })
+	case "exponential":
+		if p.Lambda != nil {
+			lambda := arg("lambda")
+			spec.sampler = fixed(func(r *rand.Rand) float64 { return r.ExpFloat64() / lambda })
+			break
+		}
+		// Without a rate the distribution starts at min with mean max-min.
+		lambda := 1 / (hi - lo)
+		spec.params["lambda"] = lambda
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return lo + r.ExpFloat64()/lambda })
+	case "poisson":
+		lambda := arg("lambda")
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return poissonSample(r, lambda) })
+	case "binomial":
+		n, prob := int(arg("n")), arg("p")
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return binomialSample(r, n, prob) })
+	case "geometric":
+		prob := arg("p")
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return geometricSample(r, prob) })
+	case "gamma":
+		alpha, beta := arg("alpha"), arg("beta")
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return gammaSample(r, alpha) / beta })
+	case "beta":
+		alpha, beta := arg("alpha"), arg("beta")
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return betaSample(r, alpha, beta) })
+	case "log-normal":
+		mu, sigma := arg("mu"), arg("sigma")
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return math.Exp(mu + sigma*r.NormFloat64()) })
+	case "weibull":
+		shape, scale := arg("shape"), arg("scale")
+		spec.sampler = fixed(func(r *rand.Rand) float64 {
+			return scale * math.Pow(-math.Log(1-r.Float64()), 1/shape)
+		})
+	case "triangular":
+		mode := (lo + hi) / 2
+		if p.Mode != nil {
+			mode = arg("mode")
+		}
+		spec.params["mode"] = mode
+		spec.sampler = fixed(func(r *rand.Rand) float64 { return triangularSample(r, lo, mode, hi) })
+	case "zipf":
+		s, n := arg("exponent"), uint64(arg("n"))
+		spec.sampler = func(r *rand.Rand) func() float64 {
+			z := rand.NewZipf(r, s, 1, n-1)
+			return func() float64 { return float64(z.Uint64() + 1) }
+		}
+	}
+	return spec
+}
+
+// String describes the distribution and its parameters, e.g.
+// "poisson(lambda=4)".
+func (spec distributionSpec) String() string {
+	return formatDistribution(spec.name, spec.params)
+}
+
+// formatDistribution writes a distribution name followed by its parameters
+// in alphabetical order.
+func formatDistribution(name string, params map[string]float64) string {
+	if len(params) == 0 {
+		return name
+	}
+	names := make([]string, 0, len(params))
+	for k := range params {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	parts := make([]string, len(names))
+	for i, k := range names {
+		parts[i] = fmt.Sprintf("%s=%.6g", k, params[k])
+	}
+	return name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (spec distributionSpec) bounded() bool {
+	return !math.IsInf(spec.lo, -1) || !math.IsInf(spec.hi, 1)
+}
+
+// sampleValues draws count values from spec, rounded to integers unless
+// float is set. Values outside the bounds are redrawn rather than clamped,
+// which samples the truncated distribution exactly, and so are non-finite
+// draws. With unique set the values are drawn without replacement: integer
+// uniform samples use Floyd's algorithm followed by a shuffle, everything
+// else redraws duplicates.
+func sampleValues(src randomSource, spec distributionSpec, count int, float, unique bool) ([]float64, error) {
+	values := make([]float64, 0, count)
+	if !float && spec.name == "uniform" {
+		lo, hi := int(spec.lo), int(spec.hi)
+		if !unique {
+			for range count {
+				v, err := src.uniform(lo, hi)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, float64(v))
+			}
+			return values, nil
+		}
+		if span := uint64(hi-lo) + 1; uint64(count) > span {
+			return nil, fmt.Errorf("cannot draw %d distinct values from [%d, %d], which holds only %d", count, lo, hi, span)
+		}
+		seen := make(map[int]bool, count)
+		for i := range count {
+			j := hi - count + 1 + i
+			v, err := src.uniform(lo, j)
+			if err != nil {
+				return nil, err
+			}
+			if seen[v] {
+				v = j
+			}
+			seen[v] = true
+			values = append(values, float64(v))
+		}
+		for i := len(values) - 1; i > 0; i-- {
+			k, err := src.uniform(0, i)
+			if err != nil {
+				return nil, err
+			}
+			values[i], values[k] = values[k], values[i]
+		}
+		return values, nil
+	}
+
+	if unique && !float && spec.bounded() {
+		if span := math.Floor(spec.hi) - math.Ceil(spec.lo) + 1; float64(count) > span {
+			return nil, fmt.Errorf("cannot draw %d distinct integers from [%g, %g]", count, spec.lo, spec.hi)
+		}
+	}
+	next := spec.sampler(src.rand)
+	seen := make(map[float64]bool)
+	for attempts := 0; len(values) < count; attempts++ {
+		if attempts == maxTruncationAttempts*(len(values)+1) {
+			if unique && len(seen) > 0 {
+				return nil, fmt.Errorf("could not draw %d distinct values from %s; reduce count or widen min and max", count, spec)
+			}
+			return nil, fmt.Errorf("[%g, %g] holds too little of %s to sample by redrawing; widen min and max", spec.lo, spec.hi, spec)
+		}
+		v := next()
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		if !float {
+			v = math.Round(v)
+			if math.Abs(v) > maxSafeInteger {
+				return nil, fmt.Errorf("drew %g, which is too large for integer output; use output float", v)
+			}
+		}
+		if v < spec.lo || v > spec.hi || (unique && seen[v]) {
+			continue
+		}
+		if unique {
+			seen[v] = true
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// poissonSample draws from a Poisson distribution with mean lambda, by
+// multiplying uniforms for small means and by Hörmann's transformed
+// rejection (PTRS) otherwise.
+func poissonSample(r *rand.Rand, lambda float64) float64 {
+	if lambda < 10 {
+		limit := math.Exp(-lambda)
+		k, prod := 0.0, r.Float64()
+		for prod > limit {
+			k++
+			prod *= r.Float64()
+		}
+		return k
+	}
+	slam, loglam := math.Sqrt(lambda), math.Log(lambda)
+	b := 0.931 + 2.53*slam
+	a := -0.059 + 0.02483*b
+	invAlpha := 1.1239 + 1.1328/(b-3.4)
+	vr := 0.9277 - 3.6224/(b-2)
+	for {
+		u := r.Float64() - 0.5
+		v := r.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + lambda + 0.43)
+		if us >= 0.07 && v <= vr {
+			return k
+		}
+		if k < 0 || (us < 0.013 && v > us) {
+			continue
+		}
+		lg, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invAlpha)-math.Log(a/(us*us)+b) <= -lambda+k*loglam-lg {
+			return k
+		}
+	}
+}
+
+// binomialSample draws the number of successes in n trials with success
+// probability p. Large n is reduced by splitting at a beta-distributed order
+// statistic (Knuth, TAOCP 3.4.1) until direct simulation is cheap.
+func binomialSample(r *rand.Rand, n int, p float64) float64 {
+	k := 0
+	for n > 40 {
+		a := 1 + n/2
+		b := n + 1 - a
+		x := betaSample(r, float64(a), float64(b))
+		if x >= p {
+			n, p = a-1, p/x
+		} else {
+			k += a
+			n, p = b-1, (p-x)/(1-x)
+		}
+	}
+	for range n {
+		if r.Float64() < p {
+			k++
+		}
+	}
+	return float64(k)
+}
+
+// geometricSample draws the number of trials up to and including the first
+// success.
+func geometricSample(r *rand.Rand, p float64) float64 {
+	if p == 1 {
+		return 1
+	}
+	return math.Max(1, math.Ceil(math.Log(1-r.Float64())/math.Log1p(-p)))
+}
+
+// gammaSample draws from a gamma distribution with the given shape and unit
+// scale using the method of Marsaglia and Tsang.
+func gammaSample(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		return gammaSample(r, shape+1) * math.Pow(r.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+// betaSample draws from a beta distribution as a ratio of gamma variates,
+// X/(X+Y) = 1/(1+Y/X). The variates are compared by their logarithms, since
+// for small shapes both can underflow to 0.
+func betaSample(r *rand.Rand, alpha, beta float64) float64 {
+	return 1 / (1 + math.Exp(logGammaSample(r, beta)-logGammaSample(r, alpha)))
+}
+
+// logGammaSample draws the logarithm of a gamma variate with the given shape
+// and unit scale. Shapes below 1 use G(a) = G(a+1)·U^(1/a) in log space.
+func logGammaSample(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		return math.Log(gammaSample(r, shape+1)) + math.Log(1-r.Float64())/shape
+	}
+	return math.Log(gammaSample(r, shape))
+}
+
+// triangularSample inverts the CDF of the triangular distribution on
+// [lo, hi] with peak at mode.
+func triangularSample(r *rand.Rand, lo, mode, hi float64) float64 {
+	u := r.Float64()
+	if u < (mode-lo)/(hi-lo) {
+		return lo + math.Sqrt(u*(hi-lo)*(mode-lo))
+	}
+	return hi - math.Sqrt((1-u)*(hi-lo)*(hi-mode))
+}
+
+// HistogramBin counts the sampled values between Low and High.
+type HistogramBin struct {
+	Low   float64 `json:"low" jsonschema:"lower edge of the bin (inclusive)"`
+	High  float64 `json:"high" jsonschema:"upper edge of the bin; inclusive for integer output and for the last bin"`
+	Count int     `json:"count" jsonschema:"number of values in the bin"`
+}
+
+// histogram splits [lo, hi] into at most bins bins and counts values in each.
+// Infinite bounds are replaced by the smallest and largest value. Integer
+// bins have equal integer width (the last may be narrower).
+func histogram(values []float64, lo, hi float64, bins int, float bool) []HistogramBin {
+	if math.IsInf(lo, -1) {
+		lo = slices.Min(values)
+	}
+	if math.IsInf(hi, 1) {
+		hi = slices.Max(values)
+	}
+
+	if !float {
+		span := hi - lo + 1
+		width := math.Ceil(span / float64(bins))
+		result := make([]HistogramBin, int(math.Ceil(span/width)))
+		for i := range result {
+			low := lo + float64(i)*width
+			result[i] = HistogramBin{Low: low, High: math.Min(low+width-1, hi)}
+		}
+		for _, v := range values {
+			result[int((v-lo)/width)].Count++
+		}
+		return result
+	}
+
+	width := (hi - lo) / float64(bins)
+	if width == 0 {
+		return []HistogramBin{{Low: lo, High: hi, Count: len(values)}}
+	}
+	result := make([]HistogramBin, bins)
+	for i := range result {
+		result[i] = HistogramBin{Low: lo + float64(i)*width, High: lo + float64(i+1)*width}
+	}
+	result[bins-1].High = hi
+	for _, v := range values {
+		result[min(int((v-lo)/width), bins-1)].Count++
+	}
+	return result
+}
+
+// formatValues lists up to limit values, noting how many were left out.
+func formatValues(values []float64, limit int) string {
+	parts := make([]string, 0, min(len(values), limit))
+	for _, v := range values[:min(len(values), limit)] {
+		format := byte('g')
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			format = 'f'
+		}
+		parts = append(parts, strconv.FormatFloat(v, format, -1, 64))
+	}
+	text := strings.Join(parts, ", ")
+	if len(values) > limit {
+		text += fmt.Sprintf(", ... (%d more)", len(values)-limit)
+	}
+	return text
+}
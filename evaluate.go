@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// EvaluateParams defines the parameters for the evaluate tool.
+type EvaluateParams struct {
+	Expression string `json:"expression" jsonschema:"arithmetic expression, e.g. (3+4)*2^5/sqrt(7); supports + - * / % ^, parentheses, unary minus, functions and constants such as pi and e"`
+}
+
+func (p EvaluateParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Expression, validation.Required, validation.Length(1, 4096)),
+	)
+}
+
+// ParseNode is one node of the parse tree returned by the evaluate tool.
+// Nodes are listed in pre-order; the root is at index 0.
+type ParseNode struct {
+	Kind     string  `json:"kind" jsonschema:"node kind: number, identifier, unary, binary or call"`
+	Text     string  `json:"text" jsonschema:"literal, identifier, operator or function name"`
+	Value    float64 `json:"value" jsonschema:"value of the sub-expression rooted at this node"`
+	Children []int   `json:"children,omitempty" jsonschema:"indices of the child nodes in the tree"`
+}
+
+// EvaluateResult defines the result for the evaluate tool.
+type EvaluateResult struct {
+	Result     float64     `json:"result" jsonschema:"value of the expression"`
+	Expression string      `json:"expression" jsonschema:"the expression with explicit parentheses"`
+	Tree       []ParseNode `json:"tree" jsonschema:"parse tree in pre-order, root first"`
+}
+
+func handleEvaluate(ctx context.Context, req *mcp.CallToolRequest, param EvaluateParams) (*mcp.CallToolResult, EvaluateResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			EvaluateResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	root, err := parseExpression(param.Expression)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Invalid expression: %v", err)),
+			EvaluateResult{}, fmt.Errorf("invalid expression: %v", err)
+	}
+
+	var tree []ParseNode
+	result, err := flattenExpr(root, nil, &tree)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Evaluation error: %v", err)),
+			EvaluateResult{}, fmt.Errorf("evaluation error: %v", err)
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return errorResult("Evaluation error: result is not a finite number"),
+			EvaluateResult{}, fmt.Errorf("evaluation error: result is not a finite number")
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Result: %g", result)}},
+	}, EvaluateResult{
+		Result:     result,
+		Expression: formatExpr(root),
+		Tree:       tree,
+	}, nil
+}
+
+// flattenExpr evaluates node and appends it and its descendants to tree in
+// pre-order, recording the value of every sub-expression along the way.
+func flattenExpr(node *exprNode, vars map[string]float64, tree *[]ParseNode) (float64, error) {
+	index := len(*tree)
+	*tree = append(*tree, ParseNode{Kind: node.kind, Text: node.text})
+	args := make([]float64, len(node.args))
+	for i, arg := range node.args {
+		(*tree)[index].Children = append((*tree)[index].Children, len(*tree))
+		v, err := flattenExpr(arg, vars, tree)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	value, err := applyNode(node, args, vars)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%s at position %d is not a finite number", formatExpr(node), node.pos)
+	}
+	(*tree)[index].Value = value
+	return value, nil
+}
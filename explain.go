@@ -0,0 +1,595 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxExplanationSteps bounds the steps of a worked solution.
+	maxExplanationSteps = 40
+	// maxExplanationLength bounds the expression of the explanation prompt.
+	maxExplanationLength = 1000
+	// defaultExplanationLevel is used when the prompt is given no level.
+	defaultExplanationLevel = "high-school"
+)
+
+// explanationLevels describes the audience of each pedagogy level.
+var explanationLevels = map[string]string{
+	"elementary":  "an elementary school student. Use simple words, work every calculation on paper in columns and say what happens in each column",
+	"high-school": "a high school student. Name the order of operations (PEMDAS) at each step and show written methods briefly",
+	"university":  "a university student. Be concise, state precedence and associativity, and give exact values where they exist",
+}
+
+// legacyOperators maps the operations of the original operation, num1 and
+// num2 arguments to expression operators.
+var legacyOperators = map[string]string{"add": "+", "subtract": "-", "multiply": "*", "divide": "/"}
+
+// explanationStep is one reduction of a worked solution.
+type explanationStep struct {
+	reason string // why this part is worked out now
+	work   string // what is worked out, e.g. "3 × 4 = 12"
+	before string
+	after  string
+	layout string   // written method, shown in a monospaced block
+	notes  []string // sentences about the written method
+}
+
+// reducible is a node whose operands are all numbers, so that it can be
+// worked out next.
+type reducible struct {
+	node   *exprNode
+	parens bool // inside parentheses or function arguments
+}
+
+func handleCalculationExplanation(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := req.Params.Arguments
+	expression := strings.TrimSpace(args["expression"])
+	operation := args["operation"]
+	num1Str := args["num1"]
+	num2Str := args["num2"]
+
+	level := strings.NewReplacer(" ", "-", "_", "-").Replace(strings.ToLower(strings.TrimSpace(args["level"])))
+	if level == "" {
+		level = defaultExplanationLevel
+	}
+	if _, ok := explanationLevels[level]; !ok {
+		return explanationMessage(fmt.Sprintf("Invalid level: %s. Valid levels are: elementary, high-school, university", args["level"])), nil
+	}
+
+	var root *exprNode
+	switch {
+	case expression != "" && (operation != "" || num1Str != "" || num2Str != ""):
+		return explanationMessage("Please provide either an expression or operation, num1 and num2 arguments, not both"), nil
+	case expression != "":
+		if len(expression) > maxExplanationLength {
+			return explanationMessage(fmt.Sprintf("Invalid expression: must be at most %d characters long", maxExplanationLength)), nil
+		}
+		var err error
+		if root, err = parseExpression(expression); err != nil {
+			return explanationMessage(fmt.Sprintf("Invalid expression: %v", err)), nil
+		}
+	case operation == "" || num1Str == "" || num2Str == "":
+		return explanationMessage("Please provide an expression, or operation, num1 and num2 arguments"), nil
+	default:
+		op, ok := legacyOperators[operation]
+		if !ok {
+			return explanationMessage(fmt.Sprintf("Invalid operation: %s. Valid operations are: add, subtract, multiply, divide", operation)), nil
+		}
+		x1, err := parseNumber(num1Str, args["locale"])
+		if err != nil {
+			return explanationMessage(fmt.Sprintf("Invalid num1: %v", err)), nil
+		}
+		x2, err := parseNumber(num2Str, args["locale"])
+		if err != nil {
+			return explanationMessage(fmt.Sprintf("Invalid num2: %v", err)), nil
+		}
+		num1, _ := x1.Float64()
+		num2, _ := x2.Float64()
+		if math.IsInf(num1, 0) || math.IsInf(num2, 0) {
+			return explanationMessage("Invalid numbers: num1 and num2 must be within the range of float64"), nil
+		}
+		root = &exprNode{kind: nodeBinary, text: op, args: []*exprNode{
+			{kind: nodeNumber, text: stepNumber(num1), value: num1, pos: 1},
+			{kind: nodeNumber, text: stepNumber(num2), value: num2, pos: 1},
+		}}
+	}
+
+	problem, parsed := renderStep(root), formatExpr(root)
+	exact, isExact := exactValue(root)
+	steps, result, err := deriveSteps(root, level)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "cannot divide by zero") {
+			return explanationMessage("Cannot divide by zero. Division by zero is undefined in mathematics."), nil
+		}
+		return explanationMessage(fmt.Sprintf("Cannot work out %s: %v", problem, err)), nil
+	}
+
+	messages := []*mcp.PromptMessage{
+		{
+			Role: "user",
+			Content: &mcp.TextContent{Text: fmt.Sprintf("Explain step by step how to work out %s. The explanation is for %s.",
+				problem, explanationLevels[level])},
+		},
+		{
+			Role:    "assistant",
+			Content: &mcp.TextContent{Text: explanationPlan(problem, parsed, level, len(steps))},
+		},
+	}
+	for i, step := range steps {
+		messages = append(messages, &mcp.PromptMessage{
+			Role:    "assistant",
+			Content: &mcp.TextContent{Text: formatExplanationStep(i+1, step, level)},
+		})
+	}
+
+	answer := fmt.Sprintf("Answer: %s = %s", problem, stepNumber(result))
+	if len(steps) == 0 {
+		answer = "Answer: " + problem
+	}
+	if level == "university" && isExact && !exact.IsInt() {
+		answer += fmt.Sprintf("\nExact value: %s", exact.RatString())
+		if digits, ok := terminatingDigits(exact); !ok || strconv.FormatFloat(result, 'f', -1, 64) != exact.FloatString(digits) {
+			answer += " (the decimal above is rounded)"
+		}
+	}
+	messages = append(messages, &mcp.PromptMessage{
+		Role:    "assistant",
+		Content: &mcp.TextContent{Text: answer},
+	})
+
+	return &mcp.GetPromptResult{
+		Description: "Calculation explanation",
+		Messages:    messages,
+	}, nil
+}
+
+// explanationMessage returns a prompt result asking the user to correct the
+// arguments.
+func explanationMessage(text string) *mcp.GetPromptResult {
+	return &mcp.GetPromptResult{
+		Description: "Calculation explanation prompt",
+		Messages: []*mcp.PromptMessage{
+			{
+				Role:    "user",
+				Content: &mcp.TextContent{Text: text},
+			},
+		},
+	}
+}
+
+// explanationPlan introduces the order in which the steps are taken.
+func explanationPlan(problem, parsed, level string, steps int) string {
+	if steps == 0 {
+		return fmt.Sprintf("%s is already a single number, so there is nothing to work out.", problem)
+	}
+	count := fmt.Sprintf("%d step%s", steps, plural(steps))
+	switch level {
+	case "elementary":
+		return fmt.Sprintf("We will solve this in %s. We always work out brackets first, then powers, then × and ÷ from left to right, and + and - last, also from left to right.", count)
+	case "university":
+		return fmt.Sprintf("Precedence, highest first: function application; ^ (right-associative, binding tighter than unary minus, so -2^2 = -4); unary minus; ×, ÷ and mod (left-associative); + and - (left-associative). "+
+			"The expression parses as %s and reduces in %s.", parsed, count)
+	}
+	return fmt.Sprintf("We follow the order of operations, PEMDAS: Parentheses, Exponents, Multiplication and Division from left to right, then Addition and Subtraction from left to right. This takes %s.", count)
+}
+
+// formatExplanationStep renders one step as the text of a message.
+func formatExplanationStep(n int, step explanationStep, level string) string {
+	if level == "university" {
+		return fmt.Sprintf("Step %d (%s): %s\n%s\n= %s", n, step.reason, step.work, step.before, step.after)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Step %d: %s %s\n\n  %s\n= %s", n, step.reason, step.work, step.before, step.after)
+	if step.layout != "" {
+		fmt.Fprintf(&b, "\n\n```\n%s\n```", step.layout)
+	}
+	if len(step.notes) > 0 {
+		b.WriteString("\n\n" + strings.Join(step.notes, "\n"))
+	}
+	return b.String()
+}
+
+// deriveSteps works out root one operation at a time, in the order a student
+// would: constants, then the innermost parentheses, then functions,
+// exponents, negation, multiplication and division, and finally addition and
+// subtraction, each from left to right. It rewrites root in place and
+// returns the steps and the value of the expression.
+func deriveSteps(root *exprNode, level string) ([]explanationStep, float64, error) {
+	foldNegatives(root)
+	var steps []explanationStep
+	for root.kind != nodeNumber {
+		if len(steps) == maxExplanationSteps {
+			return nil, 0, fmt.Errorf("the expression needs more than %d steps; split it into smaller parts", maxExplanationSteps)
+		}
+		next := nextReducible(root)
+		node := next.node
+		args := make([]float64, len(node.args))
+		for i, arg := range node.args {
+			args[i] = arg.value
+		}
+		value, err := applyNode(node, args, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, 0, fmt.Errorf("%s is not a finite number", renderStep(node))
+		}
+		// 15 significant digits are the most a float64 always holds, so
+		// that 0.1 + 0.2 is shown as 0.3.
+		value = roundSignificant(value, 15)
+
+		step := explainReduction(node, args, value, next.parens, level)
+		step.before = renderStep(root)
+		*node = exprNode{kind: nodeNumber, text: stepNumber(value), value: value, pos: node.pos}
+		// A minus sign in front of the result, as in -2^2, goes with it.
+		foldNegatives(root)
+		step.after = renderStep(root)
+		steps = append(steps, step)
+	}
+	return steps, root.value, nil
+}
+
+// foldNegatives turns negated literals into negative numbers, so that -3 is
+// read as a number rather than as a step.
+func foldNegatives(node *exprNode) {
+	for _, arg := range node.args {
+		foldNegatives(arg)
+	}
+	if node.kind == nodeUnary && node.args[0].kind == nodeNumber {
+		value := -node.args[0].value
+		*node = exprNode{kind: nodeNumber, text: stepNumber(value), value: value, pos: node.pos}
+	}
+}
+
+// nextReducible picks the node to work out next.
+func nextReducible(root *exprNode) reducible {
+	var candidates []reducible
+	var depths []int
+	var walk func(node *exprNode, depth int)
+	walk = func(node *exprNode, depth int) {
+		ready := node.kind != nodeNumber
+		for i, arg := range node.args {
+			d := depth
+			if node.kind == nodeCall || needsParens(node, i, arg) {
+				d++
+			}
+			walk(arg, d)
+			ready = ready && arg.kind == nodeNumber
+		}
+		if ready {
+			candidates = append(candidates, reducible{node, depth > 0})
+			depths = append(depths, depth)
+		}
+	}
+	walk(root, 0)
+
+	// Candidates are in left-to-right order, so the first of the highest
+	// rank wins.
+	rank := func(i int) (int, int, int) {
+		constant := 1
+		if candidates[i].node.kind == nodeIdent {
+			constant = 0
+		}
+		return constant, -depths[i], operatorClass(candidates[i].node)
+	}
+	best := 0
+	for i := range candidates {
+		c, d, o := rank(i)
+		bc, bd, bo := rank(best)
+		if c < bc || c == bc && (d < bd || d == bd && o < bo) {
+			best = i
+		}
+	}
+	return candidates[best]
+}
+
+// operatorClass orders operations by precedence, lowest class first.
+func operatorClass(node *exprNode) int {
+	switch node.kind {
+	case nodeIdent:
+		return 0
+	case nodeCall:
+		return 1
+	case nodeUnary:
+		return 3
+	case nodeBinary:
+		switch node.text {
+		case "^":
+			return 2
+		case "*", "/", "%":
+			return 4
+		}
+	}
+	return 5
+}
+
+// explainReduction describes working out node, whose operands are the
+// numbers args, to value.
+func explainReduction(node *exprNode, args []float64, value float64, parens bool, level string) explanationStep {
+	var step explanationStep
+	result := stepNumber(value)
+	switch node.kind {
+	case nodeIdent:
+		step.reason = map[string]string{
+			"elementary":  fmt.Sprintf("%s is a special number with a name.", node.text),
+			"high-school": "Substitute the constant.",
+			"university":  "constant",
+		}[level]
+		step.work = fmt.Sprintf("%s ≈ %s", node.text, result)
+		if level == "elementary" {
+			step.work = fmt.Sprintf("Put in its value: %s is about %s.", node.text, result)
+		}
+		return step
+	case nodeCall:
+		step.work = fmt.Sprintf("%s = %s", renderStep(node), result)
+	case nodeUnary:
+		step.work = fmt.Sprintf("-(%s) = %s", stepNumber(args[0]), result)
+	default:
+		step.work = fmt.Sprintf("%s = %s", renderStep(node), result)
+	}
+
+	var reason string
+	switch level {
+	case "elementary":
+		reason = map[int]string{
+			1: "Work out the function.",
+			2: "Powers come before ×, ÷, + and -.",
+			3: "Apply the minus sign.",
+			4: "× and ÷ come before + and -, working from left to right.",
+			5: "+ and - come last, working from left to right.",
+		}[operatorClass(node)]
+		if parens {
+			reason = "Work inside the brackets first."
+		}
+		step.work = "Work out " + step.work + "."
+	case "university":
+		reason = map[int]string{
+			1: "function application",
+			2: "exponentiation, right-associative",
+			3: "negation",
+			4: "multiplicative, left-associative",
+			5: "additive, left-associative",
+		}[operatorClass(node)]
+		if parens {
+			reason = "innermost group, " + reason
+		}
+	default:
+		reason = map[int]string{
+			1: "Functions are evaluated before the operations around them.",
+			2: "E: exponents.",
+			3: "Negation.",
+			4: "MD: multiplication and division, from left to right.",
+			5: "AS: addition and subtraction, from left to right.",
+		}[operatorClass(node)]
+		if parens {
+			reason = "P: parentheses first."
+		}
+		step.work += "."
+	}
+	step.reason = reason
+
+	if node.kind == nodeBinary && level != "university" {
+		step.layout, step.notes = writtenMethod(node.text, args[0], args[1], value, level)
+	}
+	return step
+}
+
+// writtenMethod works a binary operation on paper where that helps: column
+// addition and subtraction, long multiplication and long division. Facts a
+// student knows by heart, such as 7 + 5 or 6 × 8, are left alone.
+func writtenMethod(op string, x, y, value float64, level string) (string, []string) {
+	narrate := level == "elementary"
+	a, okA := toColumnNumber(x)
+	b, okB := toColumnNumber(y)
+	if !okA || !okB {
+		return "", nil
+	}
+	single := func(n columnNumber) bool { return len(n.whole) == 1 && n.frac == "" }
+	if single(a) && single(b) && op != "/" && op != "%" {
+		return "", nil
+	}
+
+	var notes []string
+	switch op {
+	case "+", "-":
+		// Adding a negative number is subtracting, and subtracting one is
+		// adding.
+		sx, sy := math.Signbit(x), math.Signbit(y) != (op == "-")
+		if sx == sy {
+			if sx {
+				notes = append(notes, fmt.Sprintf("Both numbers are negative, so add %s and %s and make the answer negative.", a, b))
+			} else if op == "-" {
+				notes = append(notes, fmt.Sprintf("Subtracting a negative number is the same as adding: %s + %s.", a, b))
+			}
+			layout, steps := columnAddition(a, b, narrate)
+			return layout, append(notes, steps...)
+		}
+		larger, smaller := a, b
+		if math.Abs(y) > math.Abs(x) {
+			larger, smaller = b, a
+		}
+		if !(op == "-" && !sx && math.Abs(x) >= math.Abs(y)) {
+			sign := "positive"
+			if value < 0 {
+				sign = "negative"
+			}
+			notes = append(notes, fmt.Sprintf("The signs are different, so take %s away from %s and the answer is %s.", smaller, larger, sign))
+		}
+		layout, steps := columnSubtraction(larger, smaller, narrate)
+		return layout, append(notes, steps...)
+	case "*":
+		if math.Signbit(x) != math.Signbit(y) && value != 0 {
+			notes = append(notes, "One number is negative, so the product is negative.")
+		} else if math.Signbit(x) && math.Signbit(y) {
+			notes = append(notes, "Both numbers are negative, so the product is positive.")
+		}
+		// The number with more digits goes on top.
+		if len(a.whole+a.frac) < len(b.whole+b.frac) {
+			a, b = b, a
+		}
+		layout, steps, ok := longMultiplication(a, b, narrate)
+		if !ok {
+			return "", nil
+		}
+		return layout, append(notes, steps...)
+	case "/", "%":
+		if y == 0 || x == math.Trunc(x) && y == math.Trunc(y) && math.Abs(x) < 100 && math.Abs(y) < 10 && op == "/" && value == math.Trunc(value) {
+			// A times-table fact such as 56 ÷ 8.
+			return "", nil
+		}
+		whole := a.frac == "" && b.frac == ""
+		if op == "%" && (!whole || x < 0 || y < 0) {
+			return "", nil
+		}
+		if op == "/" && math.Signbit(x) != math.Signbit(y) && value != 0 {
+			notes = append(notes, "One number is negative, so the quotient is negative.")
+		} else if op == "/" && math.Signbit(x) && math.Signbit(y) {
+			notes = append(notes, "Both numbers are negative, so the quotient is positive.")
+		}
+		layout, steps, ok := longDivision(a, b, whole && (narrate || op == "%"), narrate)
+		if !ok {
+			return "", nil
+		}
+		notes = append(notes, steps...)
+		if op == "%" {
+			notes = append(notes, fmt.Sprintf("The remainder is what mod gives: %s mod %s = %s.", a, b, stepNumber(value)))
+		} else if whole && narrate && value != math.Trunc(value) {
+			notes = append(notes, fmt.Sprintf("As a decimal, %s ÷ %s = %s.", a, b, stepNumber(math.Abs(value))))
+		}
+		return layout, notes
+	}
+	return "", nil
+}
+
+// renderStep renders node in the usual notation with as few parentheses as
+// its structure allows, using × and ÷.
+func renderStep(node *exprNode) string {
+	switch node.kind {
+	case nodeNumber:
+		return stepNumber(node.value)
+	case nodeUnary:
+		return "-" + renderChild(node, 0)
+	case nodeBinary:
+		op := map[string]string{"*": "×", "/": "÷", "%": "mod"}[node.text]
+		if op == "" {
+			op = node.text
+		}
+		if node.text == "^" {
+			return renderChild(node, 0) + "^" + renderChild(node, 1)
+		}
+		return renderChild(node, 0) + " " + op + " " + renderChild(node, 1)
+	case nodeCall:
+		args := make([]string, len(node.args))
+		for i, arg := range node.args {
+			args[i] = renderStep(arg)
+		}
+		return node.text + "(" + strings.Join(args, ", ") + ")"
+	}
+	return node.text
+}
+
+func renderChild(node *exprNode, i int) string {
+	if needsParens(node, i, node.args[i]) {
+		return "(" + renderStep(node.args[i]) + ")"
+	}
+	return renderStep(node.args[i])
+}
+
+// precedence returns the binding strength of node as printed: negative
+// numbers print like negation.
+func precedence(node *exprNode) int {
+	switch node.kind {
+	case nodeBinary:
+		switch node.text {
+		case "+", "-":
+			return 1
+		case "*", "/", "%":
+			return 2
+		}
+		return 4
+	case nodeUnary:
+		return 3
+	case nodeNumber:
+		if math.Signbit(node.value) {
+			return 3
+		}
+	}
+	return 5
+}
+
+// needsParens reports whether the ith operand of node must be parenthesized
+// to keep the structure of the tree. Negative operands on the right are
+// parenthesized too, as in 5 - (-3), since that is how they are written.
+func needsParens(node *exprNode, i int, child *exprNode) bool {
+	p, c := precedence(node), precedence(child)
+	switch {
+	case node.kind == nodeCall:
+		return false
+	case node.kind == nodeUnary:
+		return c <= 3
+	case node.text == "^":
+		return c <= 4 && i == 0 || c <= 3
+	case i == 1:
+		return c <= p || c == 3
+	}
+	return c < p
+}
+
+// stepNumber formats x in plain decimal notation unless it is very large or
+// small.
+func stepNumber(x float64) string {
+	if x == 0 {
+		return "0"
+	}
+	if math.Abs(x) >= 1e15 || math.Abs(x) < 1e-6 {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+// exactValue evaluates node in rational arithmetic when it uses only
+// decimal literals, + - × ÷ and whole-number powers.
+func exactValue(node *exprNode) (*big.Rat, bool) {
+	args := make([]*big.Rat, len(node.args))
+	for i, arg := range node.args {
+		v, ok := exactValue(arg)
+		if !ok {
+			return nil, false
+		}
+		args[i] = v
+	}
+	switch node.kind {
+	case nodeNumber:
+		r, err := parseDecimal(node.text)
+		return r, err == nil
+	case nodeUnary:
+		return new(big.Rat).Neg(args[0]), true
+	case nodeBinary:
+		var r *big.Rat
+		var err error
+		switch node.text {
+		case "+":
+			r, err = calculateExact("add", args)
+		case "-":
+			r, err = calculateExact("subtract", args)
+		case "*":
+			r, err = calculateExact("multiply", args)
+		case "/":
+			r, err = calculateExact("divide", args)
+		case "^":
+			r, err = ratPow(args[0], args[1])
+		default:
+			err = errors.New("not exact")
+		}
+		return r, err == nil
+	}
+	return nil, false
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Node kinds produced by the expression parser.
+const (
+	nodeNumber = "number"
+	nodeIdent  = "identifier"
+	nodeUnary  = "unary"
+	nodeBinary = "binary"
+	nodeCall   = "call"
+)
+
+// exprNode is a node of a parsed arithmetic expression.
+type exprNode struct {
+	kind  string
+	text  string // literal text, identifier, operator or function name
+	value float64
+	args  []*exprNode
+	pos   int
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits an expression into tokens. Positions are 1-based rune
+// offsets so they can be reported back to the caller as-is.
+func tokenize(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					for j < len(runes) && unicode.IsDigit(runes[j]) {
+						j++
+					}
+					i = j
+				}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: start + 1})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), pos: start + 1})
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			tokens = append(tokens, token{kind: tokOperator, text: "^", pos: i + 1})
+			i += 2
+		case strings.ContainsRune("+-*/^%", r):
+			tokens = append(tokens, token{kind: tokOperator, text: string(r), pos: i + 1})
+			i++
+		case r == '×':
+			tokens = append(tokens, token{kind: tokOperator, text: "*", pos: i + 1})
+			i++
+		case r == '÷':
+			tokens = append(tokens, token{kind: tokOperator, text: "/", pos: i + 1})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i + 1})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i + 1})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i+1)
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes) + 1})
+	return tokens, nil
+}
+
+// exprParser is a recursive-descent parser with the usual precedence:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("-" | "+") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number [ power ] | identifier | identifier "(" args ")" | "(" expr ")"
+//
+// Exponentiation is right-associative and binds tighter than unary minus,
+// so -2^2 is -4. A number directly followed by an identifier or a
+// parenthesis is multiplied by it, as in 3x^2 or 2(x + 1); the implicit
+// product binds tighter than division, so 1/2x is 1/(2*x).
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseExpression parses input into an expression tree.
+func parseExpression(input string) (*exprNode, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, errors.New("expression is empty")
+	}
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) parseExpr() (*exprNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+", "-") {
+		op := p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{kind: nodeBinary, text: op.text, args: []*exprNode{left, right}, pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseTerm() (*exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*", "/", "%") {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{kind: nodeBinary, text: op.text, args: []*exprNode{left, right}, pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (*exprNode, error) {
+	if p.isOperator("-", "+") {
+		op := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op.text == "+" {
+			return operand, nil
+		}
+		return &exprNode{kind: nodeUnary, text: "-", args: []*exprNode{operand}, pos: op.pos}, nil
+	}
+	return p.parsePower()
+}
+
+func (p *exprParser) parsePower() (*exprNode, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOperator("^") {
+		op := p.next()
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNode{kind: nodeBinary, text: "^", args: []*exprNode{base, exponent}, pos: op.pos}, nil
+	}
+	return base, nil
+}
+
+func (p *exprParser) parsePrimary() (*exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		number := &exprNode{kind: nodeNumber, text: tok.text, value: value, pos: tok.pos}
+		if next := p.peek(); next.kind == tokIdent || next.kind == tokLParen {
+			factor, err := p.parsePower()
+			if err != nil {
+				return nil, err
+			}
+			return &exprNode{kind: nodeBinary, text: "*", args: []*exprNode{number, factor}, pos: next.pos}, nil
+		}
+		return number, nil
+	case tokIdent:
+		if p.peek().kind != tokLParen {
+			return &exprNode{kind: nodeIdent, text: tok.text, pos: tok.pos}, nil
+		}
+		p.next()
+		call := &exprNode{kind: nodeCall, text: tok.text, pos: tok.pos}
+		if p.peek().kind == tokRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek().kind == tokComma {
+				p.next()
+				continue
+			}
+			if closing := p.next(); closing.kind != tokRParen {
+				return nil, fmt.Errorf("expected ')' to close call to %s at position %d", tok.text, closing.pos)
+			}
+			return call, nil
+		}
+	case tokLParen:
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected ')' at position %d", closing.pos)
+		}
+		return node, nil
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression at position %d", tok.pos)
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+// exprFunc describes a function callable from an expression. A negative
+// arity accepts one or more arguments.
+type exprFunc struct {
+	arity int
+	fn    func(args []float64) (float64, error)
+}
+
+func unaryFunc(fn func(float64) float64) exprFunc {
+	return exprFunc{arity: 1, fn: func(args []float64) (float64, error) { return fn(args[0]), nil }}
+}
+
+var exprFunctions = map[string]exprFunc{
+	"sqrt": {arity: 1, fn: func(args []float64) (float64, error) {
+		if args[0] < 0 {
+			return 0, fmt.Errorf("cannot take the square root of a negative number (%g)", args[0])
+		}
+		return math.Sqrt(args[0]), nil
+	}},
+	"cbrt":  unaryFunc(math.Cbrt),
+	"abs":   unaryFunc(math.Abs),
+	"sin":   unaryFunc(math.Sin),
+	"cos":   unaryFunc(math.Cos),
+	"tan":   unaryFunc(math.Tan),
+	"asin":  unaryFunc(math.Asin),
+	"acos":  unaryFunc(math.Acos),
+	"atan":  unaryFunc(math.Atan),
+	"sinh":  unaryFunc(math.Sinh),
+	"cosh":  unaryFunc(math.Cosh),
+	"tanh":  unaryFunc(math.Tanh),
+	"exp":   unaryFunc(math.Exp),
+	"floor": unaryFunc(math.Floor),
+	"ceil":  unaryFunc(math.Ceil),
+	"round": unaryFunc(math.Round),
+	"ln": {arity: 1, fn: func(args []float64) (float64, error) {
+		if args[0] <= 0 {
+			return 0, fmt.Errorf("cannot take the logarithm of a non-positive number (%g)", args[0])
+		}
+		return math.Log(args[0]), nil
+	}},
+	"log": {arity: 1, fn: func(args []float64) (float64, error) {
+		if args[0] <= 0 {
+			return 0, fmt.Errorf("cannot take the logarithm of a non-positive number (%g)", args[0])
+		}
+		return math.Log10(args[0]), nil
+	}},
+	"log2": {arity: 1, fn: func(args []float64) (float64, error) {
+		if args[0] <= 0 {
+			return 0, fmt.Errorf("cannot take the logarithm of a non-positive number (%g)", args[0])
+		}
+		return math.Log2(args[0]), nil
+	}},
+	"atan2": {arity: 2, fn: func(args []float64) (float64, error) { return math.Atan2(args[0], args[1]), nil }},
+	"pow":   {arity: 2, fn: func(args []float64) (float64, error) { return math.Pow(args[0], args[1]), nil }},
+	"hypot": {arity: 2, fn: func(args []float64) (float64, error) { return math.Hypot(args[0], args[1]), nil }},
+	"min": {arity: -1, fn: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, v := range args[1:] {
+			result = math.Min(result, v)
+		}
+		return result, nil
+	}},
+	"max": {arity: -1, fn: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, v := range args[1:] {
+			result = math.Max(result, v)
+		}
+		return result, nil
+	}},
+}
+
+// evalExpr evaluates node. Identifiers are looked up in vars first and then
+// in mathConstants.
+func evalExpr(node *exprNode, vars map[string]float64) (float64, error) {
+	args := make([]float64, len(node.args))
+	for i, arg := range node.args {
+		v, err := evalExpr(arg, vars)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return applyNode(node, args, vars)
+}
+
+// applyNode computes the value of node given the already evaluated values of
+// its children.
+func applyNode(node *exprNode, args []float64, vars map[string]float64) (float64, error) {
+	switch node.kind {
+	case nodeNumber:
+		return node.value, nil
+	case nodeIdent:
+		if v, ok := vars[node.text]; ok {
+			return v, nil
+		}
+		if v, ok := mathConstants[node.text]; ok {
+			return v, nil
+		}
+		return 0, fmt.Errorf("unknown identifier %q at position %d", node.text, node.pos)
+	case nodeUnary:
+		return -args[0], nil
+	case nodeBinary:
+		return applyBinary(node.text, args[0], args[1], node.pos)
+	case nodeCall:
+		f, ok := exprFunctions[node.text]
+		if !ok {
+			return 0, fmt.Errorf("unknown function %q at position %d", node.text, node.pos)
+		}
+		if f.arity >= 0 && len(args) != f.arity {
+			return 0, fmt.Errorf("%s expects %d argument(s), got %d", node.text, f.arity, len(args))
+		}
+		if f.arity < 0 && len(args) == 0 {
+			return 0, fmt.Errorf("%s expects at least one argument", node.text)
+		}
+		return f.fn(args)
+	}
+	return 0, fmt.Errorf("unsupported node %q", node.kind)
+}
+
+func applyBinary(op string, left, right float64, pos int) (float64, error) {
+	switch op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, fmt.Errorf("cannot divide by zero at position %d", pos)
+		}
+		return left / right, nil
+	case "%":
+		if right == 0 {
+			return 0, fmt.Errorf("cannot divide by zero at position %d", pos)
+		}
+		return math.Mod(left, right), nil
+	case "^":
+		return math.Pow(left, right), nil
+	}
+	return 0, fmt.Errorf("unknown operator %q at position %d", op, pos)
+}
+
+// formatExpr renders node as a fully parenthesized infix string.
+func formatExpr(node *exprNode) string {
+	switch node.kind {
+	case nodeUnary:
+		return "(-" + formatExpr(node.args[0]) + ")"
+	case nodeBinary:
+		return "(" + formatExpr(node.args[0]) + " " + node.text + " " + formatExpr(node.args[1]) + ")"
+	case nodeCall:
+		args := make([]string, len(node.args))
+		for i, arg := range node.args {
+			args[i] = formatExpr(arg)
+		}
+		return node.text + "(" + strings.Join(args, ", ") + ")"
+	}
+	return node.text
+}
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// financePrecision is the mantissa size, in bits, of the money
+	// arithmetic: amounts are exact decimals, and growth factors are
+	// computed to far more digits than any amount is rounded to.
+	financePrecision = 256
+	// maxFinancePeriods bounds the periods of the time-value operations.
+	maxFinancePeriods = 100_000
+	// maxAmortizationRows bounds the rows of an amortization schedule.
+	maxAmortizationRows = 1200
+	// maxCashFlows bounds the cash flows of npv, irr and xirr.
+	maxCashFlows = 1000
+	// maxMoneyScale bounds the fractional digits of amounts.
+	maxMoneyScale = 10
+	// maxAmountExponent bounds results to below 10^maxAmountExponent.
+	maxAmountExponent = 40
+)
+
+var (
+	financeOperations = []interface{}{
+		"compound-interest", "future-value", "present-value", "payment",
+		"amortization", "npv", "irr", "xirr",
+	}
+	paymentTimings = []interface{}{"end", "begin"}
+)
+
+// FinanceParams defines the parameters for the finance tool.
+type FinanceParams struct {
+	Operation      string        `json:"operation" jsonschema:"'compound-interest' (what present_value grows to in years), 'future-value' (what present_value and regular deposits of payment grow to), 'present-value' (what future_value and regular payments of payment are worth today), 'payment' (the regular payment that repays the loan present_value down to future_value), 'amortization' (the loan's payment schedule), 'npv', 'irr' or 'xirr' of cash_flows"`
+	PresentValue   interface{}   `json:"present_value,omitempty" jsonschema:"the amount now: the principal, deposit or loan (default for future-value and payment: 0); a number or a string such as \"250000.00\" or \"250,000\""`
+	FutureValue    interface{}   `json:"future_value,omitempty" jsonschema:"present-value: the amount at the end; payment and amortization: the balance left owing after the last payment, or for a savings plan the amount to reach (default: 0)"`
+	Payment        interface{}   `json:"payment,omitempty" jsonschema:"future-value and present-value: the regular payment each period (default: 0)"`
+	Rate           interface{}   `json:"rate,omitempty" jsonschema:"the annual nominal interest rate as a fraction, such as 0.05 or \"5%\"; npv: the discount rate per period of the cash flows"`
+	Years          interface{}   `json:"years,omitempty" jsonschema:"compound-interest: the time in years, which may be fractional"`
+	Periods        int           `json:"periods,omitempty" jsonschema:"future-value, present-value, payment and amortization: the number of periods (payments)"`
+	PeriodsPerYear int           `json:"periods_per_year,omitempty" jsonschema:"periods a year: compounding periods of compound-interest (default: 1), otherwise payment periods (default: 12)"`
+	Continuous     bool          `json:"continuous,omitempty" jsonschema:"compound-interest: compound continuously instead of periods_per_year times a year"`
+	Timing         string        `json:"timing,omitempty" jsonschema:"when payments fall in each period: 'end' (default) or 'begin'"`
+	CashFlows      []interface{} `json:"cash_flows,omitempty" jsonschema:"npv, irr and xirr: the cash flows, negative for money paid out; the first is at time 0 and is not discounted"`
+	Dates          []string      `json:"dates,omitempty" jsonschema:"xirr: the date (2026-03-01) of each cash flow, none before the first"`
+	Scale          *int          `json:"scale,omitempty" jsonschema:"fractional digits of amounts (default: 2)"`
+	Rounding       string        `json:"rounding,omitempty" jsonschema:"rounding mode of amounts: 'half-even' (default), 'half-up', 'down', 'up', 'ceiling' or 'floor'"`
+	Locale         string        `json:"locale,omitempty" jsonschema:"separators of amounts given as strings: 'en' (default, 1,234.5), 'de' (1.234,5), 'fr' (1 234,5), 'ch' (1'234.5) or another language code"`
+}
+
+func (p FinanceParams) Validate() error {
+	if err := checkLocale(p.Locale); err != nil {
+		return err
+	}
+	op := p.Operation
+	uses := func(ops ...string) bool { return slices.Contains(ops, op) }
+	timeValue := uses("future-value", "present-value", "payment", "amortization")
+	flows := uses("npv", "irr", "xirr")
+	number := func(value interface{}) error {
+		_, err := decimalValue(value, p.Locale)
+		return err
+	}
+	nonNegative := func(value interface{}) error {
+		if x, err := decimalValue(value, p.Locale); err == nil && x.Sign() < 0 {
+			return errors.New("must not be negative")
+		}
+		return nil
+	}
+	only := func(description string) validation.Rule {
+		return validation.Nil.Error("only applies to " + description)
+	}
+	periodsPerYear := 12
+	if op == "compound-interest" {
+		periodsPerYear = 1
+	}
+	if p.PeriodsPerYear != 0 {
+		periodsPerYear = p.PeriodsPerYear
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Operation, validation.Required, validation.In(financeOperations...)),
+		validation.Field(&p.PresentValue,
+			validation.When(op == "compound-interest" || op == "amortization", validation.NotNil.Error("is required for "+op)),
+			validation.When(flows || op == "present-value", only("compound-interest, future-value, payment and amortization")),
+			validation.By(number), validation.By(nonNegative),
+		),
+		validation.Field(&p.FutureValue,
+			validation.When(!uses("present-value", "payment", "amortization"), only("present-value, payment and amortization")),
+			validation.By(number), validation.By(nonNegative),
+		),
+		validation.Field(&p.Payment,
+			validation.When(!uses("future-value", "present-value"), only("future-value and present-value")),
+			validation.By(number), validation.By(nonNegative),
+		),
+		validation.Field(&p.Rate,
+			validation.When(op != "irr" && op != "xirr", validation.NotNil.Error("is required for "+op)),
+			validation.When(op == "irr" || op == "xirr", validation.Nil.Error("does not apply to "+op+", which finds the rate")),
+			validation.By(number),
+			validation.By(func(value interface{}) error {
+				x, err := decimalValue(value, p.Locale)
+				if err != nil {
+					return nil
+				}
+				if x.Cmp(big.NewRat(100, 1)) > 0 {
+					return errors.New("must be at most 100 (10000%); give rates as fractions, such as 0.05 for 5%")
+				}
+				// A rate of -100% per period or below leaves nothing of a
+				// period's value.
+				floor := big.NewRat(-int64(periodsPerYear), 1)
+				if op == "npv" {
+					floor = big.NewRat(-1, 1)
+				}
+				if !p.Continuous && x.Cmp(floor) <= 0 {
+					return fmt.Errorf("must be above %s", floor.RatString())
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Years,
+			validation.When(op == "compound-interest", validation.NotNil.Error("is required for compound-interest")),
+			validation.When(op != "compound-interest", only("compound-interest")),
+			validation.By(number), validation.By(nonNegative),
+			validation.By(func(value interface{}) error {
+				if x, err := decimalValue(value, p.Locale); err == nil && x.Cmp(big.NewRat(1000, 1)) > 0 {
+					return errors.New("must be at most 1000")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Periods,
+			validation.When(timeValue, validation.Required.Error("is required for "+op)),
+			validation.When(!timeValue, validation.Empty.Error("only applies to future-value, present-value, payment and amortization")),
+			validation.Min(0),
+			validation.When(op == "amortization", validation.Max(maxAmortizationRows)),
+			validation.Max(maxFinancePeriods),
+		),
+		validation.Field(&p.PeriodsPerYear,
+			validation.When(flows, validation.Empty.Error("does not apply to "+op)),
+			validation.When(p.Continuous, validation.Empty.Error("cannot be combined with continuous")),
+			validation.Min(0), validation.Max(366),
+		),
+		validation.Field(&p.Continuous,
+			validation.When(op != "compound-interest", validation.Empty.Error("only applies to compound-interest")),
+		),
+		validation.Field(&p.Timing,
+			validation.In(paymentTimings...),
+			validation.When(!timeValue, validation.Empty.Error("only applies to future-value, present-value, payment and amortization")),
+		),
+		validation.Field(&p.CashFlows,
+			validation.When(flows, validation.Required.Error("is required for "+op), validation.Length(2, maxCashFlows)),
+			validation.When(!flows, only("npv, irr and xirr")),
+			validation.Each(validation.NotNil, validation.By(number)),
+			validation.When(op == "irr" || op == "xirr", validation.By(func(value interface{}) error {
+				var positive, negative bool
+				for _, v := range p.CashFlows {
+					if x, err := decimalValue(v, p.Locale); err == nil {
+						positive, negative = positive || x.Sign() > 0, negative || x.Sign() < 0
+					}
+				}
+				if !positive || !negative {
+					return errors.New("needs both a positive and a negative cash flow for a rate of return")
+				}
+				return nil
+			})),
+		),
+		validation.Field(&p.Dates,
+			validation.When(op == "xirr", validation.Required.Error("is required for xirr"),
+				validation.Length(len(p.CashFlows), len(p.CashFlows)).Error("must have one date per cash flow")),
+			validation.When(op != "xirr", only("xirr")),
+			validation.Each(validation.Date(time.DateOnly).Error("must be a date such as 2026-03-01")),
+			validation.By(func(value interface{}) error {
+				_, err := yearFractions(p.Dates)
+				return err
+			}),
+		),
+		validation.Field(&p.Scale, validation.Min(0), validation.Max(maxMoneyScale)),
+		validation.Field(&p.Rounding, validation.In(roundingModes...)),
+	)
+}
+
+// yearFractions returns the time of each date after the first in years of
+// 365 days, as the XIRR of spreadsheets counts it.
+func yearFractions(dates []string) ([]float64, error) {
+	times := make([]float64, len(dates))
+	var first time.Time
+	for i, text := range dates {
+		d, err := time.Parse(time.DateOnly, text)
+		if err != nil {
+			return nil, nil // reported by the per-date rule
+		}
+		if i == 0 {
+			first = d
+		}
+		if d.Before(first) {
+			return nil, fmt.Errorf("%s is before the first date, %s", text, dates[0])
+		}
+		times[i] = d.Sub(first).Hours() / 24 / 365
+	}
+	return times, nil
+}
+
+// AmortizationRow is one payment of an amortization schedule.
+type AmortizationRow struct {
+	Period    int    `json:"period" jsonschema:"the payment number, from 1"`
+	Payment   string `json:"payment" jsonschema:"the payment"`
+	Interest  string `json:"interest" jsonschema:"the part of the payment that is interest"`
+	Principal string `json:"principal" jsonschema:"the part of the payment that repays principal"`
+	Balance   string `json:"balance" jsonschema:"the balance owing after the payment"`
+}
+
+// FinanceResult defines the result for the finance tool.
+type FinanceResult struct {
+	Result    string            `json:"result" jsonschema:"the amount, as a decimal string rounded to scale; irr and xirr: the rate as a fraction"`
+	Value     float64           `json:"value" jsonschema:"result as a number"`
+	Interest  string            `json:"interest,omitempty" jsonschema:"compound-interest: the interest earned; payment and amortization: the total interest paid"`
+	TotalPaid string            `json:"total_paid,omitempty" jsonschema:"payment and amortization: the sum of the payments, the last of which takes up the rounding of the others"`
+	Rates     []string          `json:"rates,omitempty" jsonschema:"irr and xirr: every rate of return found, when the cash flows have more than one"`
+	Schedule  []AmortizationRow `json:"schedule,omitempty" jsonschema:"amortization: one row per payment; the text content repeats it as CSV"`
+	Method    string            `json:"method,omitempty" jsonschema:"how the result was found"`
+	Note      string            `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handleFinance(ctx context.Context, req *mcp.CallToolRequest, param FinanceParams) (*mcp.CallToolResult, FinanceResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			FinanceResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	result, text, err := runFinance(param)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			FinanceResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	content := []mcp.Content{&mcp.TextContent{Text: text}}
+	if result.Schedule != nil {
+		content = append(content, &mcp.TextContent{Text: amortizationCSV(result.Schedule)})
+	}
+	return &mcp.CallToolResult{Content: content}, result, nil
+}
+
+// money rounds amounts to a number of fractional digits.
+type money struct {
+	scale int
+	mode  string
+}
+
+// round rounds r to the scale.
+func (m money) round(r *big.Rat) *big.Rat {
+	return new(big.Rat).SetFrac(roundRat(r, m.scale, m.mode), pow10(m.scale))
+}
+
+// text writes r rounded to the scale.
+func (m money) text(r *big.Rat) string {
+	return formatScaled(roundRat(r, m.scale, m.mode), m.scale)
+}
+
+// exact converts the high-precision f to a rational, refusing amounts too
+// large to be meaningful.
+func (m money) exact(f *big.Float) (*big.Rat, error) {
+	if f.IsInf() || f.MantExp(nil) > maxAmountExponent*10/3 {
+		return nil, fmt.Errorf("the result exceeds 10^%d", maxAmountExponent)
+	}
+	r, _ := f.Rat(nil)
+	return r, nil
+}
+
+// runFinance performs the operation and returns the result with its text
+// form. The parameters must already have passed Validate.
+func runFinance(param FinanceParams) (FinanceResult, string, error) {
+	m := money{scale: 2, mode: param.Rounding}
+	if param.Scale != nil {
+		m.scale = *param.Scale
+	}
+	if m.mode == "" {
+		m.mode = "half-even"
+	}
+	pv, _ := decimalValue(param.PresentValue, param.Locale)
+	fv, _ := decimalValue(param.FutureValue, param.Locale)
+	pmt, _ := decimalValue(param.Payment, param.Locale)
+	rate, _ := decimalValue(param.Rate, param.Locale)
+	perYear := param.PeriodsPerYear
+	if perYear == 0 {
+		perYear = 12
+	}
+	// i is the interest rate per period.
+	i := new(big.Rat).Quo(rate, big.NewRat(int64(perYear), 1))
+	begin := param.Timing == "begin"
+
+	var result FinanceResult
+	var text string
+	switch param.Operation {
+	case "compound-interest":
+		years, _ := decimalValue(param.Years, param.Locale)
+		if param.PeriodsPerYear == 0 {
+			perYear = 1
+		}
+		factor, method := compoundFactor(rate, years, perYear, param.Continuous)
+		amount, err := m.exact(factor.Mul(factor, bigFloat(pv)))
+		if err != nil {
+			return FinanceResult{}, "", err
+		}
+		result.Result, result.Method = m.text(amount), method
+		result.Interest = m.text(new(big.Rat).Sub(amount, pv))
+		unit := "years"
+		if years.Cmp(big.NewRat(1, 1)) == 0 {
+			unit = "year"
+		}
+		text = fmt.Sprintf("%s at %s%% %s for %s %s grows to %s (interest %s)",
+			ratDecimalString(pv, maxDecimalScale), ratDecimalString(new(big.Rat).Mul(rate, big.NewRat(100, 1)), maxDecimalScale),
+			method, ratDecimalString(years, maxDecimalScale), unit, result.Result, result.Interest)
+
+	case "future-value", "present-value":
+		n := param.Periods
+		g := powFloat(new(big.Float).SetPrec(financePrecision).SetRat(new(big.Rat).Add(big.NewRat(1, 1), i)), n)
+		annuity := annuityFactor(i, g, n, begin)
+		var value *big.Float
+		if param.Operation == "future-value" {
+			// PV·g + PMT·((g - 1)/i)·(1 + i if payments begin periods)
+			value = new(big.Float).Mul(bigFloat(pv), g)
+			value.Add(value, annuity.Mul(annuity, bigFloat(pmt)))
+		} else {
+			// FV/g + PMT·((1 - 1/g)/i)·(1 + i if payments begin periods)
+			value = new(big.Float).Quo(bigFloat(fv), g)
+			annuity.Quo(annuity, g)
+			value.Add(value, annuity.Mul(annuity, bigFloat(pmt)))
+		}
+		amount, err := m.exact(value)
+		if err != nil {
+			return FinanceResult{}, "", err
+		}
+		result.Result = m.text(amount)
+		text = fmt.Sprintf("%s over %d period%s (%d a year) at %s%%: %s", capitalize(strings.ReplaceAll(param.Operation, "-", " ")),
+			n, plural(n), perYear, ratDecimalString(new(big.Rat).Mul(rate, big.NewRat(100, 1)), maxDecimalScale), result.Result)
+
+	case "payment", "amortization":
+		n := param.Periods
+		payment, deposit, err := loanPayment(pv, fv, i, n, begin)
+		if err != nil {
+			return FinanceResult{}, "", err
+		}
+		exact, err := m.exact(payment)
+		if err != nil {
+			return FinanceResult{}, "", err
+		}
+		rounded := m.round(exact)
+		if deposit && param.Operation == "amortization" {
+			return FinanceResult{}, "", errors.New("future_value is more than present_value grows to, so there is no loan to amortize")
+		}
+		if deposit {
+			result.Note = "future_value is more than present_value grows to, so the payments are deposits that build the balance up to it"
+		}
+		// The totals come from the schedule, whose last payment takes up
+		// what rounding the payment left over. Deposits run through it as
+		// negative payments.
+		signed := new(big.Rat).Set(rounded)
+		if deposit {
+			signed.Neg(signed)
+		}
+		schedule := amortize(pv, fv, i, signed, n, begin, m)
+		total, interest := new(big.Rat), new(big.Rat)
+		for _, row := range schedule {
+			p, _ := new(big.Rat).SetString(row.Payment)
+			in, _ := new(big.Rat).SetString(row.Interest)
+			total.Add(total, p)
+			interest.Add(interest, in)
+		}
+		last, _ := new(big.Rat).SetString(schedule[len(schedule)-1].Payment)
+		if deposit {
+			total.Neg(total)
+			last.Neg(last)
+		}
+		result.Result, result.TotalPaid, result.Interest = m.text(rounded), m.text(total), m.text(interest)
+		if param.Operation == "payment" {
+			text = fmt.Sprintf("Payment: %s per period for %d period%s", result.Result, n, plural(n))
+			if last.Cmp(rounded) != 0 {
+				text += fmt.Sprintf(", the last one %s", m.text(last))
+			}
+			text += fmt.Sprintf(" (total paid %s, interest %s)", result.TotalPaid, result.Interest)
+			break
+		}
+		result.Schedule = schedule
+		text = fmt.Sprintf("%d payment%s of %s", n, plural(n), result.Result)
+		if last.Cmp(rounded) != 0 {
+			text = fmt.Sprintf("%d payment%s of %s and a last payment of %s", n-1, plural(n-1), result.Result, m.text(last))
+		}
+		text += fmt.Sprintf(" (total paid %s, interest %s); the schedule follows as CSV", result.TotalPaid, result.Interest)
+
+	case "npv":
+		npv := new(big.Float).SetPrec(financePrecision)
+		discount := new(big.Float).SetPrec(financePrecision).SetRat(new(big.Rat).Add(big.NewRat(1, 1), rate))
+		factor := new(big.Float).SetPrec(financePrecision).SetInt64(1)
+		for _, v := range param.CashFlows {
+			cf, _ := decimalValue(v, param.Locale)
+			npv.Add(npv, new(big.Float).Quo(bigFloat(cf), factor))
+			factor.Mul(factor, discount)
+		}
+		amount, err := m.exact(npv)
+		if err != nil {
+			return FinanceResult{}, "", err
+		}
+		result.Result = m.text(amount)
+		text = fmt.Sprintf("NPV at %s%% per period: %s", ratDecimalString(new(big.Rat).Mul(rate, big.NewRat(100, 1)), maxDecimalScale), result.Result)
+
+	case "irr", "xirr":
+		flows := make([]float64, len(param.CashFlows))
+		times := make([]float64, len(param.CashFlows))
+		for k, v := range param.CashFlows {
+			cf, _ := decimalValue(v, param.Locale)
+			flows[k], _ = cf.Float64()
+			times[k] = float64(k)
+		}
+		per := "period"
+		if param.Operation == "xirr" {
+			times, _ = yearFractions(param.Dates)
+			per = "year"
+		}
+		rates, err := ratesOfReturn(flows, times)
+		if err != nil {
+			return FinanceResult{}, "", err
+		}
+		best := rates[0]
+		for _, r := range rates {
+			if math.Abs(r) < math.Abs(best) {
+				best = r
+			}
+		}
+		result.Result = strconv.FormatFloat(best, 'g', 12, 64)
+		result.Method = "Brent's method on each sign change of the NPV between rates of -99.9% and 10000%"
+		if len(rates) > 1 {
+			for _, r := range rates {
+				result.Rates = append(result.Rates, strconv.FormatFloat(r, 'g', 12, 64))
+			}
+			result.Note = fmt.Sprintf("the cash flows change sign more than once and have %d rates of return; result is the one nearest 0", len(rates))
+		}
+		text = fmt.Sprintf("%s: %s%% per %s", strings.ToUpper(param.Operation), strconv.FormatFloat(best*100, 'g', 10, 64), per)
+	}
+
+	result.Value, _ = strconv.ParseFloat(result.Result, 64)
+	if result.Note != "" {
+		text += "\nNote: " + result.Note
+	}
+	return result, text, nil
+}
+
+// bigFloat converts r to a float at financePrecision.
+func bigFloat(r *big.Rat) *big.Float {
+	return new(big.Float).SetPrec(financePrecision).SetRat(r)
+}
+
+// powFloat returns x^n for n ≥ 0 by repeated squaring.
+func powFloat(x *big.Float, n int) *big.Float {
+	result := new(big.Float).SetPrec(financePrecision).SetInt64(1)
+	square := new(big.Float).SetPrec(financePrecision).Set(x)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result.Mul(result, square)
+		}
+		square.Mul(square, square)
+	}
+	return result
+}
+
+// expFloat returns e^x by its Taylor series, after halving x until it is
+// small and squaring the result back.
+func expFloat(x *big.Float) *big.Float {
+	x = new(big.Float).SetPrec(financePrecision).Set(x)
+	halvings := 0
+	for x.MantExp(nil) > -8 {
+		x.SetMantExp(x, -1)
+		halvings++
+	}
+	sum := new(big.Float).SetPrec(financePrecision).SetInt64(1)
+	term := new(big.Float).SetPrec(financePrecision).SetInt64(1)
+	for k := int64(1); ; k++ {
+		term.Mul(term, x).Quo(term, new(big.Float).SetInt64(k))
+		if term.Sign() == 0 || term.MantExp(nil) < -financePrecision {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	for range halvings {
+		sum.Mul(sum, sum)
+	}
+	return sum
+}
+
+// compoundFactor returns what one unit grows to at the annual rate over the
+// years, compounded perYear times a year or continuously, with a
+// description of the compounding.
+func compoundFactor(rate, years *big.Rat, perYear int, continuous bool) (*big.Float, string) {
+	if continuous {
+		return expFloat(bigFloat(new(big.Rat).Mul(rate, years))), "compounded continuously"
+	}
+	i := new(big.Rat).Quo(rate, big.NewRat(int64(perYear), 1))
+	periods := new(big.Rat).Mul(years, big.NewRat(int64(perYear), 1))
+	whole := new(big.Int).Quo(periods.Num(), periods.Denom())
+	factor := powFloat(bigFloat(new(big.Rat).Add(big.NewRat(1, 1), i)), int(whole.Int64()))
+	if fraction := new(big.Rat).Sub(periods, new(big.Rat).SetInt(whole)); fraction.Sign() != 0 {
+		// (1 + i)^f = e^(f·ln(1 + i)); the logarithm in float64 only
+		// perturbs a factor close to 1.
+		f, _ := fraction.Float64()
+		fi, _ := i.Float64()
+		factor.Mul(factor, expFloat(big.NewFloat(f*math.Log1p(fi))))
+	}
+	names := map[int]string{1: "annually", 2: "semiannually", 4: "quarterly", 12: "monthly", 52: "weekly", 365: "daily"}
+	name, ok := names[perYear]
+	if !ok {
+		name = fmt.Sprintf("%d times a year", perYear)
+	}
+	return factor, "compounded " + name
+}
+
+// annuityFactor returns (g - 1)/i, the future value of n payments of 1 at
+// the rate i per period, where g = (1 + i)^n; n when i is 0. Payments at
+// the beginning of periods earn one more period of interest.
+func annuityFactor(i *big.Rat, g *big.Float, n int, begin bool) *big.Float {
+	if i.Sign() == 0 {
+		return new(big.Float).SetPrec(financePrecision).SetInt64(int64(n))
+	}
+	factor := new(big.Float).SetPrec(financePrecision).Sub(g, big.NewFloat(1))
+	factor.Quo(factor, bigFloat(i))
+	if begin {
+		factor.Mul(factor, bigFloat(new(big.Rat).Add(big.NewRat(1, 1), i)))
+	}
+	return factor
+}
+
+// loanPayment returns the payment per period that repays pv over n periods
+// at the rate i, leaving fv owing. When fv is more than pv grows to, the
+// payments are deposits instead, and deposit is true.
+func loanPayment(pv, fv, i *big.Rat, n int, begin bool) (payment *big.Float, deposit bool, err error) {
+	g := powFloat(bigFloat(new(big.Rat).Add(big.NewRat(1, 1), i)), n)
+	// PMT = (PV·g - FV) / annuityFactor
+	owed := new(big.Float).Mul(bigFloat(pv), g)
+	owed.Sub(owed, bigFloat(fv))
+	factor := annuityFactor(i, g, n, begin)
+	if factor.Sign() <= 0 {
+		return nil, false, errors.New("the rate leaves nothing of each period's value, so no payment repays the loan")
+	}
+	payment = owed.Quo(owed, factor)
+	if payment.Sign() < 0 {
+		return payment.Neg(payment), true, nil
+	}
+	return payment, false, nil
+}
+
+// amortize builds the schedule of n payments of payment on the loan pv at
+// the rate i. Interest is rounded each period, and the last payment is
+// adjusted so that exactly fv is left owing.
+func amortize(pv, fv, i, payment *big.Rat, n int, begin bool, m money) []AmortizationRow {
+	rows := make([]AmortizationRow, 0, n)
+	balance := new(big.Rat).Set(pv)
+	for period := 1; period <= n; period++ {
+		interest := new(big.Rat)
+		if !begin || period > 1 {
+			interest = m.round(new(big.Rat).Mul(balance, i))
+		}
+		paid := new(big.Rat).Set(payment)
+		if period == n {
+			paid.Add(balance, interest).Sub(paid, fv)
+		}
+		principal := new(big.Rat).Sub(paid, interest)
+		balance.Sub(balance, principal)
+		rows = append(rows, AmortizationRow{
+			Period:    period,
+			Payment:   m.text(paid),
+			Interest:  m.text(interest),
+			Principal: m.text(principal),
+			Balance:   m.text(balance),
+		})
+	}
+	return rows
+}
+
+// amortizationCSV writes the schedule as CSV with a header row.
+func amortizationCSV(rows []AmortizationRow) string {
+	var b strings.Builder
+	b.WriteString("period,payment,interest,principal,balance\n")
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%d,%s,%s,%s,%s\n", row.Period, row.Payment, row.Interest, row.Principal, row.Balance)
+	}
+	return b.String()
+}
+
+// ratesOfReturn finds the rates r at which the cash flows, at the given
+// times in periods, have a net present value of zero. It scans the NPV on
+// a grid of rates from -99.9% to 10000% and refines every sign change by
+// Brent's method.
+func ratesOfReturn(flows, times []float64) ([]float64, error) {
+	npv := func(r float64) float64 {
+		sum := 0.0
+		for k, cf := range flows {
+			sum += cf * math.Exp(-times[k]*math.Log1p(r))
+		}
+		return sum
+	}
+	const gridPoints = 2000
+	lo, hi := math.Log(0.001), math.Log(101)
+	var rates []float64
+	a := math.Expm1(lo)
+	fa := npv(a)
+	for k := 1; k <= gridPoints; k++ {
+		b := math.Expm1(lo + (hi-lo)*float64(k)/gridPoints)
+		fb := npv(b)
+		switch {
+		case fb == 0:
+			rates = append(rates, b)
+		case fa != 0 && (fa > 0) != (fb > 0) && !math.IsInf(fa, 0) && !math.IsInf(fb, 0):
+			r, _, err := brent(npv, a, b, fa, fb, 1e-15, 200)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, r)
+		}
+		a, fa = b, fb
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("the NPV does not reach zero at any rate between -99.9% and 10000%, so there is no rate of return")
+	}
+	return rates, nil
+}
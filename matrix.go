@@ -0,0 +1,580 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxMatrixDimension bounds the number of rows and columns accepted by the
+// matrix tool.
+const maxMatrixDimension = 200
+
+// MatrixParams defines the parameters for the matrix tool.
+type MatrixParams struct {
+	Operation string          `json:"operation" jsonschema:"operation: add, subtract, multiply, transpose, determinant, inverse, rank, trace, solve, lu or qr"`
+	A         [][]interface{} `json:"a" jsonschema:"first matrix as an array of rows, each entry a number or a string such as \"1/3\""`
+	B         [][]interface{} `json:"b,omitempty" jsonschema:"second matrix for add, subtract and multiply"`
+	Vector    []interface{}   `json:"vector,omitempty" jsonschema:"right-hand side b for solve (Ax=b), or a vector to multiply A by"`
+}
+
+func (p MatrixParams) Validate() error {
+	needsSquare := p.Operation == "determinant" || p.Operation == "inverse" || p.Operation == "trace" ||
+		p.Operation == "solve" || p.Operation == "lu"
+	a, b, vector, err := p.floats()
+	if err != nil {
+		return err
+	}
+	rows, cols := len(a), 0
+	if rows > 0 {
+		cols = len(a[0])
+	}
+
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Operation,
+			validation.Required,
+			validation.In("add", "subtract", "multiply", "transpose", "determinant", "inverse", "rank", "trace", "solve", "lu", "qr"),
+		),
+		validation.Field(&p.A,
+			validation.Required,
+			validation.By(func(value interface{}) error {
+				if err := checkMatrixShape(a); err != nil {
+					return err
+				}
+				if needsSquare && rows != cols {
+					return fmt.Errorf("%s requires a square matrix, got %dx%d", p.Operation, rows, cols)
+				}
+				if p.Operation == "inverse" || p.Operation == "solve" {
+					if _, _, _, singular := luDecompose(a); singular {
+						return errors.New("matrix is singular")
+					}
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.B,
+			validation.When(p.Operation == "add" || p.Operation == "subtract" || (p.Operation == "multiply" && p.Vector == nil),
+				validation.Required),
+			validation.When(p.Operation != "add" && p.Operation != "subtract" && p.Operation != "multiply",
+				validation.Nil.Error("is only supported for add, subtract and multiply")),
+			validation.By(func(value interface{}) error {
+				if b == nil {
+					return nil
+				}
+				if err := checkMatrixShape(b); err != nil {
+					return err
+				}
+				bRows, bCols := len(b), len(b[0])
+				switch p.Operation {
+				case "add", "subtract":
+					if bRows != rows || bCols != cols {
+						return fmt.Errorf("dimension mismatch: cannot %s a %dx%d and a %dx%d matrix", p.Operation, rows, cols, bRows, bCols)
+					}
+				case "multiply":
+					if bRows != cols {
+						return fmt.Errorf("dimension mismatch: cannot multiply a %dx%d by a %dx%d matrix", rows, cols, bRows, bCols)
+					}
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Vector,
+			validation.When(p.Operation == "solve", validation.Required),
+			validation.When(p.Operation != "solve" && p.Operation != "multiply",
+				validation.Nil.Error("is only supported for solve and multiply")),
+			validation.When(p.Operation == "multiply" && p.B != nil,
+				validation.Nil.Error("cannot be combined with b")),
+			validation.By(func(value interface{}) error {
+				if vector == nil {
+					return nil
+				}
+				want := rows
+				if p.Operation == "multiply" {
+					want = cols
+				}
+				if len(vector) != want {
+					return fmt.Errorf("dimension mismatch: expected %d elements, got %d", want, len(vector))
+				}
+				return nil
+			}),
+		),
+	)
+}
+
+// floats returns a, b and vector as float64s, or an error naming the first
+// entry that is not a number.
+func (p MatrixParams) floats() (a, b [][]float64, vector []float64, err error) {
+	a, errA := floatMatrix(p.A)
+	b, errB := floatMatrix(p.B)
+	vector, errVector := floatVector(p.Vector)
+	err = validation.Errors{"a": errA, "b": errB, "vector": errVector}.Filter()
+	return a, b, vector, err
+}
+
+// floatMatrix reads the entries of m with floatValue. A nil m stays nil.
+func floatMatrix(m [][]interface{}) ([][]float64, error) {
+	if m == nil {
+		return nil, nil
+	}
+	rows := make([][]float64, len(m))
+	for i, row := range m {
+		values, err := floatVector(row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i, err)
+		}
+		rows[i] = values
+	}
+	return rows, nil
+}
+
+// floatVector reads the entries of v with floatValue. A nil v stays nil.
+func floatVector(v []interface{}) ([]float64, error) {
+	if v == nil {
+		return nil, nil
+	}
+	values := make([]float64, len(v))
+	for i, x := range v {
+		f, err := floatValue(x)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", i, err)
+		}
+		values[i] = f
+	}
+	return values, nil
+}
+
+// checkMatrixShape verifies that m is a non-empty rectangular matrix within
+// the size limit.
+func checkMatrixShape(m [][]float64) error {
+	if len(m) == 0 || len(m[0]) == 0 {
+		return errors.New("matrix must have at least one row and one column")
+	}
+	if len(m) > maxMatrixDimension || len(m[0]) > maxMatrixDimension {
+		return fmt.Errorf("matrix must be at most %dx%d", maxMatrixDimension, maxMatrixDimension)
+	}
+	for i, row := range m {
+		if len(row) != len(m[0]) {
+			return fmt.Errorf("row %d has %d columns, expected %d", i, len(row), len(m[0]))
+		}
+	}
+	return nil
+}
+
+// MatrixResult defines the result for the matrix tool. Only the fields that
+// apply to the requested operation are set.
+type MatrixResult struct {
+	Matrix [][]float64 `json:"matrix,omitempty" jsonschema:"resulting matrix for add, subtract, multiply, transpose and inverse"`
+	Vector []float64   `json:"vector,omitempty" jsonschema:"solution x of solve, or the product of A and a vector"`
+	Scalar *float64    `json:"scalar,omitempty" jsonschema:"determinant, trace or rank"`
+	L      [][]float64 `json:"l,omitempty" jsonschema:"unit lower-triangular factor of the LU decomposition"`
+	U      [][]float64 `json:"u,omitempty" jsonschema:"upper-triangular factor of the LU decomposition"`
+	P      [][]float64 `json:"p,omitempty" jsonschema:"permutation matrix of the LU decomposition, with PA = LU"`
+	Q      [][]float64 `json:"q,omitempty" jsonschema:"orthogonal factor of the QR decomposition"`
+	R      [][]float64 `json:"r,omitempty" jsonschema:"upper-triangular factor of the QR decomposition, with A = QR"`
+}
+
+func handleMatrix(ctx context.Context, req *mcp.CallToolRequest, param MatrixParams) (*mcp.CallToolResult, MatrixResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			MatrixResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	var result MatrixResult
+	var text string
+	a, b, vector, _ := param.floats()
+	switch param.Operation {
+	case "add":
+		result.Matrix = matrixCombine(a, b, 1)
+		text = formatMatrix(result.Matrix)
+	case "subtract":
+		result.Matrix = matrixCombine(a, b, -1)
+		text = formatMatrix(result.Matrix)
+	case "multiply":
+		if vector != nil {
+			result.Vector = matrixVectorMultiply(a, vector)
+			text = formatVector(result.Vector)
+		} else {
+			result.Matrix = matrixMultiply(a, b)
+			text = formatMatrix(result.Matrix)
+		}
+	case "transpose":
+		result.Matrix = matrixTranspose(a)
+		text = formatMatrix(result.Matrix)
+	case "determinant":
+		det := determinant(a)
+		result.Scalar = &det
+		text = fmt.Sprintf("Determinant: %g", det)
+	case "inverse":
+		result.Matrix = matrixInverse(a)
+		text = formatMatrix(result.Matrix)
+	case "rank":
+		rank := float64(matrixRank(a))
+		result.Scalar = &rank
+		text = fmt.Sprintf("Rank: %g", rank)
+	case "trace":
+		var trace float64
+		for i := range a {
+			trace += a[i][i]
+		}
+		result.Scalar = &trace
+		text = fmt.Sprintf("Trace: %g", trace)
+	case "solve":
+		lu, perm, _, _ := luDecompose(a)
+		result.Vector = luSolve(lu, perm, vector)
+		text = "x = " + formatVector(result.Vector)
+	case "lu":
+		lu, perm, _, _ := luDecompose(a)
+		result.L, result.U, result.P = luFactors(lu, perm)
+		text = "L =\n" + formatMatrix(result.L) + "\nU =\n" + formatMatrix(result.U) + "\nP =\n" + formatMatrix(result.P)
+	case "qr":
+		result.Q, result.R = qrDecompose(a)
+		text = "Q =\n" + formatMatrix(result.Q) + "\nR =\n" + formatMatrix(result.R)
+	}
+
+	if !allFinite(result) {
+		return errorResult("Calculation error: result is out of the representable range"),
+			MatrixResult{}, errors.New("calculation error: result is out of the representable range")
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+func newMatrix(rows, cols int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
+
+func identityMatrix(n int) [][]float64 {
+	m := newMatrix(n, n)
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+func matrixCombine(a, b [][]float64, sign float64) [][]float64 {
+	m := newMatrix(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			m[i][j] = a[i][j] + sign*b[i][j]
+		}
+	}
+	return m
+}
+
+func matrixMultiply(a, b [][]float64) [][]float64 {
+	m := newMatrix(len(a), len(b[0]))
+	for i := range a {
+		for k := range b {
+			for j := range b[k] {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+func matrixVectorMultiply(a [][]float64, v []float64) []float64 {
+	out := make([]float64, len(a))
+	for i := range a {
+		for j := range v {
+			out[i] += a[i][j] * v[j]
+		}
+	}
+	return out
+}
+
+func matrixTranspose(a [][]float64) [][]float64 {
+	m := newMatrix(len(a[0]), len(a))
+	for i := range a {
+		for j := range a[i] {
+			m[j][i] = a[i][j]
+		}
+	}
+	return m
+}
+
+// pivotTolerance returns the magnitude below which a pivot of a is treated
+// as zero.
+func pivotTolerance(a [][]float64) float64 {
+	var scale float64
+	for _, row := range a {
+		for _, v := range row {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	return scale * float64(max(len(a), len(a[0]))) * 1e-14
+}
+
+// luDecompose factors the square matrix a as PA = LU using Gaussian
+// elimination with partial pivoting. The factors are packed into a single
+// matrix: U on and above the diagonal and the multipliers of L below it.
+// perm[i] is the row of a that ended up in row i, and sign is the sign of
+// the permutation.
+func luDecompose(a [][]float64) (lu [][]float64, perm []int, sign float64, singular bool) {
+	n := len(a)
+	lu = newMatrix(n, n)
+	for i := range a {
+		copy(lu[i], a[i])
+	}
+	perm = make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	sign = 1
+	tol := pivotTolerance(a)
+
+	for k := 0; k < n; k++ {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(lu[i][k]) > math.Abs(lu[pivot][k]) {
+				pivot = i
+			}
+		}
+		if math.Abs(lu[pivot][k]) <= tol {
+			singular = true
+			continue
+		}
+		if pivot != k {
+			lu[pivot], lu[k] = lu[k], lu[pivot]
+			perm[pivot], perm[k] = perm[k], perm[pivot]
+			sign = -sign
+		}
+		for i := k + 1; i < n; i++ {
+			lu[i][k] /= lu[k][k]
+			for j := k + 1; j < n; j++ {
+				lu[i][j] -= lu[i][k] * lu[k][j]
+			}
+		}
+	}
+	return lu, perm, sign, singular
+}
+
+// luSolve solves Ax = b given the packed LU factors of a non-singular A.
+func luSolve(lu [][]float64, perm []int, b []float64) []float64 {
+	n := len(lu)
+	x := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x[i] = b[perm[i]]
+		for j := 0; j < i; j++ {
+			x[i] -= lu[i][j] * x[j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			x[i] -= lu[i][j] * x[j]
+		}
+		x[i] /= lu[i][i]
+	}
+	return x
+}
+
+// luFactors unpacks the result of luDecompose into L, U and P.
+func luFactors(lu [][]float64, perm []int) (l, u, p [][]float64) {
+	n := len(lu)
+	l, u, p = identityMatrix(n), newMatrix(n, n), newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if j < i {
+				l[i][j] = lu[i][j]
+			} else {
+				u[i][j] = lu[i][j]
+			}
+		}
+		p[i][perm[i]] = 1
+	}
+	return l, u, p
+}
+
+func determinant(a [][]float64) float64 {
+	lu, _, sign, singular := luDecompose(a)
+	if singular {
+		return 0
+	}
+	det := sign
+	for i := range lu {
+		det *= lu[i][i]
+	}
+	return det
+}
+
+func matrixInverse(a [][]float64) [][]float64 {
+	n := len(a)
+	lu, perm, _, _ := luDecompose(a)
+	inv := newMatrix(n, n)
+	e := make([]float64, n)
+	for j := 0; j < n; j++ {
+		clear(e)
+		e[j] = 1
+		col := luSolve(lu, perm, e)
+		for i := 0; i < n; i++ {
+			inv[i][j] = col[i]
+		}
+	}
+	return inv
+}
+
+// matrixRank returns the rank of a, computed by reducing it to row echelon
+// form with partial pivoting.
+func matrixRank(a [][]float64) int {
+	m := newMatrix(len(a), len(a[0]))
+	for i := range a {
+		copy(m[i], a[i])
+	}
+	tol := pivotTolerance(a)
+	rank := 0
+	for col := 0; col < len(m[0]) && rank < len(m); col++ {
+		pivot := rank
+		for i := rank + 1; i < len(m); i++ {
+			if math.Abs(m[i][col]) > math.Abs(m[pivot][col]) {
+				pivot = i
+			}
+		}
+		if math.Abs(m[pivot][col]) <= tol {
+			continue
+		}
+		m[pivot], m[rank] = m[rank], m[pivot]
+		for i := rank + 1; i < len(m); i++ {
+			factor := m[i][col] / m[rank][col]
+			for j := col; j < len(m[i]); j++ {
+				m[i][j] -= factor * m[rank][j]
+			}
+		}
+		rank++
+	}
+	return rank
+}
+
+// qrDecompose factors the m×n matrix a as A = QR with Householder
+// reflections, returning the m×m orthogonal Q and the m×n upper-triangular R.
+func qrDecompose(a [][]float64) (q, r [][]float64) {
+	rows, cols := len(a), len(a[0])
+	r = newMatrix(rows, cols)
+	for i := range a {
+		copy(r[i], a[i])
+	}
+	q = identityMatrix(rows)
+
+	for k := 0; k < min(rows-1, cols); k++ {
+		var norm float64
+		for i := k; i < rows; i++ {
+			norm = math.Hypot(norm, r[i][k])
+		}
+		if norm == 0 {
+			continue
+		}
+		alpha := -math.Copysign(norm, r[k][k])
+		v := make([]float64, rows)
+		v[k] = r[k][k] - alpha
+		for i := k + 1; i < rows; i++ {
+			v[i] = r[i][k]
+		}
+		var vv float64
+		for i := k; i < rows; i++ {
+			vv += v[i] * v[i]
+		}
+		if vv == 0 {
+			continue
+		}
+		// Apply H = I - 2vvᵀ/(vᵀv) to R from the left and to Q from the right.
+		for j := 0; j < cols; j++ {
+			var dot float64
+			for i := k; i < rows; i++ {
+				dot += v[i] * r[i][j]
+			}
+			f := 2 * dot / vv
+			for i := k; i < rows; i++ {
+				r[i][j] -= f * v[i]
+			}
+		}
+		for i := 0; i < rows; i++ {
+			var dot float64
+			for j := k; j < rows; j++ {
+				dot += q[i][j] * v[j]
+			}
+			f := 2 * dot / vv
+			for j := k; j < rows; j++ {
+				q[i][j] -= f * v[j]
+			}
+		}
+		for i := k + 1; i < rows; i++ {
+			r[i][k] = 0
+		}
+	}
+	return q, r
+}
+
+func allFinite(result MatrixResult) bool {
+	finite := func(v float64) bool { return !math.IsNaN(v) && !math.IsInf(v, 0) }
+	for _, m := range [][][]float64{result.Matrix, result.L, result.U, result.P, result.Q, result.R} {
+		for _, row := range m {
+			for _, v := range row {
+				if !finite(v) {
+					return false
+				}
+			}
+		}
+	}
+	for _, v := range result.Vector {
+		if !finite(v) {
+			return false
+		}
+	}
+	return result.Scalar == nil || finite(*result.Scalar)
+}
+
+// formatMatrix renders m as a text table with right-aligned columns.
+func formatMatrix(m [][]float64) string {
+	cells := make([][]string, len(m))
+	widths := make([]int, len(m[0]))
+	for i, row := range m {
+		cells[i] = make([]string, len(row))
+		for j, v := range row {
+			cells[i][j] = formatCell(v)
+			widths[j] = max(widths[j], len(cells[i][j]))
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range cells {
+		b.WriteString("[ ")
+		for j, cell := range row {
+			if j > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(strings.Repeat(" ", widths[j]-len(cell)))
+			b.WriteString(cell)
+		}
+		b.WriteString(" ]")
+		if i < len(cells)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func formatVector(v []float64) string {
+	cells := make([]string, len(v))
+	for i, x := range v {
+		cells[i] = formatCell(x)
+	}
+	return "[" + strings.Join(cells, ", ") + "]"
+}
+
+func formatCell(v float64) string {
+	if v == 0 {
+		v = 0 // print -0 as 0
+	}
+	return strconv.FormatFloat(v, 'g', 10, 64)
+}
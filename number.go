@@ -0,0 +1,420 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// numberFormat holds the separators of a locale: the decimal separator and
+// the runes that may separate groups of three digits.
+type numberFormat struct {
+	decimal rune
+	groups  string
+}
+
+// numberLocales maps locale hints to their number formats. A hint is looked
+// up in full and then by its language alone, so "de-AT" reads like "de".
+var numberLocales = map[string]numberFormat{
+	"en":    {'.', ","},
+	"de":    {',', "."},
+	"es":    {',', "."},
+	"it":    {',', "."},
+	"nl":    {',', "."},
+	"pt":    {',', "."},
+	"id":    {',', "."},
+	"tr":    {',', "."},
+	"da":    {',', "."},
+	"fr":    {',', " \u00a0\u202f"},
+	"ru":    {',', " \u00a0\u202f"},
+	"pl":    {',', " \u00a0\u202f"},
+	"cs":    {',', " \u00a0\u202f"},
+	"sv":    {',', " \u00a0\u202f"},
+	"fi":    {',', " \u00a0\u202f"},
+	"nb":    {',', " \u00a0\u202f"},
+	"uk":    {',', " \u00a0\u202f"},
+	"ch":    {'.', "'’"},
+	"de-ch": {'.', "'’"},
+	"fr-ch": {'.', "'’"},
+	"it-ch": {'.', "'’"},
+}
+
+// vulgarFractions maps the Unicode vulgar fractions to their values.
+var vulgarFractions = map[rune][2]int64{
+	'½': {1, 2}, '⅓': {1, 3}, '⅔': {2, 3}, '¼': {1, 4}, '¾': {3, 4},
+	'⅕': {1, 5}, '⅖': {2, 5}, '⅗': {3, 5}, '⅘': {4, 5}, '⅙': {1, 6},
+	'⅚': {5, 6}, '⅐': {1, 7}, '⅛': {1, 8}, '⅜': {3, 8}, '⅝': {5, 8},
+	'⅞': {7, 8}, '⅑': {1, 9}, '⅒': {1, 10},
+}
+
+// lookupNumberFormat returns the number format of a locale hint; the empty
+// hint is "en".
+func lookupNumberFormat(locale string) (numberFormat, error) {
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if tag == "" {
+		tag = "en"
+	}
+	if format, ok := numberLocales[tag]; ok {
+		return format, nil
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	if format, ok := numberLocales[language]; ok {
+		return format, nil
+	}
+	return numberFormat{}, fmt.Errorf("unknown locale %q; use a language such as \"en\" (1,234.5), \"de\" (1.234,5), \"fr\" (1 234,5) or \"ch\" (1'234.5)", locale)
+}
+
+// parseNumber parses s as an exact number. It accepts decimals with the
+// group and decimal separators of locale and an optional exponent, such as
+// "1,234.5" or "1e-9"; integers in hexadecimal, octal or binary, such as
+// "0x1F"; fractions such as "3/4"; vulgar fractions such as "½" or "2¾"; and
+// any of these followed by "%", which divides by 100. Anything else,
+// including trailing characters, is an error naming the position of the
+// first rune that could not be read.
+func parseNumber(s, locale string) (*big.Rat, error) {
+	format, err := lookupNumberFormat(locale)
+	if err != nil {
+		return nil, err
+	}
+	sc := numberScanner{input: s, runes: []rune(s), format: format}
+	return sc.parse()
+}
+
+// parseInteger parses s with parseNumber and requires a whole number that
+// fits in an int64.
+func parseInteger(s string) (int64, error) {
+	x, err := parseNumber(s, "")
+	if err != nil {
+		return 0, err
+	}
+	if !x.IsInt() {
+		return 0, fmt.Errorf("invalid integer %q: it has a fractional part", s)
+	}
+	if !x.Num().IsInt64() {
+		return 0, fmt.Errorf("invalid integer %q: out of range", s)
+	}
+	return x.Num().Int64(), nil
+}
+
+// numberText validates that a string parses with parseNumber in locale.
+// The empty string is left to the other rules.
+func numberText(locale string) validation.Rule {
+	return validation.By(func(value interface{}) error {
+		s, _ := value.(string)
+		if s == "" {
+			return nil
+		}
+		_, err := parseNumber(s, locale)
+		return err
+	})
+}
+
+// checkLocale validates a locale hint ahead of the numbers read with it, so
+// that a bad hint is reported once rather than for every number.
+func checkLocale(locale string) error {
+	if _, err := lookupNumberFormat(locale); err != nil {
+		return validation.Errors{"locale": err}
+	}
+	return nil
+}
+
+// floatValue reads a number given as a JSON number or as a string in the
+// forms of parseNumber with the default separators, such as "1,234.5",
+// "3/4" or "15%", as the nearest float64.
+func floatValue(value interface{}) (float64, error) {
+	switch value := value.(type) {
+	case float64:
+		return value, nil
+	case string:
+		x, err := parseNumber(value, "")
+		if err != nil {
+			return 0, err
+		}
+		f, _ := x.Float64()
+		if math.IsInf(f, 0) {
+			return 0, fmt.Errorf("invalid number %q: beyond the float64 range", value)
+		}
+		return f, nil
+	}
+	return 0, errors.New("must be a number or a numeric string")
+}
+
+// optionalFloat reads an optional number with floatValue; nil stays nil.
+func optionalFloat(value interface{}) (*float64, error) {
+	if value == nil {
+		return nil, nil
+	}
+	f, err := floatValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// floatNumber validates a number that floatValue reads. nil is left to the
+// other rules.
+var floatNumber = validation.By(func(value interface{}) error {
+	_, err := optionalFloat(value)
+	return err
+})
+
+// checkNumbers validates optional numbers, keyed by their JSON names, ahead
+// of rules that read them with optionalFloat and ignore its error.
+func checkNumbers(numbers map[string]interface{}) error {
+	errs := validation.Errors{}
+	for name, value := range numbers {
+		if _, err := optionalFloat(value); err != nil {
+			errs[name] = err
+		}
+	}
+	return errs.Filter()
+}
+
+// numberScanner reads one number from runes.
+type numberScanner struct {
+	input  string
+	runes  []rune
+	pos    int
+	format numberFormat
+}
+
+func (sc *numberScanner) peek() rune {
+	if sc.pos < len(sc.runes) {
+		return sc.runes[sc.pos]
+	}
+	return 0
+}
+
+// fail reports a problem at the current position, counting runes from 1.
+// A problem with a comma or point that could be the decimal separator of
+// another locale says so.
+func (sc *numberScanner) fail(problem string) error {
+	switch {
+	case sc.peek() == ',' && sc.format.decimal == '.':
+		problem += ` (for a decimal comma, give a locale such as "de")`
+	case sc.peek() == '.' && sc.format.decimal == ',':
+		problem += ` (for a decimal point, give a locale such as "en")`
+	}
+	return fmt.Errorf("invalid number %q at position %d: %s", sc.input, sc.pos+1, problem)
+}
+
+// unexpected reports the rune at the current position, or the end of the
+// input, where expected was wanted.
+func (sc *numberScanner) unexpected(expected string) error {
+	if sc.pos >= len(sc.runes) {
+		return sc.fail("expected " + expected)
+	}
+	return sc.fail(fmt.Sprintf("unexpected %q", string(sc.peek())))
+}
+
+func (sc *numberScanner) skipSpace() {
+	for sc.pos < len(sc.runes) && unicode.IsSpace(sc.peek()) {
+		sc.pos++
+	}
+}
+
+func (sc *numberScanner) parse() (*big.Rat, error) {
+	sc.skipSpace()
+	negative := false
+	switch sc.peek() {
+	case '-', '−':
+		negative = true
+		sc.pos++
+	case '+':
+		sc.pos++
+	}
+
+	x, err := sc.magnitude()
+	if err != nil {
+		return nil, err
+	}
+	if sc.peek() == '/' || sc.peek() == '⁄' {
+		sc.pos++
+		start := sc.pos
+		d, err := sc.magnitude()
+		if err != nil {
+			return nil, err
+		}
+		if d.Sign() == 0 {
+			sc.pos = start
+			return nil, sc.fail("zero denominator")
+		}
+		x.Quo(x, d)
+	}
+
+	sc.skipSpace()
+	if sc.peek() == '%' {
+		sc.pos++
+		x.Quo(x, big.NewRat(100, 1))
+		sc.skipSpace()
+	}
+	if sc.pos < len(sc.runes) {
+		return nil, sc.unexpected("the end")
+	}
+	if negative {
+		x.Neg(x)
+	}
+	return x, nil
+}
+
+// magnitude reads an unsigned number: a prefixed integer, a decimal, a
+// vulgar fraction, or a whole number followed by a vulgar fraction.
+func (sc *numberScanner) magnitude() (*big.Rat, error) {
+	if f, ok := vulgarFractions[sc.peek()]; ok {
+		sc.pos++
+		return big.NewRat(f[0], f[1]), nil
+	}
+	if sc.peek() == '0' && sc.pos+1 < len(sc.runes) {
+		base := map[rune]int{'x': 16, 'X': 16, 'o': 8, 'O': 8, 'b': 2, 'B': 2}[sc.runes[sc.pos+1]]
+		if base != 0 {
+			sc.pos += 2
+			return sc.prefixed(base)
+		}
+	}
+
+	x, whole, err := sc.decimal()
+	if err != nil {
+		return nil, err
+	}
+	if !whole {
+		return x, nil
+	}
+	// A vulgar fraction may follow a whole number, directly or after a
+	// space, as in "2¾" or "2 ¾".
+	next := sc.pos
+	if next < len(sc.runes) && unicode.IsSpace(sc.runes[next]) {
+		next++
+	}
+	if next < len(sc.runes) {
+		if f, ok := vulgarFractions[sc.runes[next]]; ok {
+			sc.pos = next + 1
+			return x.Add(x, big.NewRat(f[0], f[1])), nil
+		}
+	}
+	return x, nil
+}
+
+// prefixed reads the digits of an integer in base after its 0x, 0o or 0b
+// prefix.
+func (sc *numberScanner) prefixed(base int) (*big.Rat, error) {
+	start := sc.pos
+	for sc.pos < len(sc.runes) && digitValue(sc.peek()) < base {
+		sc.pos++
+	}
+	if sc.pos == start {
+		return nil, sc.unexpected(fmt.Sprintf("a base-%d digit", base))
+	}
+	n, _ := new(big.Int).SetString(string(sc.runes[start:sc.pos]), base)
+	return new(big.Rat).SetInt(n), nil
+}
+
+// digitValue returns the value of r as a digit, or 36 if it is not one.
+func digitValue(r rune) int {
+	switch {
+	case '0' <= r && r <= '9':
+		return int(r - '0')
+	case 'a' <= r && r <= 'z':
+		return int(r-'a') + 10
+	case 'A' <= r && r <= 'Z':
+		return int(r-'A') + 10
+	}
+	return 36
+}
+
+// decimal reads a decimal with optional digit grouping, fraction and
+// exponent. It also reports whether it was a plain whole number.
+func (sc *numberScanner) decimal() (*big.Rat, bool, error) {
+	var digits strings.Builder
+	isDigit := func() bool { return '0' <= sc.peek() && sc.peek() <= '9' }
+	readDigits := func() int {
+		n := 0
+		for ; isDigit(); n++ {
+			digits.WriteRune(sc.peek())
+			sc.pos++
+		}
+		return n
+	}
+
+	// The integer part: groups of three digits after the first may be
+	// separated, but then every group must be.
+	first := readDigits()
+	grouped, separator := false, rune(0)
+	for first > 0 && first <= 3 || grouped {
+		r := sc.peek()
+		if !strings.ContainsRune(sc.format.groups, r) || (separator != 0 && r != separator) {
+			break
+		}
+		// A space before a vulgar fraction ends the whole number.
+		if unicode.IsSpace(r) && sc.pos+1 < len(sc.runes) {
+			if _, ok := vulgarFractions[sc.runes[sc.pos+1]]; ok {
+				break
+			}
+		}
+		at := sc.pos
+		sc.pos++
+		if n := readDigits(); n != 3 {
+			if n == 0 && unicode.IsSpace(r) {
+				// A single trailing space, as before "%", is not a group.
+				sc.pos = at
+				break
+			}
+			sc.pos = at
+			return nil, false, sc.fail(fmt.Sprintf("digit group after %q has %d digit%s instead of 3", string(r), n, plural(n)))
+		}
+		grouped, separator = true, r
+	}
+
+	whole := true
+	fraction := 0
+	if sc.peek() == sc.format.decimal {
+		whole = false
+		sc.pos++
+		fraction = readDigits()
+		if first == 0 && fraction == 0 {
+			return nil, false, sc.unexpected("a digit")
+		}
+	} else if first == 0 {
+		return nil, false, sc.unexpected("a number")
+	}
+
+	exponent := 0
+	if r := sc.peek(); r == 'e' || r == 'E' {
+		whole = false
+		sc.pos++
+		sign := 1
+		switch sc.peek() {
+		case '-', '−':
+			sign = -1
+			sc.pos++
+		case '+':
+			sc.pos++
+		}
+		start := sc.pos
+		for isDigit() {
+			exponent = exponent*10 + int(sc.peek()-'0')
+			sc.pos++
+			if exponent > maxDecimalExponent {
+				sc.pos = start
+				return nil, false, sc.fail(fmt.Sprintf("exponent beyond ±%d", maxDecimalExponent))
+			}
+		}
+		if sc.pos == start {
+			return nil, false, sc.unexpected("exponent digits")
+		}
+		exponent *= sign
+	}
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	x := new(big.Rat).SetInt(n)
+	if shift := exponent - fraction; shift >= 0 {
+		x.Mul(x, new(big.Rat).SetInt(pow10(shift)))
+	} else {
+		x.Quo(x, new(big.Rat).SetInt(pow10(-shift)))
+	}
+	return x, whole, nil
+}
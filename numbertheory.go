@@ -0,0 +1,547 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxIntegerDigits bounds the length of the integers of the
+	// number-theory tool.
+	maxIntegerDigits = 1000
+	// maxIntegerList bounds the numbers of gcd and lcm.
+	maxIntegerList = 100
+	// trialDivisionLimit is the bound of the primes tried by trial
+	// division before Miller–Rabin and Pollard's rho take over.
+	trialDivisionLimit = 10_000
+	// maxRhoSteps bounds the iterations of Pollard's rho over a whole
+	// factorization.
+	maxRhoSteps              = 1 << 20
+	defaultMillerRabinRounds = 20
+	maxMillerRabinRounds     = 200
+)
+
+var (
+	numberTheoryOperations = []interface{}{
+		"is-prime", "factorize", "gcd", "lcm", "extended-gcd",
+		"mod-pow", "mod-inverse", "totient", "next-prime", "previous-prime",
+	}
+	// millerRabinBases are the first 13 primes. Miller–Rabin with these
+	// bases is deterministic below millerRabinBound (Sorenson and
+	// Webster, 2015).
+	millerRabinBases = []int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+	millerRabinBound = func() *big.Int {
+		n, _ := new(big.Int).SetString("3317044064679887385961981", 10)
+		return n
+	}()
+	smallPrimes = primesBelow(trialDivisionLimit)
+)
+
+// primesBelow returns the primes below n by the sieve of Eratosthenes.
+func primesBelow(n int) []int64 {
+	composite := make([]bool, n)
+	var primes []int64
+	for i := 2; i < n; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, int64(i))
+		for j := i * i; j < n; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
+
+// NumberTheoryParams defines the parameters for the number-theory tool.
+type NumberTheoryParams struct {
+	Operation string   `json:"operation" jsonschema:"'is-prime', 'factorize', 'totient', 'next-prime' or 'previous-prime' of n; 'gcd', 'lcm' or 'extended-gcd' of numbers; 'mod-pow' (n to the power exponent, modulo modulus) or 'mod-inverse' (of n modulo modulus)"`
+	N         string   `json:"n,omitempty" jsonschema:"the integer, as a string so that it can have any number of digits; 0x, 0o and 0b prefixes are allowed"`
+	Numbers   []string `json:"numbers,omitempty" jsonschema:"gcd and lcm: two or more integers; extended-gcd: exactly two"`
+	Exponent  string   `json:"exponent,omitempty" jsonschema:"mod-pow: the exponent; a negative exponent uses the modular inverse of n"`
+	Modulus   string   `json:"modulus,omitempty" jsonschema:"mod-pow and mod-inverse: the modulus, at least 1"`
+	Rounds    int      `json:"rounds,omitempty" jsonschema:"number of random Miller–Rabin bases for numbers above 3.3e24, where the test is not deterministic (default: 20)"`
+}
+
+func (p NumberTheoryParams) Validate() error {
+	var needsN, needsNumbers, modular bool
+	var minimum *big.Int
+	switch p.Operation {
+	case "is-prime", "next-prime":
+		needsN = true
+	case "factorize", "totient":
+		needsN, minimum = true, big.NewInt(1)
+	case "previous-prime":
+		needsN, minimum = true, big.NewInt(3)
+	case "gcd", "lcm", "extended-gcd":
+		needsNumbers = true
+	case "mod-pow", "mod-inverse":
+		needsN, modular = true, true
+	}
+	primality := slices.Contains([]string{"is-prime", "factorize", "totient", "next-prime", "previous-prime"}, p.Operation)
+	integer := func(value interface{}) error {
+		_, err := parseBigInt(value.(string))
+		return err
+	}
+	atLeast := func(min *big.Int) validation.RuleFunc {
+		return func(value interface{}) error {
+			if n, err := parseBigInt(value.(string)); err == nil && min != nil && n.Cmp(min) < 0 {
+				return fmt.Errorf("must be at least %s for %s", min, p.Operation)
+			}
+			return nil
+		}
+	}
+	count := validation.Length(2, maxIntegerList)
+	if p.Operation == "extended-gcd" {
+		count = validation.Length(2, 2).Error("must have exactly two numbers")
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Operation, validation.Required, validation.In(numberTheoryOperations...)),
+		validation.Field(&p.N,
+			validation.When(needsN, validation.Required),
+			validation.When(!needsN, validation.Empty.Error("does not apply to "+p.Operation+"; use numbers")),
+			validation.When(p.N != "", validation.By(integer), validation.By(atLeast(minimum))),
+		),
+		validation.Field(&p.Numbers,
+			validation.When(needsNumbers, validation.Required, count),
+			validation.When(!needsNumbers, validation.Nil.Error("only applies to gcd, lcm and extended-gcd")),
+			validation.Each(validation.Required, validation.By(integer)),
+		),
+		validation.Field(&p.Exponent,
+			validation.When(p.Operation == "mod-pow", validation.Required),
+			validation.When(p.Operation != "mod-pow", validation.Empty.Error("only applies to mod-pow")),
+			validation.When(p.Exponent != "", validation.By(integer)),
+		),
+		validation.Field(&p.Modulus,
+			validation.When(modular, validation.Required),
+			validation.When(!modular, validation.Empty.Error("only applies to mod-pow and mod-inverse")),
+			validation.When(p.Modulus != "", validation.By(integer), validation.By(atLeast(big.NewInt(1)))),
+		),
+		validation.Field(&p.Rounds,
+			validation.When(!primality, validation.Empty.Error("only applies to the prime operations")),
+			validation.Min(0), validation.Max(maxMillerRabinRounds),
+		),
+	)
+}
+
+// parseBigInt parses a decimal integer, or a hexadecimal, octal or binary
+// one with a 0x, 0o or 0b prefix, with an optional sign.
+func parseBigInt(s string) (*big.Int, error) {
+	text := strings.TrimSpace(s)
+	digits := strings.ToLower(strings.TrimLeft(text, "+-"))
+	base := 10
+	if len(digits) > 2 && digits[0] == '0' && strings.ContainsRune("xob", rune(digits[1])) {
+		base = 0
+	}
+	if len(digits) > maxIntegerDigits {
+		return nil, fmt.Errorf("must have at most %d digits", maxIntegerDigits)
+	}
+	n, ok := new(big.Int).SetString(text, base)
+	if !ok {
+		return nil, fmt.Errorf("%q is not an integer", s)
+	}
+	return n, nil
+}
+
+// PrimePower is a prime factor and its exponent.
+type PrimePower struct {
+	Prime    string `json:"prime" jsonschema:"the prime, as a decimal string"`
+	Exponent int    `json:"exponent" jsonschema:"its exponent"`
+}
+
+// NumberTheoryResult defines the result for the number-theory tool.
+type NumberTheoryResult struct {
+	Result     string       `json:"result,omitempty" jsonschema:"the answer as a decimal integer string"`
+	IsPrime    *bool        `json:"is_prime,omitempty" jsonschema:"is-prime: whether n is prime"`
+	Certainty  string       `json:"certainty,omitempty" jsonschema:"is-prime and the prime operations: 'proven', or 'probable' when Miller–Rabin with random bases was used"`
+	Factors    []PrimePower `json:"factors,omitempty" jsonschema:"factorize: the prime factors in increasing order"`
+	Unfactored []string     `json:"unfactored,omitempty" jsonschema:"factorize: composite factors that Pollard's rho could not split within its iteration limit"`
+	X          string       `json:"x,omitempty" jsonschema:"extended-gcd: x with a*x + b*y = gcd(a, b)"`
+	Y          string       `json:"y,omitempty" jsonschema:"extended-gcd: y with a*x + b*y = gcd(a, b)"`
+	Method     string       `json:"method,omitempty" jsonschema:"how the result was found"`
+	Note       string       `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handleNumberTheory(ctx context.Context, req *mcp.CallToolRequest, param NumberTheoryParams) (*mcp.CallToolResult, NumberTheoryResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			NumberTheoryResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	result, text, err := runNumberTheory(param)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			NumberTheoryResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// runNumberTheory performs the operation and returns the result with its
+// text form. The parameters must already have passed Validate.
+func runNumberTheory(param NumberTheoryParams) (NumberTheoryResult, string, error) {
+	n, _ := parseBigInt(param.N)
+	rounds := param.Rounds
+	if rounds == 0 {
+		rounds = defaultMillerRabinRounds
+	}
+	var result NumberTheoryResult
+	switch param.Operation {
+	case "is-prime":
+		t := primality(n, rounds)
+		result.IsPrime, result.Certainty, result.Method = &t.prime, t.certainty(), t.method
+		text := fmt.Sprintf("%s is prime", n)
+		switch {
+		case !t.prime && t.divisor != nil:
+			text = fmt.Sprintf("%s is not prime: it is divisible by %s", n, t.divisor)
+		case !t.prime:
+			text = fmt.Sprintf("%s is not prime", n)
+		case !t.proven:
+			text = fmt.Sprintf("%s is probably prime", n)
+			result.Note = fmt.Sprintf("a composite number passes %d random Miller–Rabin bases with probability below 4^-%d", rounds, rounds)
+		}
+		return result, fmt.Sprintf("%s (%s)", text, t.method), nil
+
+	case "factorize":
+		f := factorize(n, rounds)
+		result.Factors, result.Method = f.factors, "trial division, Pollard's rho (Brent's variant) and Miller–Rabin"
+		for _, u := range f.unfactored {
+			result.Unfactored = append(result.Unfactored, u.String())
+		}
+		result.Note = f.note()
+		text := fmt.Sprintf("%s = %s", n, f.String())
+		if result.Note != "" {
+			text += "\nNote: " + result.Note
+		}
+		return result, text, nil
+
+	case "totient":
+		f := factorize(n, rounds)
+		if len(f.unfactored) > 0 {
+			return NumberTheoryResult{}, "", fmt.Errorf("the totient needs the prime factors of n, but %s could not be factored", f.unfactored[0])
+		}
+		phi := big.NewInt(1)
+		for _, pp := range f.factors {
+			p, _ := new(big.Int).SetString(pp.Prime, 10)
+			phi.Mul(phi, new(big.Int).Sub(p, big.NewInt(1)))
+			phi.Mul(phi, new(big.Int).Exp(p, big.NewInt(int64(pp.Exponent-1)), nil))
+		}
+		result.Result, result.Method, result.Note = phi.String(), "φ(n) = n·∏(1 - 1/p) over the prime factors p", f.note()
+		return result, fmt.Sprintf("φ(%s) = %s", n, phi), nil
+
+	case "next-prime", "previous-prime":
+		step := int64(1)
+		if param.Operation == "previous-prime" {
+			step = -1
+		}
+		p := new(big.Int).Set(n)
+		for {
+			p.Add(p, big.NewInt(step))
+			if t := primality(p, rounds); t.prime {
+				result.Result, result.Certainty, result.Method = p.String(), t.certainty(), t.method
+				break
+			}
+		}
+		if result.Certainty == "probable" {
+			result.Note = fmt.Sprintf("a composite number passes %d random Miller–Rabin bases with probability below 4^-%d", rounds, rounds)
+		}
+		if step < 0 {
+			return result, fmt.Sprintf("The previous prime before %s is %s", n, p), nil
+		}
+		return result, fmt.Sprintf("The next prime after %s is %s", n, p), nil
+
+	case "gcd", "lcm":
+		xs := make([]*big.Int, len(param.Numbers))
+		for i, s := range param.Numbers {
+			xs[i], _ = parseBigInt(s)
+		}
+		result.Result = gcdLCM(param.Operation, xs).String()
+		return result, fmt.Sprintf("%s(%s) = %s", param.Operation, strings.Join(param.Numbers, ", "), result.Result), nil
+
+	case "extended-gcd":
+		a, _ := parseBigInt(param.Numbers[0])
+		b, _ := parseBigInt(param.Numbers[1])
+		x, y := new(big.Int), new(big.Int)
+		g := new(big.Int).GCD(x, y, new(big.Int).Abs(a), new(big.Int).Abs(b))
+		if a.Sign() < 0 {
+			x.Neg(x)
+		}
+		if b.Sign() < 0 {
+			y.Neg(y)
+		}
+		result.Result, result.X, result.Y, result.Method = g.String(), x.String(), y.String(), "extended Euclidean algorithm"
+		signed := func(v *big.Int) string {
+			if v.Sign() < 0 {
+				return "(" + v.String() + ")"
+			}
+			return v.String()
+		}
+		return result, fmt.Sprintf("gcd(%s, %s) = %s = %s·%s + %s·%s", a, b, g, signed(a), signed(x), signed(b), signed(y)), nil
+
+	case "mod-pow":
+		e, _ := parseBigInt(param.Exponent)
+		m, _ := parseBigInt(param.Modulus)
+		base := new(big.Int).Mod(n, m)
+		if e.Sign() < 0 {
+			inverse := new(big.Int).ModInverse(base, m)
+			if inverse == nil && m.Cmp(big.NewInt(1)) != 0 {
+				return NumberTheoryResult{}, "", fmt.Errorf("a negative exponent needs the inverse of n modulo %s, but gcd(n, modulus) = %s", m, new(big.Int).GCD(nil, nil, base, m))
+			}
+			if inverse == nil {
+				inverse = new(big.Int)
+			}
+			base, e = inverse, new(big.Int).Neg(e)
+		}
+		r := new(big.Int).Exp(base, e, m)
+		result.Result, result.Method = r.String(), "square-and-multiply"
+		return result, fmt.Sprintf("%s^%s mod %s = %s", n, param.Exponent, m, r), nil
+
+	default: // mod-inverse
+		m, _ := parseBigInt(param.Modulus)
+		if m.Cmp(big.NewInt(1)) == 0 {
+			result.Result, result.Method = "0", "extended Euclidean algorithm"
+			return result, fmt.Sprintf("%s⁻¹ mod 1 = 0", n), nil
+		}
+		inverse := new(big.Int).ModInverse(new(big.Int).Mod(n, m), m)
+		if inverse == nil {
+			return NumberTheoryResult{}, "", fmt.Errorf("%s has no inverse modulo %s: gcd(%s, %s) = %s", n, m, n, m, new(big.Int).GCD(nil, nil, new(big.Int).Abs(n), m))
+		}
+		result.Result, result.Method = inverse.String(), "extended Euclidean algorithm"
+		return result, fmt.Sprintf("%s⁻¹ mod %s = %s", n, m, inverse), nil
+	}
+}
+
+// primalityTest is the outcome of a primality test, with a divisor when
+// trial division found one.
+type primalityTest struct {
+	prime, proven bool
+	divisor       *big.Int
+	method        string
+}
+
+func (t primalityTest) certainty() string {
+	if t.proven {
+		return "proven"
+	}
+	return "probable"
+}
+
+// primality tests n by trial division, then by Miller–Rabin: with the
+// deterministic bases below millerRabinBound, and with rounds random bases
+// above it, where only compositeness is proven.
+func primality(n *big.Int, rounds int) primalityTest {
+	if n.Cmp(big.NewInt(2)) < 0 {
+		return primalityTest{proven: true, method: "primes are at least 2"}
+	}
+	trial := fmt.Sprintf("trial division by primes below %d", trialDivisionLimit)
+	r := new(big.Int)
+	for _, p := range smallPrimes {
+		bp := big.NewInt(p)
+		if n.Cmp(bp) == 0 {
+			return primalityTest{prime: true, proven: true, method: trial}
+		}
+		if r.Mod(n, bp).Sign() == 0 {
+			return primalityTest{proven: true, divisor: bp, method: trial}
+		}
+		if n.IsInt64() && p*p > n.Int64() {
+			return primalityTest{prime: true, proven: true, method: trial}
+		}
+	}
+	if n.Cmp(millerRabinBound) < 0 {
+		bases := make([]*big.Int, len(millerRabinBases))
+		for i, b := range millerRabinBases {
+			bases[i] = big.NewInt(b)
+		}
+		return primalityTest{prime: millerRabin(n, bases), proven: true,
+			method: "deterministic Miller–Rabin with the prime bases 2 to 41"}
+	}
+	bases := make([]*big.Int, rounds)
+	limit := new(big.Int).Sub(n, big.NewInt(3))
+	for i := range bases {
+		bases[i] = new(big.Int).Rand(secureRand, limit)
+		bases[i].Add(bases[i], big.NewInt(2))
+	}
+	prime := millerRabin(n, bases)
+	return primalityTest{prime: prime, proven: !prime,
+		method: fmt.Sprintf("Miller–Rabin with %d random bases", rounds)}
+}
+
+// millerRabin reports whether the odd number n > 3 is a strong probable
+// prime to every base.
+func millerRabin(n *big.Int, bases []*big.Int) bool {
+	one := big.NewInt(1)
+	nMinus1 := new(big.Int).Sub(n, one)
+	s := nMinus1.TrailingZeroBits()
+	d := new(big.Int).Rsh(nMinus1, s)
+	x := new(big.Int)
+next:
+	for _, a := range bases {
+		x.Exp(a, d, n)
+		if x.Cmp(one) == 0 || x.Cmp(nMinus1) == 0 {
+			continue
+		}
+		for range s - 1 {
+			x.Exp(x, big.NewInt(2), n)
+			if x.Cmp(nMinus1) == 0 {
+				continue next
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// factorization is the prime factorization of a number, possibly with
+// composite factors left over.
+type factorization struct {
+	factors    []PrimePower
+	unfactored []*big.Int
+	probable   bool
+}
+
+func (f factorization) String() string {
+	var parts []string
+	for _, pp := range f.factors {
+		if pp.Exponent > 1 {
+			parts = append(parts, fmt.Sprintf("%s^%d", pp.Prime, pp.Exponent))
+		} else {
+			parts = append(parts, pp.Prime)
+		}
+	}
+	for _, u := range f.unfactored {
+		parts = append(parts, "("+u.String()+")")
+	}
+	if len(parts) == 0 {
+		return "1"
+	}
+	return strings.Join(parts, " × ")
+}
+
+// note explains unfactored and probable factors.
+func (f factorization) note() string {
+	var notes []string
+	if len(f.unfactored) > 0 {
+		notes = append(notes, fmt.Sprintf("the composite factors in parentheses could not be split within %d steps of Pollard's rho", maxRhoSteps))
+	}
+	if f.probable {
+		notes = append(notes, fmt.Sprintf("factors above %s are probable primes by Miller–Rabin with random bases", millerRabinBound))
+	}
+	return strings.Join(notes, "; ")
+}
+
+// factorize factors n ≥ 1 by trial division by the small primes, then by
+// Pollard's rho on the remaining composites.
+func factorize(n *big.Int, rounds int) factorization {
+	var f factorization
+	counts := map[string]int{}
+	var primes []*big.Int
+	add := func(p *big.Int) {
+		key := p.String()
+		if counts[key] == 0 {
+			primes = append(primes, p)
+		}
+		counts[key]++
+	}
+
+	rest := new(big.Int).Set(n)
+	q, r := new(big.Int), new(big.Int)
+	for _, p := range smallPrimes {
+		bp := big.NewInt(p)
+		if new(big.Int).Mul(bp, bp).Cmp(rest) > 0 {
+			break
+		}
+		for {
+			q.QuoRem(rest, bp, r)
+			if r.Sign() != 0 {
+				break
+			}
+			add(bp)
+			rest.Set(q)
+		}
+	}
+
+	budget := maxRhoSteps
+	pending := []*big.Int{}
+	if rest.Cmp(big.NewInt(1)) > 0 {
+		pending = append(pending, rest)
+	}
+	for len(pending) > 0 {
+		m := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if t := primality(m, rounds); t.prime {
+			f.probable = f.probable || !t.proven
+			add(m)
+			continue
+		}
+		d := pollardRho(m, &budget)
+		if d == nil {
+			f.unfactored = append(f.unfactored, m)
+			continue
+		}
+		pending = append(pending, d, new(big.Int).Quo(m, d))
+	}
+
+	slices.SortFunc(primes, func(a, b *big.Int) int { return a.Cmp(b) })
+	for _, p := range primes {
+		f.factors = append(f.factors, PrimePower{Prime: p.String(), Exponent: counts[p.String()]})
+	}
+	slices.SortFunc(f.unfactored, func(a, b *big.Int) int { return a.Cmp(b) })
+	return f
+}
+
+// pollardRho returns a non-trivial factor of the composite n, found by
+// Pollard's rho with Brent's cycle detection on x² + c for c = 1, 2, ...,
+// or nil when the budget of steps runs out.
+func pollardRho(n *big.Int, budget *int) *big.Int {
+	const batch = 128
+	one := big.NewInt(1)
+	if n.Bit(0) == 0 {
+		return big.NewInt(2)
+	}
+	for c := int64(1); *budget > 0; c++ {
+		bc := big.NewInt(c)
+		step := func(x *big.Int) {
+			x.Mul(x, x).Add(x, bc).Mod(x, n)
+		}
+		y, x, ys := big.NewInt(2), new(big.Int), new(big.Int)
+		g, q, diff := big.NewInt(1), big.NewInt(1), new(big.Int)
+		for length := 1; g.Cmp(one) == 0 && *budget > 0; length *= 2 {
+			x.Set(y)
+			for range length {
+				step(y)
+			}
+			*budget -= length
+			for k := 0; k < length && g.Cmp(one) == 0; k += batch {
+				ys.Set(y)
+				for range min(batch, length-k) {
+					step(y)
+					q.Mul(q, diff.Abs(diff.Sub(x, y))).Mod(q, n)
+				}
+				*budget -= min(batch, length-k)
+				g.GCD(nil, nil, q, n)
+			}
+		}
+		if g.Cmp(n) == 0 {
+			// The batch overshot: retrace it one step at a time.
+			for {
+				step(ys)
+				g.GCD(nil, nil, diff.Abs(diff.Sub(x, ys)), n)
+				if g.Cmp(one) != 0 {
+					break
+				}
+			}
+		}
+		if g.Cmp(one) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+)
+
+const (
+	// maxOperands bounds the operands array of the calculate tool.
+	maxOperands = 10_000
+	// maxPowerExponent bounds integer exponents in the big and exact modes.
+	maxPowerExponent = 1_000_000
+	// maxPowerBits bounds the size of an exact power, so that a small input
+	// such as 10^1000000 cannot be used to exhaust memory.
+	maxPowerBits = 1 << 22
+)
+
+// operationArity gives the least and greatest number of operands each
+// operation of the calculate tool takes.
+var operationArity = map[string]struct{ min, max int }{
+	"add":            {2, 2},
+	"subtract":       {2, 2},
+	"multiply":       {2, 2},
+	"divide":         {2, 2},
+	"modulo":         {2, 2},
+	"integer-divide": {2, 2},
+	"power":          {2, 2},
+	"percent-of":     {2, 2},
+	"sum":            {1, maxOperands},
+	"product":        {1, maxOperands},
+	"min":            {1, maxOperands},
+	"max":            {1, maxOperands},
+	"average":        {1, maxOperands},
+	"gcd":            {2, maxOperands},
+	"lcm":            {2, maxOperands},
+}
+
+var calculateOperations = []interface{}{
+	"add", "subtract", "multiply", "divide", "modulo", "integer-divide", "power", "percent-of",
+	"sum", "product", "min", "max", "average", "gcd", "lcm",
+}
+
+// divides reports whether operation divides by its second operand.
+func divides(operation string) bool {
+	return operation == "divide" || operation == "modulo" || operation == "integer-divide"
+}
+
+// checkArity reports whether operation accepts n operands.
+func checkArity(operation string, n int) error {
+	arity, ok := operationArity[operation]
+	if !ok {
+		return nil // reported by validation.In
+	}
+	switch {
+	case n == 0:
+		return errors.New("must not be empty")
+	case arity.min == arity.max && n != arity.min:
+		return fmt.Errorf("%s takes exactly %d operands, got %d", operation, arity.min, n)
+	case n < arity.min:
+		return fmt.Errorf("%s takes at least %d operands, got %d", operation, arity.min, n)
+	case n > arity.max:
+		return fmt.Errorf("%s takes at most %d operands, got %d", operation, arity.max, n)
+	}
+	return nil
+}
+
+// calculateFloat applies operation to xs in float64 arithmetic. modulo and
+// integer-divide use floored division, so x = y*q + r with r taking the sign
+// of y.
+func calculateFloat(operation string, xs []float64) (float64, error) {
+	switch operation {
+	case "add":
+		return xs[0] + xs[1], nil
+	case "subtract":
+		return xs[0] - xs[1], nil
+	case "multiply":
+		return xs[0] * xs[1], nil
+	case "divide":
+		if xs[1] == 0 {
+			return 0, errors.New("cannot divide by zero")
+		}
+		return xs[0] / xs[1], nil
+	case "modulo", "integer-divide":
+		x, y := xs[0], xs[1]
+		if y == 0 {
+			return 0, errors.New("cannot divide by zero")
+		}
+		r := math.Mod(x, y)
+		if r != 0 && (r < 0) != (y < 0) {
+			r += y
+		}
+		if operation == "modulo" {
+			return r, nil
+		}
+		return math.Round((x - r) / y), nil
+	case "power":
+		x, y := xs[0], xs[1]
+		if x == 0 && y < 0 {
+			return 0, errors.New("cannot raise zero to a negative power")
+		}
+		if x < 0 && y != math.Trunc(y) {
+			return 0, errors.New("cannot raise a negative number to a non-integer power")
+		}
+		return math.Pow(x, y), nil
+	case "percent-of":
+		return xs[0] / 100 * xs[1], nil
+	case "sum":
+		return compensatedSum(xs, 1), nil
+	case "average":
+		n := float64(len(xs))
+		if sum := compensatedSum(xs, 1); !math.IsInf(sum, 0) {
+			return sum / n, nil
+		}
+		// The sum overflows, but the mean of finite operands need not:
+		// add up the operands divided by their count instead.
+		return compensatedSum(xs, n), nil
+	case "product":
+		product := 1.0
+		for _, x := range xs {
+			product *= x
+		}
+		return product, nil
+	case "min":
+		return slices.Min(xs), nil
+	case "max":
+		return slices.Max(xs), nil
+	case "gcd", "lcm":
+		ints := make([]*big.Int, len(xs))
+		for i, x := range xs {
+			if x != math.Trunc(x) || math.Abs(x) > maxSafeInteger {
+				return 0, fmt.Errorf("%s needs whole numbers between -2^53 and 2^53, got %g", operation, x)
+			}
+			ints[i] = big.NewInt(int64(x))
+		}
+		f, _ := new(big.Float).SetInt(gcdLCM(operation, ints)).Float64()
+		return f, nil
+	}
+	return 0, fmt.Errorf("unsupported operation %q", operation)
+}
+
+// compensatedSum returns the sum of x/divisor over xs by Neumaier's
+// compensated summation, which keeps the rounding error independent of the
+// number of operands.
+func compensatedSum(xs []float64, divisor float64) float64 {
+	var sum, compensation float64
+	for _, x := range xs {
+		x /= divisor
+		t := sum + x
+		if math.IsInf(t, 0) {
+			// The sum has overflowed; the compensation of an infinite
+			// sum would be -Inf+Inf, which is NaN.
+			sum, compensation = t, 0
+			continue
+		}
+		if math.Abs(sum) >= math.Abs(x) {
+			compensation += (sum - t) + x
+		} else {
+			compensation += (x - t) + sum
+		}
+		sum = t
+	}
+	return sum + compensation
+}
+
+// gcdLCM returns the non-negative greatest common divisor or least common
+// multiple of xs. The gcd of zeros is 0, as is any lcm with a zero operand.
+func gcdLCM(operation string, xs []*big.Int) *big.Int {
+	result := new(big.Int).Abs(xs[0])
+	for _, x := range xs[1:] {
+		x = new(big.Int).Abs(x)
+		if operation == "gcd" {
+			result.GCD(nil, nil, result, x)
+			continue
+		}
+		if result.Sign() == 0 || x.Sign() == 0 {
+			return new(big.Int)
+		}
+		g := new(big.Int).GCD(nil, nil, result, x)
+		result.Mul(result.Quo(result, g), x)
+	}
+	return result
+}
+
+// calculateExact applies operation to xs in exact rational arithmetic.
+// modulo and integer-divide use floored division like calculateFloat; power
+// needs an integer exponent.
+func calculateExact(operation string, xs []*big.Rat) (*big.Rat, error) {
+	result := new(big.Rat)
+	switch operation {
+	case "add":
+		return result.Add(xs[0], xs[1]), nil
+	case "subtract":
+		return result.Sub(xs[0], xs[1]), nil
+	case "multiply":
+		return result.Mul(xs[0], xs[1]), nil
+	case "divide", "modulo", "integer-divide":
+		if xs[1].Sign() == 0 {
+			return nil, errors.New("cannot divide by zero")
+		}
+		result.Quo(xs[0], xs[1])
+		if operation == "divide" {
+			return result, nil
+		}
+		// Rat denominators are positive, so Euclidean division of the
+		// numerator by the denominator rounds toward negative infinity.
+		q := new(big.Int).Div(result.Num(), result.Denom())
+		if operation == "integer-divide" {
+			return result.SetInt(q), nil
+		}
+		return result.Sub(xs[0], result.Mul(xs[1], result.SetInt(q))), nil
+	case "power":
+		return ratPow(xs[0], xs[1])
+	case "percent-of":
+		result.Mul(xs[0], xs[1])
+		return result.Quo(result, big.NewRat(100, 1)), nil
+	case "sum", "average":
+		for _, x := range xs {
+			result.Add(result, x)
+		}
+		if operation == "average" {
+			result.Quo(result, big.NewRat(int64(len(xs)), 1))
+		}
+		return result, nil
+	case "product":
+		result.SetInt64(1)
+		for _, x := range xs {
+			result.Mul(result, x)
+		}
+		return result, nil
+	case "min", "max":
+		result.Set(xs[0])
+		for _, x := range xs[1:] {
+			if c := x.Cmp(result); (operation == "min" && c < 0) || (operation == "max" && c > 0) {
+				result.Set(x)
+			}
+		}
+		return result, nil
+	case "gcd", "lcm":
+		ints := make([]*big.Int, len(xs))
+		for i, x := range xs {
+			if !x.IsInt() {
+				return nil, fmt.Errorf("%s needs whole numbers, got %s", operation, x.RatString())
+			}
+			ints[i] = x.Num()
+		}
+		return result.SetInt(gcdLCM(operation, ints)), nil
+	}
+	return nil, fmt.Errorf("unsupported operation %q", operation)
+}
+
+// powerExponent checks that y is an integer exponent no larger than
+// maxPowerExponent in magnitude.
+func powerExponent(y *big.Rat) (int64, error) {
+	if !y.IsInt() {
+		return 0, errors.New("power needs a whole-number exponent in this precision; use float64 precision for fractional exponents")
+	}
+	if !y.Num().IsInt64() || y.Num().Int64() > maxPowerExponent || y.Num().Int64() < -maxPowerExponent {
+		return 0, fmt.Errorf("exponent must be between -%d and %d", maxPowerExponent, maxPowerExponent)
+	}
+	return y.Num().Int64(), nil
+}
+
+// ratPow returns x^y exactly for an integer exponent y.
+func ratPow(x, y *big.Rat) (*big.Rat, error) {
+	e, err := powerExponent(y)
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() == 0 && e < 0 {
+		return nil, errors.New("cannot raise zero to a negative power")
+	}
+	n := e
+	if n < 0 {
+		n = -n
+	}
+	if int64(x.Num().BitLen()+x.Denom().BitLen())*n > maxPowerBits {
+		return nil, fmt.Errorf("result would have more than %d bits", maxPowerBits)
+	}
+	num := new(big.Int).Exp(x.Num(), big.NewInt(n), nil)
+	den := new(big.Int).Exp(x.Denom(), big.NewInt(n), nil)
+	if e < 0 {
+		num, den = den, num
+	}
+	return new(big.Rat).SetFrac(num, den), nil
+}
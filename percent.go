@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// defaultAllocationScale is the number of fractional digits of the
+	// shares of allocate, such as cents.
+	defaultAllocationScale = 2
+	// maxRatioTerms bounds the terms of a ratio.
+	maxRatioTerms = 1000
+	// maxSignificantDigits bounds the significant figures of round.
+	maxSignificantDigits = 100
+)
+
+var percentOperations = []interface{}{
+	"percent-of", "what-percent", "percent-change",
+	"add-percent", "subtract-percent", "reverse-percent",
+	"allocate", "simplify-ratio", "round",
+}
+
+// PercentRatioParams defines the parameters for the percent-and-ratio tool.
+type PercentRatioParams struct {
+	Operation   string        `json:"operation" jsonschema:"'percent-of' (percent of value), 'what-percent' (value as a percentage of total), 'percent-change' (from from to to), 'add-percent' (value plus percent, such as adding VAT), 'subtract-percent' (value less percent, such as a discount), 'reverse-percent' (the amount before percent was added to value, such as the net of a price with VAT), 'allocate' (split value in the proportions of ratio without losing cents), 'simplify-ratio' or 'round' (value to scale decimal places or to significant figures)"`
+	Value       interface{}   `json:"value,omitempty" jsonschema:"the amount, as a number or a string such as \"119.99\" or \"1,250\""`
+	Percent     interface{}   `json:"percent,omitempty" jsonschema:"the percentage, such as 20 or \"20%\" for 20%"`
+	Total       interface{}   `json:"total,omitempty" jsonschema:"what-percent: the whole that value is a part of"`
+	From        interface{}   `json:"from,omitempty" jsonschema:"percent-change: the old value"`
+	To          interface{}   `json:"to,omitempty" jsonschema:"percent-change: the new value"`
+	Ratio       []interface{} `json:"ratio,omitempty" jsonschema:"allocate and simplify-ratio: the terms of the ratio, such as [3, 2, 1] for 3:2:1"`
+	Scale       *int          `json:"scale,omitempty" jsonschema:"fractional digits of the result (default: exact; allocate: 2); negative to round to tens, hundreds and so on"`
+	Significant int           `json:"significant,omitempty" jsonschema:"round: the number of significant figures, instead of scale"`
+	Rounding    string        `json:"rounding,omitempty" jsonschema:"rounding mode: 'half-even' (default), 'half-up', 'down', 'up', 'ceiling' or 'floor'; allocate always uses the largest remainder method"`
+	Locale      string        `json:"locale,omitempty" jsonschema:"separators of numbers given as strings: 'en' (default, 1,234.5), 'de' (1.234,5), 'fr' (1 234,5), 'ch' (1'234.5) or another language code"`
+}
+
+func (p PercentRatioParams) Validate() error {
+	if err := checkLocale(p.Locale); err != nil {
+		return err
+	}
+	op := p.Operation
+	uses := func(ops ...string) bool { return slices.Contains(ops, op) }
+	number := func(value interface{}) error {
+		_, err := decimalValue(value, p.Locale)
+		return err
+	}
+	nonZero := func(value interface{}) error {
+		if x, err := decimalValue(value, p.Locale); value != nil && err == nil && x.Sign() == 0 {
+			return errors.New("must not be zero")
+		}
+		return nil
+	}
+	required := func(applies bool, description string) validation.Rule {
+		if applies {
+			return validation.NotNil.Error("is required for " + op)
+		}
+		return validation.Nil.Error("only applies to " + description)
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Operation, validation.Required, validation.In(percentOperations...)),
+		validation.Field(&p.Value,
+			required(!uses("percent-change", "simplify-ratio"), "the operations other than percent-change and simplify-ratio"),
+			validation.By(number),
+		),
+		validation.Field(&p.Percent,
+			required(uses("percent-of", "add-percent", "subtract-percent", "reverse-percent"), "percent-of, add-percent, subtract-percent and reverse-percent"),
+			validation.By(number),
+			validation.When(op == "reverse-percent", validation.By(func(value interface{}) error {
+				if x, err := percentValue(value, p.Locale); err == nil && x.Cmp(big.NewRat(-100, 1)) <= 0 {
+					return errors.New("must be above -100 for reverse-percent")
+				}
+				return nil
+			})),
+		),
+		validation.Field(&p.Total,
+			required(op == "what-percent", "what-percent"),
+			validation.By(number), validation.By(nonZero),
+		),
+		validation.Field(&p.From,
+			required(op == "percent-change", "percent-change"),
+			validation.By(number), validation.By(nonZero),
+		),
+		validation.Field(&p.To,
+			required(op == "percent-change", "percent-change"),
+			validation.By(number),
+		),
+		validation.Field(&p.Ratio,
+			validation.When(uses("allocate", "simplify-ratio"), validation.Required.Error("is required for "+op), validation.Length(1, maxRatioTerms)),
+			validation.When(!uses("allocate", "simplify-ratio"), validation.Nil.Error("only applies to allocate and simplify-ratio")),
+			validation.Each(validation.NotNil, validation.By(number), validation.By(func(value interface{}) error {
+				if x, err := decimalValue(value, p.Locale); err == nil && x.Sign() < 0 {
+					return errors.New("must not be negative")
+				}
+				return nil
+			})),
+			validation.When(len(p.Ratio) > 0, validation.By(func(value interface{}) error {
+				for _, term := range p.Ratio {
+					if x, err := decimalValue(term, p.Locale); err != nil || x.Sign() != 0 {
+						return nil
+					}
+				}
+				return errors.New("needs a term above zero")
+			})),
+		),
+		validation.Field(&p.Scale,
+			validation.Min(-maxDecimalScale), validation.Max(maxDecimalScale),
+			validation.When(op == "simplify-ratio", validation.Nil.Error("does not apply to simplify-ratio")),
+			validation.When(op == "allocate", validation.Min(0)),
+			validation.When(p.Significant != 0, validation.Nil.Error("cannot be combined with significant")),
+		),
+		validation.Field(&p.Significant,
+			validation.When(op != "round", validation.Empty.Error("only applies to round")),
+			validation.When(op == "round" && p.Scale == nil, validation.Required.Error("is required for round when scale is not given")),
+			validation.Min(0), validation.Max(maxSignificantDigits),
+		),
+		validation.Field(&p.Rounding,
+			validation.In(roundingModes...),
+			validation.When(op == "allocate" || op == "simplify-ratio", validation.Empty.Error("does not apply to "+op)),
+		),
+	)
+}
+
+// AllocationShare is one share of an allocation.
+type AllocationShare struct {
+	Result float64 `json:"result" jsonschema:"the share"`
+	Value  string  `json:"value" jsonschema:"the share as a decimal string with scale digits"`
+	Exact  string  `json:"exact" jsonschema:"the exact proportional share as a reduced fraction, before rounding"`
+	Term   string  `json:"term" jsonschema:"the term of the ratio"`
+}
+
+// PercentRatioResult defines the result for the percent-and-ratio tool.
+type PercentRatioResult struct {
+	Result  float64           `json:"result" jsonschema:"result of the operation, rounded to scale when one is given; percentages are in percent, so 25 means 25%; simplify-ratio: the first term over the second for a ratio of two terms"`
+	Value   string            `json:"value" jsonschema:"result as a decimal string, rounded to scale when one is given; simplify-ratio: the simplified ratio"`
+	Exact   string            `json:"exact,omitempty" jsonschema:"exact result as a reduced fraction, when value is rounded or cut off; simplify-ratio: the first term over the second"`
+	Portion string            `json:"portion,omitempty" jsonschema:"add-percent, subtract-percent and reverse-percent: the percentage part, such as the VAT or the discount; with scale it is rounded first and the result follows from it"`
+	Shares  []AllocationShare `json:"shares,omitempty" jsonschema:"allocate: one share per term of ratio, summing exactly to value"`
+	Ratio   []string          `json:"ratio,omitempty" jsonschema:"simplify-ratio: the ratio in lowest whole terms"`
+	Note    string            `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handlePercentRatio(ctx context.Context, req *mcp.CallToolRequest, param PercentRatioParams) (*mcp.CallToolResult, PercentRatioResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			PercentRatioResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	result, text := runPercentRatio(param)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// runPercentRatio performs the operation and returns the result with its
+// text form. The parameters must already have passed Validate.
+func runPercentRatio(param PercentRatioParams) (PercentRatioResult, string) {
+	value, _ := decimalValue(param.Value, param.Locale)
+	percent, _ := percentValue(param.Percent, param.Locale)
+	rounding := param.Rounding
+	if rounding == "" {
+		rounding = "half-even"
+	}
+	// input writes a parameter exactly; show writes a result rounded to
+	// scale, or exactly when there is none.
+	input := func(r *big.Rat) string { return ratDecimalString(r, maxRationalDigits) }
+	show := func(r *big.Rat) string {
+		if param.Scale != nil {
+			return formatRounded(roundToScale(r, *param.Scale, rounding), *param.Scale)
+		}
+		return ratDecimalString(r, maxRationalDigits)
+	}
+	hundred := big.NewRat(100, 1)
+	fraction := new(big.Rat).Quo(percent, hundred)
+	portion := new(big.Rat).Mul(value, fraction)
+
+	var result PercentRatioResult
+	var exact *big.Rat
+	var text string
+	switch param.Operation {
+	case "percent-of":
+		exact = portion
+		text = fmt.Sprintf("%s%% of %s = %s", input(percent), input(value), show(exact))
+
+	case "what-percent":
+		total, _ := decimalValue(param.Total, param.Locale)
+		exact = new(big.Rat).Quo(value, total)
+		exact.Mul(exact, hundred)
+		text = fmt.Sprintf("%s is %s%% of %s", input(value), show(exact), input(total))
+
+	case "percent-change":
+		from, _ := decimalValue(param.From, param.Locale)
+		to, _ := decimalValue(param.To, param.Locale)
+		exact = new(big.Rat).Sub(to, from)
+		exact.Quo(exact, new(big.Rat).Abs(from)).Mul(exact, hundred)
+		sign := ""
+		if exact.Sign() > 0 {
+			sign = "+"
+		}
+		text = fmt.Sprintf("From %s to %s: %s%s%%", input(from), input(to), sign, show(exact))
+		if from.Sign() < 0 {
+			result.Note = "from is negative; the change is relative to its magnitude, so a rise is positive"
+		}
+
+	case "add-percent", "subtract-percent":
+		if param.Scale != nil {
+			// Round the part first, as on an invoice, so that value and
+			// the part add up to the result.
+			portion = roundToScale(portion, *param.Scale, rounding)
+		}
+		sign := "+"
+		exact = new(big.Rat).Add(value, portion)
+		if param.Operation == "subtract-percent" {
+			sign = "-"
+			exact.Sub(value, portion)
+		}
+		result.Portion = show(portion)
+		text = fmt.Sprintf("%s %s %s%% = %s (%s%% is %s)", input(value), sign, input(percent), show(exact), input(percent), result.Portion)
+
+	case "reverse-percent":
+		// value = base·(1 + percent/100), so base = value/(1 + percent/100).
+		exact = new(big.Rat).Quo(value, new(big.Rat).Add(big.NewRat(1, 1), fraction))
+		part := new(big.Rat).Sub(value, exact)
+		if param.Scale != nil {
+			// Round the base and take the part as the rest, so that the
+			// two add up to value.
+			part.Sub(value, roundToScale(exact, *param.Scale, rounding))
+		}
+		result.Portion = show(part)
+		text = fmt.Sprintf("%s including %s%% is %s before it was added (%s%% is %s)", input(value), input(percent), show(exact), input(percent), result.Portion)
+
+	case "allocate":
+		scale := defaultAllocationScale
+		if param.Scale != nil {
+			scale = *param.Scale
+		}
+		terms := make([]*big.Rat, len(param.Ratio))
+		for i, term := range param.Ratio {
+			terms[i], _ = decimalValue(term, param.Locale)
+		}
+		shares, exacts := allocate(value, terms, scale)
+		parts := make([]string, len(shares))
+		for i, share := range shares {
+			f, _ := share.Float64()
+			parts[i] = formatRounded(share, scale)
+			result.Shares = append(result.Shares, AllocationShare{
+				Result: f,
+				Value:  parts[i],
+				Exact:  exacts[i].RatString(),
+				Term:   ratDecimalString(terms[i], maxRationalDigits),
+			})
+		}
+		exact = value
+		text = fmt.Sprintf("%s split %s = %s", input(value), joinRatio(terms), strings.Join(parts, " + "))
+		result.Note = "shares are rounded down, and the units left over go one each to the largest remainders"
+
+	case "simplify-ratio":
+		terms := make([]*big.Rat, len(param.Ratio))
+		for i, term := range param.Ratio {
+			terms[i], _ = decimalValue(term, param.Locale)
+		}
+		simplified := simplifyRatio(terms)
+		for _, n := range simplified {
+			result.Ratio = append(result.Ratio, n.String())
+		}
+		exact = new(big.Rat)
+		if len(simplified) == 2 && simplified[1].Sign() != 0 {
+			exact.SetFrac(simplified[0], simplified[1])
+			result.Exact = exact.RatString()
+		}
+		text = fmt.Sprintf("%s = %s", joinRatio(terms), strings.Join(result.Ratio, ":"))
+
+	case "round":
+		scale := 0
+		if param.Scale != nil {
+			scale = *param.Scale
+		}
+		how := fmt.Sprintf("%d decimal place%s", scale, plural(scale))
+		if scale < 0 {
+			how = "the nearest " + pow10(-scale).String()
+		}
+		if param.Significant != 0 {
+			scale = param.Significant - decimalExponent(value)
+			how = fmt.Sprintf("%d significant figure%s", param.Significant, plural(param.Significant))
+		}
+		exact = roundToScale(value, scale, rounding)
+		if param.Significant != 0 && decimalExponent(exact) > decimalExponent(value) {
+			// Rounding carried into a new digit, as 99.96 to 100: keep
+			// the count of significant figures.
+			scale--
+		}
+		result.Value = formatRounded(exact, scale)
+		text = fmt.Sprintf("%s to %s (%s) = %s", input(value), how, rounding, result.Value)
+	}
+
+	result.Result, _ = exact.Float64()
+	result.Result = saturate(result.Result)
+	switch param.Operation {
+	case "round":
+	case "allocate":
+		result.Value = ratDecimalString(exact, maxRationalDigits)
+	case "simplify-ratio":
+		result.Value = strings.Join(result.Ratio, ":")
+	default:
+		result.Value = show(exact)
+		shown, _ := new(big.Rat).SetString(strings.TrimSuffix(result.Value, "..."))
+		if shown.Cmp(exact) != 0 {
+			result.Exact = exact.RatString()
+		}
+		if param.Scale != nil {
+			result.Result, _ = shown.Float64()
+			result.Result = saturate(result.Result)
+		}
+	}
+	if result.Note != "" {
+		text += "\nNote: " + result.Note
+	}
+	return result, text
+}
+
+// percentValue reads a percentage. A string such as "20%" reads as 0.2
+// elsewhere, but here it already says 20 percent.
+func percentValue(value interface{}, locale string) (*big.Rat, error) {
+	x, err := decimalValue(value, locale)
+	if s, ok := value.(string); ok && err == nil && strings.HasSuffix(strings.TrimSpace(s), "%") {
+		x.Mul(x, big.NewRat(100, 1))
+	}
+	return x, err
+}
+
+// roundToScale rounds r to scale fractional digits with the rounding mode.
+// A negative scale rounds to tens, hundreds and so on.
+func roundToScale(r *big.Rat, scale int, mode string) *big.Rat {
+	if scale >= 0 {
+		return new(big.Rat).SetFrac(roundRat(r, scale, mode), pow10(scale))
+	}
+	unit := new(big.Rat).SetInt(pow10(-scale))
+	rounded := roundRat(new(big.Rat).Quo(r, unit), 0, mode)
+	return new(big.Rat).Mul(new(big.Rat).SetInt(rounded), unit)
+}
+
+// formatRounded writes r, already rounded to scale, with exactly scale
+// fractional digits, or as an integer when scale is not positive.
+func formatRounded(r *big.Rat, scale int) string {
+	if scale <= 0 {
+		return new(big.Int).Quo(r.Num(), r.Denom()).String()
+	}
+	return r.FloatString(scale)
+}
+
+// decimalExponent returns e with 10^(e-1) ≤ |r| < 10^e, the number of
+// digits of r before the decimal point, negative for the zeros after it.
+// It is 1 for r = 0, which is written with a single digit.
+func decimalExponent(r *big.Rat) int {
+	if r.Sign() == 0 {
+		return 1
+	}
+	abs := new(big.Rat).Abs(r)
+	e := len(abs.Num().String()) - len(abs.Denom().String()) + 1
+	power := func(e int) *big.Rat {
+		if e >= 0 {
+			return new(big.Rat).SetInt(pow10(e))
+		}
+		return new(big.Rat).SetFrac(big.NewInt(1), pow10(-e))
+	}
+	for abs.Cmp(power(e-1)) < 0 {
+		e--
+	}
+	for abs.Cmp(power(e)) >= 0 {
+		e++
+	}
+	return e
+}
+
+// allocate splits total into shares proportional to terms, each a whole
+// number of units of 10^-scale, that sum exactly to total. Every share is
+// first rounded towards zero; the units left over go one each to the
+// shares with the largest remainders, the earlier share winning ties. It
+// also returns the exact proportional shares.
+func allocate(total *big.Rat, terms []*big.Rat, scale int) (shares, exacts []*big.Rat) {
+	sum := new(big.Rat)
+	for _, t := range terms {
+		sum.Add(sum, t)
+	}
+	unit := new(big.Rat).SetFrac(big.NewInt(1), pow10(scale))
+	sign := int64(total.Sign())
+	units := make([]*big.Int, len(terms))
+	remainders := make([]*big.Rat, len(terms))
+	allotted := new(big.Int)
+	for i, t := range terms {
+		exact := new(big.Rat).Mul(total, t)
+		exact.Quo(exact, sum)
+		exacts = append(exacts, exact)
+		// In units, |share| = whole + remainder with 0 ≤ remainder < 1.
+		scaled := new(big.Rat).Abs(exact)
+		scaled.Quo(scaled, unit)
+		units[i] = new(big.Int).Quo(scaled.Num(), scaled.Denom())
+		remainders[i] = scaled.Sub(scaled, new(big.Rat).SetInt(units[i]))
+		allotted.Add(allotted, units[i])
+	}
+	whole := new(big.Rat).Abs(total)
+	whole.Quo(whole, unit)
+	left := new(big.Int).Quo(whole.Num(), whole.Denom())
+	left.Sub(left, allotted)
+
+	order := make([]int, len(terms))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+	for k := 0; left.Sign() > 0; k++ {
+		units[order[k]].Add(units[order[k]], big.NewInt(1))
+		left.Sub(left, big.NewInt(1))
+	}
+	for _, n := range units {
+		share := new(big.Rat).Mul(new(big.Rat).SetInt(n), unit)
+		shares = append(shares, share.Mul(share, big.NewRat(sign, 1)))
+	}
+	return shares, exacts
+}
+
+// simplifyRatio scales the terms to the smallest whole numbers in the same
+// proportions.
+func simplifyRatio(terms []*big.Rat) []*big.Int {
+	denominators := make([]*big.Int, len(terms))
+	for i, t := range terms {
+		denominators[i] = t.Denom()
+	}
+	common := gcdLCM("lcm", denominators)
+	whole := make([]*big.Int, len(terms))
+	var nonZero []*big.Int
+	for i, t := range terms {
+		whole[i] = new(big.Int).Mul(t.Num(), new(big.Int).Quo(common, t.Denom()))
+		if whole[i].Sign() != 0 {
+			nonZero = append(nonZero, whole[i])
+		}
+	}
+	divisor := nonZero[0]
+	if len(nonZero) > 1 {
+		divisor = gcdLCM("gcd", nonZero)
+	}
+	for _, n := range whole {
+		n.Quo(n, divisor)
+	}
+	return whole
+}
+
+// joinRatio writes terms as a ratio such as 3:2:1.
+func joinRatio(terms []*big.Rat) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = ratDecimalString(t, maxRationalDigits)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultBigFloatBits is the mantissa size used by the bigfloat precision
+	// mode when the caller does not ask for one.
+	defaultBigFloatBits = 256
+	// maxBigFloatBits bounds the mantissa size a caller may request.
+	maxBigFloatBits = 4096
+	// maxRationalDigits is the number of fractional digits shown for a
+	// rational result whose decimal expansion does not terminate.
+	maxRationalDigits = 50
+)
+
+// exactDecimal returns the shortest decimal string that round-trips to f, so
+// that an input of 0.1 is treated as exactly one tenth by the big modes.
+func exactDecimal(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// decimalValue reads a JSON number, or a string in the formats of
+// parseNumber with the separators of locale, as an exact rational. Numbers
+// are taken at their shortest decimal form, so 0.1 is one tenth rather than
+// the binary fraction nearest to it.
+func decimalValue(value interface{}, locale string) (*big.Rat, error) {
+	switch value := value.(type) {
+	case nil:
+		return new(big.Rat), nil
+	case float64:
+		return parseDecimal(exactDecimal(value))
+	case string:
+		return parseNumber(value, locale)
+	}
+	return nil, errors.New("must be a number or a numeric string")
+}
+
+// saturate maps an infinite float64 to the largest finite value of the same
+// sign so that it can be encoded as JSON.
+func saturate(f float64) float64 {
+	if math.IsInf(f, 1) {
+		return math.MaxFloat64
+	}
+	if math.IsInf(f, -1) {
+		return -math.MaxFloat64
+	}
+	return f
+}
+
+// calculateBigFloat applies operation to operands using big.Float with the
+// given mantissa size in bits. Each operand and step rounds to that size;
+// comparisons and gcd/lcm are exact.
+func calculateBigFloat(operation string, operands []*big.Rat, bits uint) (*big.Float, error) {
+	xs := make([]*big.Float, len(operands))
+	for i, r := range operands {
+		xs[i] = new(big.Float).SetPrec(bits).SetRat(r)
+	}
+
+	result := new(big.Float).SetPrec(bits)
+	switch operation {
+	case "add":
+		result.Add(xs[0], xs[1])
+	case "subtract":
+		result.Sub(xs[0], xs[1])
+	case "multiply":
+		result.Mul(xs[0], xs[1])
+	case "divide", "modulo", "integer-divide":
+		if xs[1].Sign() == 0 {
+			return nil, fmt.Errorf("cannot divide by zero")
+		}
+		result.Quo(xs[0], xs[1])
+		if operation == "divide" {
+			break
+		}
+		q := bigFloatFloor(result)
+		if operation == "integer-divide" {
+			return q, nil
+		}
+		result.Sub(xs[0], result.Mul(xs[1], q))
+	case "power":
+		y, _ := xs[1].Rat(nil)
+		e, err := powerExponent(y)
+		if err != nil {
+			return nil, err
+		}
+		if xs[0].Sign() == 0 && e < 0 {
+			return nil, fmt.Errorf("cannot raise zero to a negative power")
+		}
+		n := e
+		if n < 0 {
+			n = -n
+		}
+		// Square and multiply.
+		result.SetInt64(1)
+		square := new(big.Float).SetPrec(bits).Set(xs[0])
+		for ; n > 0; n >>= 1 {
+			if n&1 == 1 {
+				result.Mul(result, square)
+			}
+			square.Mul(square, square)
+		}
+		if e < 0 {
+			result.Quo(new(big.Float).SetPrec(bits).SetInt64(1), result)
+		}
+	case "percent-of":
+		result.Mul(xs[0], xs[1])
+		result.Quo(result, big.NewFloat(100))
+	case "sum", "average":
+		for _, x := range xs {
+			result.Add(result, x)
+		}
+		if operation == "average" {
+			result.Quo(result, new(big.Float).SetInt64(int64(len(xs))))
+		}
+	case "product":
+		result.SetInt64(1)
+		for _, x := range xs {
+			result.Mul(result, x)
+		}
+	case "min", "max":
+		result.Set(xs[0])
+		for _, x := range xs[1:] {
+			if c := x.Cmp(result); (operation == "min" && c < 0) || (operation == "max" && c > 0) {
+				result.Set(x)
+			}
+		}
+	case "gcd", "lcm":
+		ints := make([]*big.Int, len(xs))
+		for i, x := range xs {
+			if !x.IsInt() {
+				return nil, fmt.Errorf("%s needs whole numbers, got %s", operation, x.Text('g', -1))
+			}
+			ints[i], _ = x.Int(nil)
+		}
+		result.SetInt(gcdLCM(operation, ints))
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", operation)
+	}
+	if result.IsInf() {
+		return nil, fmt.Errorf("result overflows the bigfloat exponent range")
+	}
+	return result, nil
+}
+
+// bigFloatFloor returns the largest integer not greater than x, with the
+// precision of x.
+func bigFloatFloor(x *big.Float) *big.Float {
+	if x.IsInt() {
+		return new(big.Float).Copy(x)
+	}
+	i, _ := x.Int(nil) // truncates toward zero
+	if x.Sign() < 0 {
+		i.Sub(i, big.NewInt(1))
+	}
+	return new(big.Float).SetPrec(x.Prec()).SetInt(i)
+}
+
+// ratDecimalString renders r as a decimal string. Terminating expansions are
+// exact; others are cut off after maxDigits fractional digits and marked with
+// a trailing "...".
+func ratDecimalString(r *big.Rat, maxDigits int) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	if digits, ok := terminatingDigits(r); ok && digits <= maxDigits {
+		return strings.TrimRight(r.FloatString(digits), "0")
+	}
+	return r.FloatString(maxDigits) + "..."
+}
+
+// terminatingDigits returns the number of fractional digits in the decimal
+// expansion of r, and false if the expansion does not terminate.
+func terminatingDigits(r *big.Rat) (int, bool) {
+	// A reduced fraction terminates iff its denominator has no prime
+	// factors other than 2 and 5; the number of digits needed is the larger
+	// of the two multiplicities.
+	denom := new(big.Int).Set(r.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	mod := new(big.Int)
+	twos, fives := 0, 0
+	for mod.Mod(denom, two).Sign() == 0 {
+		denom.Quo(denom, two)
+		twos++
+	}
+	for mod.Mod(denom, five).Sign() == 0 {
+		denom.Quo(denom, five)
+		fives++
+	}
+	return max(twos, fives), denom.Cmp(big.NewInt(1)) == 0
+}
@@ -0,0 +1,789 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxZipfRanks bounds n for the zipf distribution, whose cdf is a direct sum.
+const maxZipfRanks = 1_000_000
+
+// Accuracy statements reported with each result. They describe the relative
+// error observed against exact reference values, not a proof.
+const (
+	accuracyClosedForm = "closed form; relative error within a few units in the last place (about 1e-15)"
+	accuracyErfc       = "via math.Erfc on the tail that holds the result; relative error about 1e-15"
+	accuracyGamma      = "regularized incomplete gamma function (series or continued fraction, or Temme's uniform asymptotic expansion from a shape of 1e6); relative error about 1e-14, growing to about shape × 1e-16 for large shapes and 1e-12 for the expansion"
+	accuracyBeta       = "regularized incomplete beta function (continued fraction); relative error about 1e-14, growing to a few times (a + b) × 1e-16 for large shape parameters"
+	accuracySum        = "compensated direct summation; relative error about 1e-15"
+	accuracyBisection  = "bisection on the cdf below the median and on the survival function above it, down to adjacent floating-point numbers; as accurate as those functions"
+	accuracySearch     = "exact integer search for the smallest k with P(X ≤ k) ≥ probability; ties are resolved with a relative tolerance of 1.4e-14"
+)
+
+var probabilityFunctions = []interface{}{"pdf", "pmf", "cdf", "survival", "quantile"}
+
+var probabilityDistributionNames = []interface{}{
+	"uniform", "normal", "exponential", "poisson", "binomial", "geometric", "gamma",
+	"beta", "log-normal", "weibull", "triangular", "zipf", "student-t", "chi-squared",
+}
+
+// probabilityDistributions lists the parameters each distribution of the
+// distribution tool requires and the defaults of those it does not.
+var probabilityDistributions = map[string]struct {
+	required []string
+	defaults map[string]float64
+}{
+	"uniform":     {defaults: map[string]float64{"min": 0, "max": 1}},
+	"normal":      {defaults: map[string]float64{"mean": 0, "stddev": 1}},
+	"exponential": {defaults: map[string]float64{"lambda": 1}},
+	"poisson":     {required: []string{"lambda"}},
+	"binomial":    {required: []string{"n", "p"}},
+	"geometric":   {required: []string{"p"}},
+	"gamma":       {required: []string{"alpha", "beta"}},
+	"beta":        {required: []string{"alpha", "beta"}},
+	"log-normal":  {defaults: map[string]float64{"mu": 0, "sigma": 1}},
+	"weibull":     {required: []string{"shape", "scale"}},
+	"triangular":  {required: []string{"min", "max"}, defaults: map[string]float64{"mode": math.NaN()}},
+	"zipf":        {required: []string{"exponent", "n"}},
+	"student-t":   {required: []string{"df"}},
+	"chi-squared": {required: []string{"df"}},
+}
+
+// DistributionParams defines the parameters for the distribution tool.
+type DistributionParams struct {
+	Distribution string      `json:"distribution" jsonschema:"'uniform', 'normal', 'exponential', 'poisson', 'binomial', 'geometric', 'gamma', 'beta', 'log-normal', 'weibull', 'triangular', 'zipf', 'student-t' or 'chi-squared'"`
+	Function     string      `json:"function" jsonschema:"'pdf' (density) or 'pmf' (mass), 'cdf' P(X ≤ x), 'survival' P(X > x), or 'quantile' (inverse cdf)"`
+	X            interface{} `json:"x,omitempty" jsonschema:"point at which to evaluate pdf, pmf, cdf or survival, as a number or a string such as \"1/3\""`
+	Probability  interface{} `json:"probability,omitempty" jsonschema:"probability between 0 and 1 for quantile, such as 0.975 or \"97.5%\""`
+	Min          interface{} `json:"min,omitempty" jsonschema:"uniform: lower end (default: 0); triangular: lower end"`
+	Max          interface{} `json:"max,omitempty" jsonschema:"uniform: upper end (default: 1); triangular: upper end"`
+	Mean         interface{} `json:"mean,omitempty" jsonschema:"normal: mean (default: 0)"`
+	StdDev       interface{} `json:"stddev,omitempty" jsonschema:"normal: standard deviation (default: 1)"`
+	Lambda       interface{} `json:"lambda,omitempty" jsonschema:"exponential: rate (default: 1); poisson: mean"`
+	N            *int        `json:"n,omitempty" jsonschema:"binomial: number of trials; zipf: number of ranks"`
+	P            interface{} `json:"p,omitempty" jsonschema:"binomial and geometric: success probability"`
+	Alpha        interface{} `json:"alpha,omitempty" jsonschema:"gamma: shape; beta: first shape"`
+	Beta         interface{} `json:"beta,omitempty" jsonschema:"gamma: rate; beta: second shape"`
+	Mu           interface{} `json:"mu,omitempty" jsonschema:"log-normal: mean of the logarithm (default: 0)"`
+	Sigma        interface{} `json:"sigma,omitempty" jsonschema:"log-normal: standard deviation of the logarithm (default: 1)"`
+	Shape        interface{} `json:"shape,omitempty" jsonschema:"weibull: shape k"`
+	Scale        interface{} `json:"scale,omitempty" jsonschema:"weibull: scale"`
+	Mode         interface{} `json:"mode,omitempty" jsonschema:"triangular: peak (default: middle of the range)"`
+	Exponent     interface{} `json:"exponent,omitempty" jsonschema:"zipf: exponent s"`
+	DF           interface{} `json:"df,omitempty" jsonschema:"student-t and chi-squared: degrees of freedom"`
+}
+
+func (p DistributionParams) Validate() error {
+	if err := checkNumbers(p.numbers()); err != nil {
+		return err
+	}
+	evaluate := p.Function != "quantile"
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Distribution,
+			validation.Required,
+			validation.In(probabilityDistributionNames...),
+			validation.By(func(value interface{}) error {
+				_, err := p.resolve()
+				return err
+			}),
+		),
+		validation.Field(&p.Function,
+			validation.Required,
+			validation.In(probabilityFunctions...),
+		),
+		validation.Field(&p.X,
+			validation.When(evaluate, validation.NotNil.Error("is required for "+p.Function)),
+			validation.When(!evaluate, validation.Nil.Error("is not used by quantile; pass probability")),
+		),
+		validation.Field(&p.Probability,
+			validation.When(!evaluate, validation.NotNil.Error("is required for quantile")),
+			validation.When(evaluate, validation.Nil.Error("is only used by quantile")),
+			validation.By(func(value interface{}) error {
+				if prob, _ := optionalFloat(p.Probability); prob != nil && !(*prob >= 0 && *prob <= 1) {
+					return errors.New("must be between 0 and 1")
+				}
+				return nil
+			}),
+		),
+	)
+}
+
+// resolve checks that every parameter given belongs to the distribution and
+// returns the parameters with defaults filled in.
+func (p DistributionParams) resolve() (map[string]float64, error) {
+	spec, ok := probabilityDistributions[p.Distribution]
+	if !ok {
+		return nil, nil // reported by validation.In
+	}
+	args := make(map[string]*float64)
+	for k, v := range p.numbers() {
+		if k != "x" && k != "probability" {
+			args[k], _ = optionalFloat(v)
+		}
+	}
+	if p.N != nil {
+		n := float64(*p.N)
+		args["n"] = &n
+	}
+	params := make(map[string]float64)
+	for _, k := range slices.Sorted(maps.Keys(args)) {
+		v := args[k]
+		if v == nil {
+			continue
+		}
+		if _, isDefault := spec.defaults[k]; !isDefault && !slices.Contains(spec.required, k) {
+			return nil, fmt.Errorf("%s is not a parameter of the %s distribution", k, p.Distribution)
+		}
+		if math.IsNaN(*v) || math.IsInf(*v, 0) {
+			return nil, fmt.Errorf("%s must be a finite number", k)
+		}
+		params[k] = *v
+	}
+	for _, k := range spec.required {
+		if _, ok := params[k]; !ok {
+			return nil, fmt.Errorf("the %s distribution requires %s", p.Distribution, k)
+		}
+	}
+	for k, v := range spec.defaults {
+		if _, ok := params[k]; !ok {
+			params[k] = v
+		}
+	}
+	if p.Distribution == "triangular" && math.IsNaN(params["mode"]) {
+		params["mode"] = (params["min"] + params["max"]) / 2
+	}
+	if _, err := newProbabilityModel(p.Distribution, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// numbers maps the JSON names of the number parameters to their values.
+func (p DistributionParams) numbers() map[string]interface{} {
+	return map[string]interface{}{
+		"x": p.X, "probability": p.Probability,
+		"min": p.Min, "max": p.Max, "mean": p.Mean, "stddev": p.StdDev, "lambda": p.Lambda,
+		"p": p.P, "alpha": p.Alpha, "beta": p.Beta, "mu": p.Mu, "sigma": p.Sigma,
+		"shape": p.Shape, "scale": p.Scale, "mode": p.Mode, "exponent": p.Exponent, "df": p.DF,
+	}
+}
+
+// DistributionResult defines the result for the distribution tool.
+type DistributionResult struct {
+	Value        float64            `json:"value" jsonschema:"the computed density, mass, probability or quantile"`
+	Distribution string             `json:"distribution" jsonschema:"the distribution"`
+	Function     string             `json:"function" jsonschema:"the function evaluated"`
+	Parameters   map[string]float64 `json:"parameters" jsonschema:"parameters of the distribution, including defaults"`
+	Method       string             `json:"method" jsonschema:"how the value was computed"`
+	Accuracy     string             `json:"accuracy" jsonschema:"expected numerical accuracy of the value"`
+}
+
+// probabilityModel holds the functions of one distribution with resolved
+// parameters. Discrete distributions take integer values; their pdf is the
+// probability mass function.
+type probabilityModel struct {
+	discrete bool
+	// lo and hi bound the support.
+	lo, hi      float64
+	pdf         func(x float64) float64
+	cdf, sf     func(x float64) float64
+	cdfMethod   string
+	cdfAccuracy string
+	// quantile is a closed-form inverse cdf; nil means numeric inversion.
+	quantile func(p float64) float64
+}
+
+func newProbabilityModel(name string, a map[string]float64) (probabilityModel, error) {
+	positive := func(keys ...string) error {
+		for _, k := range keys {
+			if a[k] <= 0 {
+				return fmt.Errorf("%s must be greater than 0", k)
+			}
+		}
+		return nil
+	}
+	closedForm := func(m probabilityModel) probabilityModel {
+		m.cdfMethod, m.cdfAccuracy = "closed form", accuracyClosedForm
+		return m
+	}
+	inf := math.Inf(1)
+
+	switch name {
+	case "uniform":
+		lo, hi := a["min"], a["max"]
+		if lo >= hi {
+			return probabilityModel{}, errors.New("min must be less than max")
+		}
+		return closedForm(probabilityModel{
+			lo: lo, hi: hi,
+			pdf: func(x float64) float64 { return 1 / (hi - lo) },
+			cdf: func(x float64) float64 { return (x - lo) / (hi - lo) },
+			sf:  func(x float64) float64 { return (hi - x) / (hi - lo) },
+			quantile: func(p float64) float64 {
+				if p > 0.5 {
+					return hi - (1-p)*(hi-lo)
+				}
+				return lo + p*(hi-lo)
+			},
+		}), nil
+
+	case "normal", "log-normal":
+		mean, sd := a["mean"], a["stddev"]
+		if name == "log-normal" {
+			mean, sd = a["mu"], a["sigma"]
+			if err := positive("sigma"); err != nil {
+				return probabilityModel{}, err
+			}
+		} else if err := positive("stddev"); err != nil {
+			return probabilityModel{}, err
+		}
+		m := probabilityModel{
+			lo: -inf, hi: inf,
+			pdf: func(x float64) float64 {
+				z := (x - mean) / sd
+				return math.Exp(-z*z/2) / (sd * math.Sqrt(2*math.Pi))
+			},
+			cdf:         func(x float64) float64 { return 0.5 * math.Erfc(-(x-mean)/(sd*math.Sqrt2)) },
+			sf:          func(x float64) float64 { return 0.5 * math.Erfc((x-mean)/(sd*math.Sqrt2)) },
+			quantile:    func(p float64) float64 { return mean + sd*normalQuantile(p) },
+			cdfMethod:   "complementary error function",
+			cdfAccuracy: accuracyErfc,
+		}
+		if name == "log-normal" {
+			normal := m
+			m.lo = 0
+			m.pdf = func(x float64) float64 {
+				if x <= 0 {
+					return 0
+				}
+				return normal.pdf(math.Log(x)) / x
+			}
+			m.cdf = func(x float64) float64 { return normal.cdf(math.Log(x)) }
+			m.sf = func(x float64) float64 { return normal.sf(math.Log(x)) }
+			m.quantile = func(p float64) float64 { return math.Exp(normal.quantile(p)) }
+		}
+		return m, nil
+
+	case "exponential":
+		if err := positive("lambda"); err != nil {
+			return probabilityModel{}, err
+		}
+		lambda := a["lambda"]
+		return closedForm(probabilityModel{
+			lo: 0, hi: inf,
+			pdf: func(x float64) float64 { return lambda * math.Exp(-lambda*x) },
+			cdf: func(x float64) float64 { return -math.Expm1(-lambda * x) },
+			sf:  func(x float64) float64 { return math.Exp(-lambda * x) },
+			quantile: func(p float64) float64 {
+				if p > 0.5 {
+					return -math.Log(1-p) / lambda
+				}
+				return -math.Log1p(-p) / lambda
+			},
+		}), nil
+
+	case "weibull":
+		if err := positive("shape", "scale"); err != nil {
+			return probabilityModel{}, err
+		}
+		k, scale := a["shape"], a["scale"]
+		return closedForm(probabilityModel{
+			lo: 0, hi: inf,
+			pdf: func(x float64) float64 {
+				z := x / scale
+				return k / scale * math.Pow(z, k-1) * math.Exp(-math.Pow(z, k))
+			},
+			cdf: func(x float64) float64 { return -math.Expm1(-math.Pow(x/scale, k)) },
+			sf:  func(x float64) float64 { return math.Exp(-math.Pow(x/scale, k)) },
+			quantile: func(p float64) float64 {
+				if p > 0.5 {
+					return scale * math.Pow(-math.Log(1-p), 1/k)
+				}
+				return scale * math.Pow(-math.Log1p(-p), 1/k)
+			},
+		}), nil
+
+	case "triangular":
+		lo, hi, mode := a["min"], a["max"], a["mode"]
+		if lo >= hi {
+			return probabilityModel{}, errors.New("min must be less than max")
+		}
+		if mode < lo || mode > hi {
+			return probabilityModel{}, errors.New("mode must lie between min and max")
+		}
+		w := hi - lo
+		return closedForm(probabilityModel{
+			lo: lo, hi: hi,
+			pdf: func(x float64) float64 {
+				if x < mode {
+					return 2 * (x - lo) / (w * (mode - lo))
+				}
+				if x > mode {
+					return 2 * (hi - x) / (w * (hi - mode))
+				}
+				return 2 / w
+			},
+			// The bounds are handled first: with the mode at a bound, the
+			// branch on that side would divide 0 by 0 there.
+			cdf: func(x float64) float64 {
+				switch {
+				case x <= lo:
+					return 0
+				case x >= hi:
+					return 1
+				case x <= mode:
+					return (x - lo) * (x - lo) / (w * (mode - lo))
+				}
+				return 1 - (hi-x)*(hi-x)/(w*(hi-mode))
+			},
+			sf: func(x float64) float64 {
+				switch {
+				case x <= lo:
+					return 1
+				case x >= hi:
+					return 0
+				case x <= mode:
+					return 1 - (x-lo)*(x-lo)/(w*(mode-lo))
+				}
+				return (hi - x) * (hi - x) / (w * (hi - mode))
+			},
+			quantile: func(p float64) float64 {
+				if p < (mode-lo)/w {
+					return lo + math.Sqrt(p*w*(mode-lo))
+				}
+				return hi - math.Sqrt((1-p)*w*(hi-mode))
+			},
+		}), nil
+
+	case "gamma", "chi-squared":
+		shape, rate := a["alpha"], a["beta"]
+		if name == "chi-squared" {
+			if err := positive("df"); err != nil {
+				return probabilityModel{}, err
+			}
+			shape, rate = a["df"]/2, 0.5
+		} else if err := positive("alpha", "beta"); err != nil {
+			return probabilityModel{}, err
+		}
+		lg, _ := math.Lgamma(shape)
+		return probabilityModel{
+			lo: 0, hi: inf,
+			pdf: func(x float64) float64 {
+				if x == 0 {
+					switch {
+					case shape < 1:
+						return inf
+					case shape == 1:
+						return rate
+					}
+					return 0
+				}
+				return math.Exp(shape*math.Log(rate) + (shape-1)*math.Log(x) - rate*x - lg)
+			},
+			cdf:         func(x float64) float64 { p, _ := regIncGamma(shape, rate*x); return p },
+			sf:          func(x float64) float64 { _, q := regIncGamma(shape, rate*x); return q },
+			cdfMethod:   "regularized incomplete gamma function",
+			cdfAccuracy: accuracyGamma,
+		}, nil
+
+	case "beta":
+		if err := positive("alpha", "beta"); err != nil {
+			return probabilityModel{}, err
+		}
+		alpha, beta := a["alpha"], a["beta"]
+		lab, _ := math.Lgamma(alpha + beta)
+		la, _ := math.Lgamma(alpha)
+		lb, _ := math.Lgamma(beta)
+		return probabilityModel{
+			lo: 0, hi: 1,
+			pdf: func(x float64) float64 {
+				if (x == 0 && alpha < 1) || (x == 1 && beta < 1) {
+					return inf
+				}
+				return math.Exp(lab - la - lb + (alpha-1)*math.Log(x) + (beta-1)*math.Log1p(-x))
+			},
+			cdf:         func(x float64) float64 { ix, _ := regIncBeta(alpha, beta, x, 1-x); return ix },
+			sf:          func(x float64) float64 { _, c := regIncBeta(alpha, beta, x, 1-x); return c },
+			cdfMethod:   "regularized incomplete beta function",
+			cdfAccuracy: accuracyBeta,
+		}, nil
+
+	case "student-t":
+		if err := positive("df"); err != nil {
+			return probabilityModel{}, err
+		}
+		df := a["df"]
+		lh, _ := math.Lgamma((df + 1) / 2)
+		ld, _ := math.Lgamma(df / 2)
+		// tail returns P(T > |t|) = I_{df/(df+t²)}(df/2, 1/2) / 2.
+		tail := func(t float64) float64 {
+			t2 := t * t
+			ix, _ := regIncBeta(df/2, 0.5, df/(df+t2), t2/(df+t2))
+			return ix / 2
+		}
+		return probabilityModel{
+			lo: -inf, hi: inf,
+			pdf: func(t float64) float64 {
+				return math.Exp(lh-ld-(df+1)/2*math.Log1p(t*t/df)) / math.Sqrt(df*math.Pi)
+			},
+			cdf: func(t float64) float64 {
+				if t > 0 {
+					return 1 - tail(t)
+				}
+				return tail(t)
+			},
+			sf: func(t float64) float64 {
+				if t > 0 {
+					return tail(t)
+				}
+				return 1 - tail(t)
+			},
+			cdfMethod:   "regularized incomplete beta function",
+			cdfAccuracy: accuracyBeta,
+		}, nil
+
+	case "poisson":
+		if err := positive("lambda"); err != nil {
+			return probabilityModel{}, err
+		}
+		lambda := a["lambda"]
+		return probabilityModel{
+			discrete: true, lo: 0, hi: inf,
+			pdf: func(k float64) float64 {
+				lg, _ := math.Lgamma(k + 1)
+				return math.Exp(k*math.Log(lambda) - lambda - lg)
+			},
+			cdf:         func(k float64) float64 { _, q := regIncGamma(k+1, lambda); return q },
+			sf:          func(k float64) float64 { p, _ := regIncGamma(k+1, lambda); return p },
+			cdfMethod:   "regularized incomplete gamma function",
+			cdfAccuracy: accuracyGamma,
+		}, nil
+
+	case "binomial":
+		n, prob := a["n"], a["p"]
+		if n < 0 || n > maxSafeInteger {
+			return probabilityModel{}, errors.New("n must be between 0 and 2^53")
+		}
+		if prob < 0 || prob > 1 {
+			return probabilityModel{}, errors.New("p must be between 0 and 1")
+		}
+		ln, _ := math.Lgamma(n + 1)
+		return probabilityModel{
+			discrete: true, lo: 0, hi: n,
+			pdf: func(k float64) float64 {
+				if prob == 0 || prob == 1 {
+					if k == n*prob {
+						return 1
+					}
+					return 0
+				}
+				lk, _ := math.Lgamma(k + 1)
+				lnk, _ := math.Lgamma(n - k + 1)
+				return math.Exp(ln - lk - lnk + k*math.Log(prob) + (n-k)*math.Log1p(-prob))
+			},
+			// P(X ≤ k) = I_{1-p}(n-k, k+1).
+			cdf: func(k float64) float64 {
+				if k >= n {
+					return 1
+				}
+				ix, _ := regIncBeta(n-k, k+1, 1-prob, prob)
+				return ix
+			},
+			sf: func(k float64) float64 {
+				if k >= n {
+					return 0
+				}
+				_, c := regIncBeta(n-k, k+1, 1-prob, prob)
+				return c
+			},
+			cdfMethod:   "regularized incomplete beta function",
+			cdfAccuracy: accuracyBeta,
+		}, nil
+
+	case "geometric":
+		prob := a["p"]
+		if prob <= 0 || prob > 1 {
+			return probabilityModel{}, errors.New("p must be greater than 0 and at most 1")
+		}
+		logq := math.Log1p(-prob)
+		return closedForm(probabilityModel{
+			discrete: true, lo: 1, hi: inf,
+			pdf: func(k float64) float64 { return prob * math.Exp((k-1)*logq) },
+			cdf: func(k float64) float64 { return -math.Expm1(k * logq) },
+			sf:  func(k float64) float64 { return math.Exp(k * logq) },
+			// The smallest k with 1 - (1-p)^k ≥ q. Rounding can put the
+			// ratio just above a whole number, so k-1 is checked too.
+			quantile: func(q float64) float64 {
+				k := math.Max(1, math.Ceil(math.Log1p(-q)/logq))
+				if k > 1 && -math.Expm1((k-1)*logq) >= q {
+					k--
+				}
+				return k
+			},
+		}), nil
+
+	case "zipf":
+		s, n := a["exponent"], a["n"]
+		if err := positive("exponent"); err != nil {
+			return probabilityModel{}, err
+		}
+		if n < 1 || n > maxZipfRanks {
+			return probabilityModel{}, fmt.Errorf("n must be between 1 and %d", maxZipfRanks)
+		}
+		// harmonic returns the sum of k^-s for k in [from, to].
+		harmonic := func(from, to float64) float64 {
+			var sum, compensation float64
+			for k := to; k >= from; k-- {
+				term := math.Pow(k, -s)
+				t := sum + term
+				if math.Abs(sum) >= term {
+					compensation += (sum - t) + term
+				} else {
+					compensation += (term - t) + sum
+				}
+				sum = t
+			}
+			return sum + compensation
+		}
+		total := harmonic(1, n)
+		return probabilityModel{
+			discrete: true, lo: 1, hi: n,
+			pdf: func(k float64) float64 { return math.Pow(k, -s) / total },
+			cdf: func(k float64) float64 {
+				if k >= n {
+					return 1
+				}
+				return harmonic(1, k) / total
+			},
+			sf:          func(k float64) float64 { return harmonic(k+1, n) / total },
+			cdfMethod:   "direct summation",
+			cdfAccuracy: accuracySum,
+		}, nil
+	}
+	return probabilityModel{}, fmt.Errorf("unknown distribution %q", name)
+}
+
+// density evaluates the pdf or pmf at x, which is zero outside the support
+// and, for discrete distributions, off the integers.
+func (m probabilityModel) density(x float64) float64 {
+	if x < m.lo || x > m.hi || (m.discrete && x != math.Trunc(x)) {
+		return 0
+	}
+	return m.pdf(x)
+}
+
+// cumulative evaluates the cdf and survival function at x, clipped to the
+// support. Discrete distributions are evaluated at floor(x).
+func (m probabilityModel) cumulative(x float64) (cdf, sf float64) {
+	if m.discrete {
+		x = math.Floor(x)
+	}
+	switch {
+	case x < m.lo:
+		return 0, 1
+	case x >= m.hi:
+		return 1, 0
+	}
+	return m.cdf(x), m.sf(x)
+}
+
+// inverse returns the p-th quantile: the smallest x with P(X ≤ x) ≥ p. It
+// is NaN when the cdf does not converge on the way.
+func (m probabilityModel) inverse(p float64) (float64, string, string, error) {
+	switch {
+	case p == 0:
+		return m.lo, "support bound", accuracyClosedForm, nil
+	case p == 1:
+		return m.hi, "support bound", accuracyClosedForm, nil
+	case m.quantile != nil:
+		return m.quantile(p), m.cdfMethod, m.cdfAccuracy, nil
+	case m.discrete:
+		k, err := m.searchQuantile(p)
+		return k, "integer search on the cdf", accuracySearch, err
+	}
+	return m.bisectQuantile(p), "bisection on the cdf", accuracyBisection, nil
+}
+
+// below reports whether the p-th quantile lies above x, consulting the
+// survival function for p above the median where 1 - cdf would cancel. ok
+// is false when the cdf did not converge at x.
+func (m probabilityModel) below(x, p float64) (below, ok bool) {
+	cdf, sf := m.cumulative(x)
+	if math.IsNaN(cdf) || math.IsNaN(sf) {
+		return false, false
+	}
+	if p > 0.5 {
+		return sf > 1-p, true
+	}
+	return cdf < p, true
+}
+
+// bisectQuantile finds the quantile of a continuous distribution, or NaN
+// when the cdf does not converge at one of the points it tries.
+func (m probabilityModel) bisectQuantile(p float64) float64 {
+	failed := false
+	below := func(x float64) bool {
+		below, ok := m.below(x, p)
+		failed = failed || !ok
+		return below && !failed
+	}
+	lo, hi := m.lo, m.hi
+	if math.IsInf(lo, -1) {
+		lo = -1
+		if !math.IsInf(hi, 1) {
+			lo = hi - 1
+		}
+		for step := 1.0; !below(lo) && !failed; step *= 2 {
+			lo -= step
+		}
+	}
+	if math.IsInf(hi, 1) {
+		hi = lo + 1
+		for step := 1.0; below(hi); step *= 2 {
+			hi += step
+		}
+	}
+	for range 5000 {
+		mid := lo + (hi-lo)/2
+		if mid <= lo || mid >= hi || failed {
+			break
+		}
+		if below(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if failed {
+		return math.NaN()
+	}
+	return hi
+}
+
+// searchQuantile finds the quantile of a discrete distribution on the
+// integers. It is NaN when the cdf does not converge at one of the points
+// it tries, and an error when the quantile lies beyond 2^53, where
+// float64 no longer tells neighbouring integers apart.
+func (m probabilityModel) searchQuantile(p float64) (float64, error) {
+	const tolerance = 64 * 0x1p-52
+	failed := false
+	atLeast := func(k float64) bool {
+		cdf, sf := m.cumulative(k)
+		if math.IsNaN(cdf) || math.IsNaN(sf) {
+			failed = true
+			return true
+		}
+		if p > 0.5 {
+			return sf <= (1-p)*(1+tolerance)
+		}
+		return cdf >= p*(1-tolerance)
+	}
+	tooLarge := errors.New("the quantile lies beyond 2^53, where integers can no longer be told apart")
+	lo := m.lo
+	if atLeast(lo) {
+		if failed {
+			return math.NaN(), nil
+		}
+		return lo, nil
+	}
+	hi := lo + 1
+	for step := 1.0; !atLeast(hi); step *= 2 {
+		if hi > maxSafeInteger {
+			return 0, tooLarge
+		}
+		lo = hi
+		hi = math.Min(lo+step, m.hi)
+	}
+	for hi-lo > 1 && !failed {
+		mid := math.Floor(lo + (hi-lo)/2)
+		if mid == lo || mid == hi {
+			return 0, tooLarge
+		}
+		if atLeast(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	if failed {
+		return math.NaN(), nil
+	}
+	return hi, nil
+}
+
+func handleDistribution(ctx context.Context, req *mcp.CallToolRequest, param DistributionParams) (*mcp.CallToolResult, DistributionResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			DistributionResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	params, _ := param.resolve()
+	model, _ := newProbabilityModel(param.Distribution, params)
+	function := param.Function
+	if function == "pdf" && model.discrete {
+		function = "pmf"
+	} else if function == "pmf" && !model.discrete {
+		function = "pdf"
+	}
+
+	result := DistributionResult{
+		Distribution: param.Distribution,
+		Function:     function,
+		Parameters:   params,
+		Method:       model.cdfMethod,
+		Accuracy:     model.cdfAccuracy,
+	}
+	var text string
+	name := formatDistribution(param.Distribution, params)
+	// Validate has checked that the function has the one of x and
+	// probability that it needs.
+	x, _ := floatValue(param.X)
+	switch function {
+	case "pdf", "pmf":
+		result.Value = model.density(x)
+		result.Method, result.Accuracy = "closed form", accuracyClosedForm
+		if function == "pmf" {
+			text = fmt.Sprintf("P(X = %g) = %v for %s", x, result.Value, name)
+		} else {
+			text = fmt.Sprintf("pdf(%g) = %v for %s", x, result.Value, name)
+		}
+	case "cdf":
+		result.Value, _ = model.cumulative(x)
+		text = fmt.Sprintf("P(X ≤ %g) = %v for %s", x, result.Value, name)
+	case "survival":
+		_, result.Value = model.cumulative(x)
+		text = fmt.Sprintf("P(X > %g) = %v for %s", x, result.Value, name)
+	case "quantile":
+		p, _ := floatValue(param.Probability)
+		var err error
+		result.Value, result.Method, result.Accuracy, err = model.inverse(p)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Calculation error: %v for %s", err, name)),
+				DistributionResult{}, fmt.Errorf("calculation error: %v", err)
+		}
+		if math.IsInf(result.Value, 0) {
+			return errorResult(fmt.Sprintf("The quantile at probability %g of %s is infinite", p, name)),
+				DistributionResult{}, fmt.Errorf("quantile at probability %g is infinite", p)
+		}
+		text = fmt.Sprintf("Quantile at probability %g = %v for %s", p, result.Value, name)
+	}
+	if math.IsNaN(result.Value) {
+		return errorResult(fmt.Sprintf("Calculation error: %s did not converge for %s", result.Method, name)),
+			DistributionResult{}, fmt.Errorf("calculation error: %s did not converge", result.Method)
+	}
+	if math.IsInf(result.Value, 0) {
+		return errorResult(fmt.Sprintf("The %s of %s at %g is infinite", function, name, x)),
+			DistributionResult{}, fmt.Errorf("%s is infinite", function)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
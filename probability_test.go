@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProbabilityReferenceValues(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution string
+		params       map[string]float64
+		function     string
+		at           float64
+		want         float64
+	}{
+		{"normal quantile", "normal", map[string]float64{"mean": 0, "stddev": 1}, "quantile", 0.975, 1.959964},
+		{"student-t quantile", "student-t", map[string]float64{"df": 10}, "quantile", 0.975, 2.228139},
+		{"chi-squared quantile", "chi-squared", map[string]float64{"df": 3}, "quantile", 0.95, 7.814728},
+		{"binomial pmf", "binomial", map[string]float64{"n": 10, "p": 0.5}, "pmf", 5, 0.246094},
+		{"geometric quantile", "geometric", map[string]float64{"p": 0.5}, "quantile", 0.9, 4},
+		{"poisson median, large lambda", "poisson", map[string]float64{"lambda": 1e12}, "quantile", 0.5, 1e12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := newProbabilityModel(tt.distribution, tt.params)
+			if err != nil {
+				t.Fatalf("newProbabilityModel: %v", err)
+			}
+			var got float64
+			switch tt.function {
+			case "quantile":
+				if got, _, _, err = model.inverse(tt.at); err != nil {
+					t.Fatalf("inverse: %v", err)
+				}
+			case "pmf":
+				got = model.density(tt.at)
+			}
+			if math.Abs(got-tt.want) > 5e-7 {
+				t.Errorf("%s(%g) = %.7f, want %.6f", tt.function, tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantileBeyondSafeIntegers(t *testing.T) {
+	model, err := newProbabilityModel("poisson", map[string]float64{"lambda": 1e17})
+	if err != nil {
+		t.Fatalf("newProbabilityModel: %v", err)
+	}
+	if got, _, _, err := model.inverse(0.5); err == nil {
+		t.Errorf("inverse(0.5) = %v, want an error", got)
+	}
+}
+
+func TestTriangularModeAtBound(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode, x float64
+		cdf, sf float64
+	}{
+		{"mode at min, x at min", 0, 0, 0, 1},
+		{"mode at min, x at max", 0, 1, 1, 0},
+		{"mode at max, x at min", 1, 0, 0, 1},
+		{"mode at max, x at max", 1, 1, 1, 0},
+		{"mode at min, midpoint", 0, 0.5, 0.75, 0.25},
+		{"mode at max, midpoint", 1, 0.5, 0.25, 0.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := newProbabilityModel("triangular", map[string]float64{"min": 0, "max": 1, "mode": tt.mode})
+			if err != nil {
+				t.Fatalf("newProbabilityModel: %v", err)
+			}
+			check := func(name string, cdf, sf float64) {
+				t.Helper()
+				if math.Abs(cdf-tt.cdf) > 1e-12 || math.Abs(sf-tt.sf) > 1e-12 {
+					t.Errorf("%s(%g) = %v, %v; want %v, %v", name, tt.x, cdf, sf, tt.cdf, tt.sf)
+				}
+			}
+			cdf, sf := model.cumulative(tt.x)
+			check("cumulative", cdf, sf)
+			// cumulative clips x to the support, so call cdf and sf at
+			// the bounds directly as well.
+			check("cdf, sf", model.cdf(tt.x), model.sf(tt.x))
+			if d := model.density(tt.x); math.IsNaN(d) {
+				t.Errorf("density(%g) is NaN", tt.x)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Numerical integration, differentiation and limits for the calculus tool.
+
+const epsilon = 0x1p-52
+
+// Nodes and weights of the 15-point Kronrod rule and the 7-point Gauss rule
+// it extends, as in QUADPACK. gkNodes[7] is the center; the Gauss nodes are
+// the center and gkNodes[1], gkNodes[3] and gkNodes[5].
+var (
+	gkNodes = [8]float64{
+		0.991455371120812639206854697526329, 0.949107912342758524526189684047851,
+		0.864864423359769072789712788640926, 0.741531185599394439863864773280788,
+		0.586087235467691130294144845693013, 0.405845151377397166906606412076961,
+		0.207784955007898467600689403773245, 0,
+	}
+	gkWeights = [8]float64{
+		0.022935322010529224963732008058970, 0.063092092629978553290700663189204,
+		0.104790010322250183839876322541518, 0.140653259715525918745189590510238,
+		0.169004726639267902826583426598550, 0.190350578064785409913256402421014,
+		0.204432940075298892414161999234649, 0.209482141084727828012999174891714,
+	}
+	gaussWeights = [4]float64{
+		0.129484966168869693270611432679082, 0.279705391489276667901467771423780,
+		0.381830050505118944950369775488975, 0.417959183673469387755102040816327,
+	}
+)
+
+// quadratureSegment is a piece of the interval of integration with its
+// integral and error estimates.
+type quadratureSegment struct {
+	a, b, value, err float64
+}
+
+// segmentHeap orders segments by decreasing error estimate.
+type segmentHeap []quadratureSegment
+
+func (h segmentHeap) Len() int            { return len(h) }
+func (h segmentHeap) Less(i, j int) bool  { return h[i].err > h[j].err }
+func (h segmentHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *segmentHeap) Push(x interface{}) { *h = append(*h, x.(quadratureSegment)) }
+func (h *segmentHeap) Pop() interface{} {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// gaussKronrod applies the 15-point Kronrod rule to f on [a, b]. The error
+// estimate scales the difference from the embedded Gauss rule as QUADPACK
+// does, which is pessimistic for smooth integrands and realistic otherwise.
+func gaussKronrod(f func(float64) float64, a, b float64) quadratureSegment {
+	center, half := (a+b)/2, (b-a)/2
+	fc := f(center)
+	gauss := fc * gaussWeights[3]
+	kronrod := fc * gkWeights[7]
+	abs := math.Abs(kronrod)
+	var lower, upper [7]float64
+	for j := range 7 {
+		x := half * gkNodes[j]
+		lower[j], upper[j] = f(center-x), f(center+x)
+		sum := lower[j] + upper[j]
+		kronrod += gkWeights[j] * sum
+		abs += gkWeights[j] * (math.Abs(lower[j]) + math.Abs(upper[j]))
+		if j%2 == 1 {
+			gauss += gaussWeights[j/2] * sum
+		}
+	}
+	mean := kronrod / 2
+	asc := gkWeights[7] * math.Abs(fc-mean)
+	for j := range 7 {
+		asc += gkWeights[j] * (math.Abs(lower[j]-mean) + math.Abs(upper[j]-mean))
+	}
+	width := math.Abs(half)
+	abs *= width
+	asc *= width
+	err := math.Abs((kronrod - gauss) * half)
+	if asc != 0 && err != 0 {
+		err = asc * math.Min(1, math.Pow(200*err/asc, 1.5))
+	}
+	if abs > math.SmallestNonzeroFloat64/(50*epsilon) {
+		err = math.Max(50*epsilon*abs, err)
+	}
+	return quadratureSegment{a: a, b: b, value: kronrod * half, err: err}
+}
+
+// integration is the outcome of a numerical integration.
+type integration struct {
+	value, err float64
+	segments   int
+	// converged is false when the tolerance was not reached; worst is then
+	// the middle of the segment with the largest error.
+	converged bool
+	worst     float64
+}
+
+// integrateGaussKronrod integrates f over [a, b] by globally adaptive
+// Gauss–Kronrod quadrature: the segment with the largest error estimate is
+// bisected until the total error is within tol (absolute or relative), the
+// evaluation budget is spent or the segments cannot be split further.
+func integrateGaussKronrod(f func(float64) float64, a, b, tol float64, maxEvaluations int) integration {
+	first := gaussKronrod(f, a, b)
+	segments := &segmentHeap{first}
+	value, err := first.value, first.err
+	evaluations := 15
+	for err > math.Max(tol, tol*math.Abs(value)) && evaluations+30 <= maxEvaluations {
+		s := heap.Pop(segments).(quadratureSegment)
+		m := (s.a + s.b) / 2
+		if math.Abs(s.b-s.a) <= 100*epsilon*math.Abs(m) || m == s.a || m == s.b {
+			heap.Push(segments, s)
+			break
+		}
+		left, right := gaussKronrod(f, s.a, m), gaussKronrod(f, m, s.b)
+		evaluations += 30
+		value += left.value + right.value - s.value
+		err += left.err + right.err - s.err
+		heap.Push(segments, left)
+		heap.Push(segments, right)
+	}
+	// Sum again to drop the rounding of the running totals.
+	value, err = 0, 0
+	for _, s := range *segments {
+		value += s.value
+		err += s.err
+	}
+	worst := (*segments)[0]
+	return integration{
+		value:     value,
+		err:       err,
+		segments:  segments.Len(),
+		converged: err <= math.Max(tol, tol*math.Abs(value)),
+		worst:     (worst.a + worst.b) / 2,
+	}
+}
+
+// maxSimpsonDepth bounds the recursion of adaptive Simpson integration.
+const maxSimpsonDepth = 50
+
+// integrateSimpson integrates f over [a, b] by adaptive Simpson's rule with
+// Richardson extrapolation, splitting the tolerance between the halves.
+// Unlike Gauss–Kronrod quadrature it evaluates f at a and b.
+func integrateSimpson(f func(float64) float64, a, b, tol float64, maxEvaluations int) integration {
+	result := integration{converged: true}
+	evaluations := 3
+	largest := 0.0
+	var step func(a, fa, m, fm, b, fb, whole, tol float64, depth int) float64
+	step = func(a, fa, m, fm, b, fb, whole, tol float64, depth int) float64 {
+		lm, rm := (a+m)/2, (m+b)/2
+		flm, frm := f(lm), f(rm)
+		evaluations += 2
+		left := (m - a) / 6 * (fa + 4*flm + fm)
+		right := (b - m) / 6 * (fm + 4*frm + fb)
+		delta := left + right - whole
+		if math.Abs(delta) <= 15*tol || depth >= maxSimpsonDepth || evaluations+2 > maxEvaluations || math.IsNaN(delta) {
+			e := math.Abs(delta) / 15
+			if e > tol {
+				result.converged = false
+				if e > largest {
+					largest, result.worst = e, m
+				}
+			}
+			result.err += e
+			result.segments++
+			return left + right + delta/15
+		}
+		return step(a, fa, lm, flm, m, fm, left, tol/2, depth+1) +
+			step(m, fm, rm, frm, b, fb, right, tol/2, depth+1)
+	}
+	fa, fb, m := f(a), f(b), (a+b)/2
+	fm := f(m)
+	whole := (b - a) / 6 * (fa + 4*fm + fb)
+	result.value = step(a, fa, m, fm, b, fb, whole, tol, 0)
+	if result.err <= math.Max(tol, tol*math.Abs(result.value)) {
+		result.converged = true
+	}
+	return result
+}
+
+// infiniteSubstitution maps an integral over a range with infinite ends to
+// one over a finite range: x = a + t/(1 - t) on [0, 1) for [a, ∞),
+// x = b - (1 - t)/t on (0, 1] for (-∞, b], and x = t/(1 - t²) on (-1, 1)
+// for the whole line. It returns the new integrand and range.
+func infiniteSubstitution(f func(float64) float64, a, b float64) (func(float64) float64, float64, float64) {
+	switch {
+	case math.IsInf(a, -1) && math.IsInf(b, 1):
+		return func(t float64) float64 {
+			d := 1 - t*t
+			return f(t/d) * (1 + t*t) / (d * d)
+		}, -1, 1
+	case math.IsInf(b, 1):
+		return func(t float64) float64 {
+			d := 1 - t
+			return f(a+t/d) / (d * d)
+		}, 0, 1
+	case math.IsInf(a, -1):
+		return func(t float64) float64 {
+			return f(b-(1-t)/t) / (t * t)
+		}, 0, 1
+	}
+	return f, a, b
+}
+
+// extrapolate estimates the limit of g(h) as h goes to 0 from g(h0),
+// g(h0/ratio), g(h0/ratio²), ... by Neville's algorithm, assuming g has an
+// expansion in powers of h^power. As in Ridders' method, it stops once the
+// error of the table starts to grow, since smaller h then only adds
+// rounding error. Estimates from fewer than minSteps samples are not
+// trusted. It returns the best estimate, its error estimate and the samples
+// g(h) used, in order.
+func extrapolate(g func(h float64) float64, h0, ratio float64, power, minSteps, steps int) (float64, float64, []float64) {
+	const safe = 2
+	factor := math.Pow(ratio, float64(power))
+	table := make([][]float64, steps)
+	h := h0
+	table[0] = []float64{g(h)}
+	samples := []float64{table[0][0]}
+	best, err := table[0][0], math.Inf(1)
+	for i := 1; i < steps; i++ {
+		h /= ratio
+		table[i] = make([]float64, i+1)
+		table[i][0] = g(h)
+		samples = append(samples, table[i][0])
+		fac := factor
+		for j := 1; j <= i; j++ {
+			table[i][j] = (table[i][j-1]*fac - table[i-1][j-1]) / (fac - 1)
+			fac *= factor
+			e := math.Max(math.Abs(table[i][j]-table[i][j-1]), math.Abs(table[i][j]-table[i-1][j-1]))
+			if e <= err && i+1 >= minSteps {
+				best, err = table[i][j], e
+			}
+		}
+		if i+1 >= minSteps && math.Abs(table[i][i]-table[i-1][i-1]) >= safe*err {
+			break
+		}
+	}
+	return best, err, samples
+}
+
+// centralDifference returns the order-n central difference of f at x with
+// step h, whose error has an expansion in even powers of h.
+func centralDifference(f func(float64) float64, x, h float64, n int) float64 {
+	sum, binomial := 0.0, 1.0
+	for k := 0; k <= n; k++ {
+		term := binomial * f(x+(float64(n)/2-float64(k))*h)
+		if k%2 == 1 {
+			term = -term
+		}
+		sum += term
+		binomial = binomial * float64(n-k) / float64(k+1)
+	}
+	return sum / math.Pow(h, float64(n))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// rngStream is a seeded random source owned by one client session.
+type rngStream struct {
+	mu    sync.Mutex
+	rand  *rand.Rand
+	seed  int64
+	calls int
+}
+
+// sessionRNGs hands out one rngStream per session so that draws made by one
+// client never affect the sequence seen by another on the shared server.
+type sessionRNGs struct {
+	mu      sync.Mutex
+	streams map[*mcp.ServerSession]*rngStream
+	// shared serves requests that carry no session.
+	shared *rngStream
+}
+
+var randomStreams = &sessionRNGs{
+	streams: make(map[*mcp.ServerSession]*rngStream),
+	shared:  newRNGStream(randomSeed()),
+}
+
+func newRNGStream(seed int64) *rngStream {
+	return &rngStream{rand: rand.New(rand.NewSource(seed)), seed: seed}
+}
+
+// randomSeed picks a seed for a new stream. Seeds stay below 2^53 so they
+// survive a round trip through JSON clients that decode numbers as float64.
+func randomSeed() int64 {
+	return rand.Int63n(1 << 53)
+}
+
+// stream returns the RNG stream of session, creating a randomly seeded one on
+// first use. Streams are dropped when their session ends.
+func (s *sessionRNGs) stream(session *mcp.ServerSession) *rngStream {
+	if session == nil {
+		return s.shared
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.streams[session]; ok {
+		return st
+	}
+	st := newRNGStream(randomSeed())
+	s.streams[session] = st
+	go func() {
+		_ = session.Wait()
+		s.mu.Lock()
+		delete(s.streams, session)
+		s.mu.Unlock()
+	}()
+	return st
+}
+
+// draw runs fn with the stream's generator. A non-nil seed reseeds the stream
+// first, so a client can replay a sequence of calls by passing the same seed
+// to the first one. draw returns the seed of the stream and the 1-based
+// number of calls made since it was seeded.
+func (st *rngStream) draw(seed *int64, fn func(r *rand.Rand)) (int64, int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if seed != nil {
+		st.rand.Seed(*seed)
+		st.seed = *seed
+		st.calls = 0
+	}
+	st.calls++
+	fn(st.rand)
+	return st.seed, st.calls
+}
+
+// cryptoSource is a rand.Source64 reading from crypto/rand. It cannot be
+// seeded; it lets the distribution samplers run on secure bits.
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		panic(err) // crypto/rand.Read never fails on supported platforms
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+func (s cryptoSource) Int63() int64 { return int64(s.Uint64() >> 1) }
+
+func (cryptoSource) Seed(int64) {}
+
+var secureRand = rand.New(cryptoSource{})
+
+// secureUniform returns a uniform integer in [min, max] from crypto/rand.
+// crypto/rand.Int rejects out-of-range samples instead of reducing them
+// modulo the span, so every value is equally likely.
+func secureUniform(min, max int) (int, error) {
+	span := new(big.Int).Sub(big.NewInt(int64(max)), big.NewInt(int64(min)))
+	span.Add(span, big.NewInt(1))
+	k, err := cryptorand.Int(cryptorand.Reader, span)
+	if err != nil {
+		return 0, err
+	}
+	return int(k.Add(k, big.NewInt(int64(min))).Int64()), nil
+}
+
+// defaultMaxRandomCount is the largest batch generate-random-number returns
+// unless RANDOM_MAX_COUNT overrides it.
+const defaultMaxRandomCount = 10_000
+
+var maxRandomCount = defaultMaxRandomCount
+
+// randomSource draws either from a session stream or from crypto/rand.
+type randomSource struct {
+	// uniform returns a uniform integer in [lo, hi].
+	uniform func(lo, hi int) (int, error)
+	// rand drives everything else.
+	rand *rand.Rand
+}
+
+func streamSource(r *rand.Rand) randomSource {
+	return randomSource{
+		uniform: func(lo, hi int) (int, error) { return r.Intn(hi-lo+1) + lo, nil },
+		rand:    r,
+	}
+}
+
+var secureSource = randomSource{uniform: secureUniform, rand: secureRand}
+
+// randomDraw runs fn on crypto/rand when secure is set and on the stream of
+// session otherwise, reseeding it first if seed is given. It returns the
+// stream's seed and call number, which are nil and 0 in secure mode, and the
+// source as described in text content.
+func randomDraw(session *mcp.ServerSession, seed *int64, secure bool, fn func(src randomSource) error) (*int64, int, string, error) {
+	if secure {
+		return nil, 0, "secure", fn(secureSource)
+	}
+	var err error
+	used, sequence := randomStreams.stream(session).draw(seed, func(r *rand.Rand) {
+		err = fn(streamSource(r))
+	})
+	return &used, sequence, fmt.Sprintf("seed: %d", used), err
+}
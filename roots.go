@@ -0,0 +1,554 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"slices"
+	"strings"
+)
+
+// Roots of polynomials with rational coefficients for the solve tool. A
+// polynomial is split into square-free factors, whose roots are simple;
+// rational roots are taken out of each factor exactly, and what remains is
+// solved by radicals up to degree four: the quadratic formula, Cardano's
+// formula or its trigonometric form for cubics, and Ferrari's method for
+// quartics. Factors of higher degree are solved numerically.
+
+const (
+	// maxSolveDegree bounds the degree of polynomial equations.
+	maxSolveDegree = 100
+	// maxDurandKernerIterations bounds the simultaneous iteration used for
+	// factors of degree five and more.
+	maxDurandKernerIterations = 2000
+)
+
+// ratPoly is a polynomial with rational coefficients, lowest degree first.
+// Leading zero coefficients are trimmed, so the zero polynomial is empty.
+type ratPoly []*big.Rat
+
+func (p ratPoly) degree() int {
+	return len(p) - 1
+}
+
+func trimPoly(p ratPoly) ratPoly {
+	for len(p) > 0 && p[len(p)-1].Sign() == 0 {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+func (p ratPoly) derivative() ratPoly {
+	if len(p) <= 1 {
+		return nil
+	}
+	d := make(ratPoly, len(p)-1)
+	for i := 1; i < len(p); i++ {
+		d[i-1] = new(big.Rat).Mul(p[i], big.NewRat(int64(i), 1))
+	}
+	return trimPoly(d)
+}
+
+func (p ratPoly) sub(q ratPoly) ratPoly {
+	r := make(ratPoly, max(len(p), len(q)))
+	for i := range r {
+		r[i] = new(big.Rat)
+		if i < len(p) {
+			r[i].Add(r[i], p[i])
+		}
+		if i < len(q) {
+			r[i].Sub(r[i], q[i])
+		}
+	}
+	return trimPoly(r)
+}
+
+// divMod returns the quotient and remainder of p divided by d, which must
+// not be zero.
+func (p ratPoly) divMod(d ratPoly) (ratPoly, ratPoly) {
+	r := slices.Clone(p)
+	if len(r) < len(d) {
+		return nil, r
+	}
+	q := make(ratPoly, len(r)-len(d)+1)
+	lead := d[len(d)-1]
+	for i := len(q) - 1; i >= 0; i-- {
+		c := new(big.Rat).Quo(r[i+len(d)-1], lead)
+		q[i] = c
+		for j, dj := range d {
+			r[i+j] = new(big.Rat).Sub(r[i+j], new(big.Rat).Mul(c, dj))
+		}
+	}
+	return trimPoly(q), trimPoly(r[:len(d)-1])
+}
+
+// monic returns p divided by its leading coefficient.
+func (p ratPoly) monic() ratPoly {
+	m := make(ratPoly, len(p))
+	for i, c := range p {
+		m[i] = new(big.Rat).Quo(c, p[len(p)-1])
+	}
+	return m
+}
+
+// integral returns p scaled to integer coefficients.
+func (p ratPoly) integral() ratPoly {
+	l := big.NewInt(1)
+	for _, c := range p {
+		l.Mul(l, new(big.Int).Quo(c.Denom(), new(big.Int).GCD(nil, nil, l, c.Denom())))
+	}
+	s := make(ratPoly, len(p))
+	for i, c := range p {
+		s[i] = new(big.Rat).Mul(c, new(big.Rat).SetInt(l))
+	}
+	return s
+}
+
+func polyGCD(a, b ratPoly) ratPoly {
+	for len(b) > 0 {
+		_, r := a.divMod(b)
+		a, b = b, r
+	}
+	return a.monic()
+}
+
+// squareFreeFactor is a factor of a polynomial with simple roots, and the
+// power to which it divides the polynomial.
+type squareFreeFactor struct {
+	poly         ratPoly
+	multiplicity int
+}
+
+// squareFree splits p into square-free factors by Yun's algorithm, so that
+// p is a constant times the product of each factor to its multiplicity.
+func squareFree(p ratPoly) []squareFreeFactor {
+	if p.degree() < 1 {
+		return nil
+	}
+	dp := p.derivative()
+	g := polyGCD(p, dp)
+	b, _ := p.divMod(g)
+	c, _ := dp.divMod(g)
+	d := c.sub(b.derivative())
+	var factors []squareFreeFactor
+	for i := 1; b.degree() > 0; i++ {
+		a := polyGCD(b, d)
+		if a.degree() > 0 {
+			factors = append(factors, squareFreeFactor{a, i})
+		}
+		b, _ = b.divMod(a)
+		c, _ = d.divMod(a)
+		d = c.sub(b.derivative())
+	}
+	return factors
+}
+
+// polyRoot is a root re + im·i of a polynomial. re and im are exact when
+// known; value always holds the numeric root.
+type polyRoot struct {
+	re, im       *sym
+	value        complex128
+	multiplicity int
+	method       string
+}
+
+// exact reports whether the root is known exactly.
+func (r polyRoot) exact() bool {
+	return r.re != nil
+}
+
+// polynomialRoots returns the roots of p, each once with its multiplicity,
+// real roots first in increasing order and then complex roots by real and
+// imaginary part.
+func polynomialRoots(p ratPoly) ([]polyRoot, error) {
+	var roots []polyRoot
+	for _, f := range squareFree(p) {
+		rs, err := squareFreeRoots(f.poly)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rs {
+			rs[i].multiplicity = f.multiplicity
+		}
+		roots = append(roots, rs...)
+	}
+	slices.SortFunc(roots, func(a, b polyRoot) int {
+		ra, rb := imag(a.value) == 0, imag(b.value) == 0
+		if ra != rb {
+			if ra {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(real(a.value), real(b.value)); c != 0 {
+			return c
+		}
+		return cmp.Compare(imag(a.value), imag(b.value))
+	})
+	return roots, nil
+}
+
+// squareFreeRoots returns the roots of a polynomial with simple roots.
+func squareFreeRoots(p ratPoly) ([]polyRoot, error) {
+	var roots []polyRoot
+	for p.degree() > 0 {
+		num, den, ok := rationalRoot(p.integral())
+		if !ok {
+			break
+		}
+		r := new(big.Rat).SetFrac(num, den)
+		v, _ := r.Float64()
+		roots = append(roots, polyRoot{re: symRat(r), im: symInt(0), value: complex(v, 0), method: "rational root"})
+		p = ratPoly(deflate(p, r))
+	}
+
+	var rest []polyRoot
+	switch p.degree() {
+	case 0:
+	case 1:
+		r := new(big.Rat).Neg(new(big.Rat).Quo(p[0], p[1]))
+		rest = []polyRoot{{re: symRat(r), im: symInt(0), method: "linear"}}
+	case 2:
+		rest = quadraticRoots(p)
+	case 3:
+		rest = cubicRoots(p)
+	case 4:
+		rest = quarticRoots(p)
+	default:
+		values, err := durandKerner(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range values {
+			rest = append(rest, polyRoot{value: z, method: "Durand-Kerner iteration"})
+		}
+	}
+	for _, r := range rest {
+		if r.exact() {
+			re, _ := r.re.float()
+			im, _ := r.im.float()
+			r.value = complex(re, im)
+		}
+		r.value = polishRoot(p, r.value)
+		roots = append(roots, r)
+	}
+	return roots, nil
+}
+
+// symSqrt returns the square root of s.
+func symSqrt(s *sym) *sym {
+	return symPow(s, symRat(big.NewRat(1, 2)))
+}
+
+// symCbrt returns the real cube root of s, written as the negated root of
+// -s when s is negative so that the result evaluates with ^.
+func symCbrt(s *sym) *sym {
+	if v, ok := s.float(); ok && v < 0 {
+		return symMul(symInt(-1), symPow(symMul(symInt(-1), s), symRat(big.NewRat(1, 3))))
+	}
+	return symPow(s, symRat(big.NewRat(1, 3)))
+}
+
+// ratMul returns the product of rationals.
+func ratMul(xs ...*big.Rat) *big.Rat {
+	r := big.NewRat(1, 1)
+	for _, x := range xs {
+		r.Mul(r, x)
+	}
+	return r
+}
+
+// quadraticRoots solves p = a·x² + b·x + c, which has no rational roots, by
+// the quadratic formula.
+func quadraticRoots(p ratPoly) []polyRoot {
+	a, b, c := p[2], p[1], p[0]
+	disc := new(big.Rat).Sub(ratMul(b, b), ratMul(big.NewRat(4, 1), a, c))
+	re := symRat(new(big.Rat).Quo(new(big.Rat).Neg(b), ratMul(big.NewRat(2, 1), a)))
+	half := new(big.Rat).Inv(ratMul(big.NewRat(2, 1), new(big.Rat).Abs(a)))
+	if disc.Sign() > 0 {
+		r := symMul(symRat(half), symSqrt(symRat(disc)))
+		return []polyRoot{
+			{re: symAdd(re, symMul(symInt(-1), r)), im: symInt(0), method: "quadratic formula"},
+			{re: symAdd(re, r), im: symInt(0), method: "quadratic formula"},
+		}
+	}
+	im := symMul(symRat(half), symSqrt(symRat(new(big.Rat).Neg(disc))))
+	return []polyRoot{
+		{re: re, im: symMul(symInt(-1), im), method: "quadratic formula"},
+		{re: re, im: im, method: "quadratic formula"},
+	}
+}
+
+// depressedCubic returns p and q of t³ + p·t + q, where x = t + shift, for
+// the cubic a·x³ + b·x² + c·x + d.
+func depressedCubic(poly ratPoly) (p, q, shift *big.Rat) {
+	m := poly.monic()
+	b, c, d := m[2], m[1], m[0]
+	p = new(big.Rat).Sub(c, ratMul(b, b, big.NewRat(1, 3)))
+	q = new(big.Rat).Add(ratMul(big.NewRat(2, 27), b, b, b), new(big.Rat).Sub(d, ratMul(b, c, big.NewRat(1, 3))))
+	shift = ratMul(b, big.NewRat(-1, 3))
+	return p, q, shift
+}
+
+// cubicRoots solves a cubic with simple, irrational roots: by Cardano's
+// formula when it has one real root, and by the trigonometric form when it
+// has three, where Cardano's formula would need complex cube roots.
+func cubicRoots(poly ratPoly) []polyRoot {
+	p, q, shift := depressedCubic(poly)
+	// Δ = (q/2)² + (p/3)³ is positive for one real root and negative for
+	// three; it is never zero for simple roots.
+	halfQ := ratMul(q, big.NewRat(1, 2))
+	third := ratMul(p, big.NewRat(1, 3))
+	delta := new(big.Rat).Add(ratMul(halfQ, halfQ), ratMul(third, third, third))
+	s := symRat(shift)
+
+	if delta.Sign() > 0 {
+		sq := symSqrt(symRat(delta))
+		minusHalfQ := symRat(new(big.Rat).Neg(halfQ))
+		u := symCbrt(symAdd(minusHalfQ, sq))
+		v := symCbrt(symAdd(minusHalfQ, symMul(symInt(-1), sq)))
+		// The complex roots are -(u + v)/2 ± (u - v)·√3/2·i.
+		re := symAdd(symMul(symRat(big.NewRat(-1, 2)), symAdd(u, v)), s)
+		im := symMul(symRat(big.NewRat(1, 2)), symSqrt(symInt(3)), symAdd(u, symMul(symInt(-1), v)))
+		return []polyRoot{
+			{re: symAdd(u, v, s), im: symInt(0), method: "Cardano's formula"},
+			{re: re, im: symMul(symInt(-1), im), method: "Cardano's formula"},
+			{re: re, im: im, method: "Cardano's formula"},
+		}
+	}
+
+	// t_k = 2·√(-p/3)·cos(acos(3q/(2p)·√(-3/p))/3 - 2πk/3)
+	r := symMul(symInt(2), symSqrt(symRat(new(big.Rat).Neg(third))))
+	arg := symMul(symRat(new(big.Rat).Quo(ratMul(big.NewRat(3, 2), q), p)),
+		symSqrt(symRat(new(big.Rat).Quo(big.NewRat(-3, 1), p))))
+	angle := symMul(symRat(big.NewRat(1, 3)), symCall("acos", arg))
+	var roots []polyRoot
+	for k := int64(0); k < 3; k++ {
+		t := symMul(r, symCall("cos", symAdd(angle, symMul(symRat(big.NewRat(-2*k, 3)), symVar("pi")))))
+		roots = append(roots, polyRoot{re: symAdd(t, s), im: symInt(0), method: "trigonometric cubic formula"})
+	}
+	return roots
+}
+
+// quarticRoots solves a quartic with simple roots and no rational ones by
+// Ferrari's method. The depressed quartic y⁴ + p·y² + q·y + r is written as
+// the product of two quadratics y² ∓ s·y + p/2 + m ± q/(2s), with s = √(2m)
+// for a positive root m of the resolvent cubic
+// 8m³ + 8p·m² + (2p² - 8r)·m - q².
+func quarticRoots(poly ratPoly) []polyRoot {
+	mon := poly.monic()
+	b, c, d, e := mon[3], mon[2], mon[1], mon[0]
+	p := new(big.Rat).Sub(c, ratMul(big.NewRat(3, 8), b, b))
+	q := new(big.Rat).Add(d, new(big.Rat).Sub(ratMul(big.NewRat(1, 8), b, b, b), ratMul(big.NewRat(1, 2), b, c)))
+	r := new(big.Rat).Add(e, ratMul(big.NewRat(-1, 4), b, d))
+	r.Add(r, ratMul(big.NewRat(1, 16), b, b, c))
+	r.Add(r, ratMul(big.NewRat(-3, 256), b, b, b, b))
+	shift := symRat(ratMul(b, big.NewRat(-1, 4)))
+	method := "Ferrari's method"
+
+	var m *sym
+	if q.Sign() == 0 {
+		// A biquadratic y⁴ + p·y² + r: y² = z for the roots z of
+		// z² + p·z + r, when they are real.
+		disc := new(big.Rat).Sub(ratMul(p, p), ratMul(big.NewRat(4, 1), r))
+		if disc.Sign() > 0 {
+			var roots []polyRoot
+			for _, z := range quadraticRoots(ratPoly{r, p, big.NewRat(1, 1)}) {
+				zv, _ := z.re.float()
+				for _, sign := range []int64{-1, 1} {
+					if zv > 0 {
+						y := symMul(symInt(sign), symSqrt(z.re))
+						roots = append(roots, polyRoot{re: symAdd(y, shift), im: symInt(0), method: "biquadratic formula"})
+					} else {
+						y := symMul(symInt(sign), symSqrt(symMul(symInt(-1), z.re)))
+						roots = append(roots, polyRoot{re: shift, im: y, method: "biquadratic formula"})
+					}
+				}
+			}
+			return roots
+		}
+		// Otherwise r > p²/4, and m = √r - p/2 is positive.
+		m = symAdd(symSqrt(symRat(r)), symRat(ratMul(p, big.NewRat(-1, 2))))
+	} else {
+		// The resolvent is negative at 0 and grows without bound, so its
+		// greatest real root is positive.
+		resolvent := ratPoly{
+			new(big.Rat).Neg(ratMul(q, q)),
+			new(big.Rat).Sub(ratMul(big.NewRat(2, 1), p, p), ratMul(big.NewRat(8, 1), r)),
+			ratMul(big.NewRat(8, 1), p),
+			big.NewRat(8, 1),
+		}
+		best := math.Inf(-1)
+		for _, f := range squareFree(resolvent) {
+			rs, _ := squareFreeRoots(f.poly)
+			for _, root := range rs {
+				if imag(root.value) == 0 && real(root.value) > best && root.exact() {
+					best, m = real(root.value), root.re
+				}
+			}
+		}
+	}
+
+	s := symSqrt(symMul(symInt(2), m))
+	var roots []polyRoot
+	for _, sigma := range []int64{1, -1} {
+		// y = (σs ± √Δ)/2 with Δ = -2m - 2p - 2σq/s.
+		delta := symAdd(symMul(symInt(-2), m), symRat(ratMul(big.NewRat(-2, 1), p)),
+			symMul(symRat(ratMul(big.NewRat(-2*sigma, 1), q)), symPow(s, symInt(-1))))
+		center := symMul(symRat(big.NewRat(sigma, 2)), s)
+		dv, _ := delta.float()
+		if dv > 0 {
+			half := symMul(symRat(big.NewRat(1, 2)), symSqrt(delta))
+			roots = append(roots,
+				polyRoot{re: symAdd(center, symMul(symInt(-1), half), shift), im: symInt(0), method: method},
+				polyRoot{re: symAdd(center, half, shift), im: symInt(0), method: method})
+		} else {
+			half := symMul(symRat(big.NewRat(1, 2)), symSqrt(symMul(symInt(-1), delta)))
+			roots = append(roots,
+				polyRoot{re: symAdd(center, shift), im: symMul(symInt(-1), half), method: method},
+				polyRoot{re: symAdd(center, shift), im: half, method: method})
+		}
+	}
+	return roots
+}
+
+// polyFloat returns the coefficients of p as floats.
+func polyFloat(p ratPoly) []complex128 {
+	c := make([]complex128, len(p))
+	for i, r := range p {
+		v, _ := r.Float64()
+		c[i] = complex(v, 0)
+	}
+	return c
+}
+
+// hornerComplex evaluates c (lowest degree first) and its derivative at z.
+func hornerComplex(c []complex128, z complex128) (complex128, complex128) {
+	var v, d complex128
+	for i := len(c) - 1; i >= 0; i-- {
+		d = d*z + v
+		v = v*z + c[i]
+	}
+	return v, d
+}
+
+// polishRoot refines an approximate simple root z of p with a few Newton
+// steps, keeping z when a step does not reduce the residual. Real roots
+// stay real.
+func polishRoot(p ratPoly, z complex128) complex128 {
+	c := polyFloat(p)
+	v, _ := hornerComplex(c, z)
+	for range 3 {
+		_, d := hornerComplex(c, z)
+		if d == 0 {
+			break
+		}
+		next := z - v/d
+		if imag(z) == 0 {
+			next = complex(real(next), 0)
+		}
+		nv, _ := hornerComplex(c, next)
+		if cmplx.Abs(nv) >= cmplx.Abs(v) {
+			break
+		}
+		z, v = next, nv
+	}
+	return z
+}
+
+// durandKerner finds all roots of p, which must have simple roots, by the
+// Durand–Kerner (Weierstrass) simultaneous iteration. Roots whose
+// imaginary part is below the attainable accuracy are made real.
+func durandKerner(p ratPoly) ([]complex128, error) {
+	c := polyFloat(p.monic())
+	n := p.degree()
+	bound := 0.0
+	for _, ci := range c[:n] {
+		bound = max(bound, cmplx.Abs(ci))
+	}
+	bound++
+	z := make([]complex128, n)
+	for k := range z {
+		z[k] = cmplx.Rect(bound, 2*math.Pi*float64(k)/float64(n)+0.4)
+	}
+	for iteration := 1; iteration <= maxDurandKernerIterations; iteration++ {
+		change := 0.0
+		for k := range z {
+			v, _ := hornerComplex(c, z[k])
+			den := complex(1, 0)
+			for j := range z {
+				if j != k {
+					den *= z[k] - z[j]
+				}
+			}
+			if den == 0 {
+				den = complex(1e-300, 0)
+			}
+			step := v / den
+			z[k] -= step
+			change = max(change, cmplx.Abs(step)/max(1, cmplx.Abs(z[k])))
+		}
+		if math.IsNaN(change) {
+			break
+		}
+		if change < 1e-15 {
+			for k := range z {
+				if math.Abs(imag(z[k])) < 1e-12*max(1, cmplx.Abs(z[k])) {
+					z[k] = complex(real(z[k]), 0)
+				}
+			}
+			return z, nil
+		}
+		if iteration == maxDurandKernerIterations {
+			return nil, fmt.Errorf("the Durand-Kerner iteration for the factor of degree %d did not converge in %d iterations (last relative change %.3g)", n, maxDurandKernerIterations, change)
+		}
+	}
+	return nil, fmt.Errorf("the Durand-Kerner iteration for the factor of degree %d broke down with a non-finite value", n)
+}
+
+// complexString formats re + im·i in the expression syntax; latex selects
+// LaTeX.
+func complexString(re, im *sym, latex bool) string {
+	format := func(s *sym) string {
+		if latex {
+			return s.latex()
+		}
+		return s.String()
+	}
+	if im.isInt(0) {
+		return format(re)
+	}
+	negative := false
+	if v, ok := im.float(); ok && v < 0 {
+		negative = true
+		im = symMul(symInt(-1), im)
+	}
+	var imText string
+	switch {
+	case im.isInt(1):
+		imText = "i"
+	case latex && im.kind == symSum:
+		imText = `\left(` + format(im) + `\right) i`
+	case latex:
+		imText = format(im) + " i"
+	case im.kind == symSum:
+		imText = "(" + format(im) + ")*i"
+	default:
+		imText = format(im) + "*i"
+	}
+	var b strings.Builder
+	if !re.isInt(0) {
+		b.WriteString(format(re))
+		if negative {
+			b.WriteString(" - ")
+		} else {
+			b.WriteString(" + ")
+		}
+	} else if negative {
+		b.WriteString("-")
+	}
+	b.WriteString(imText)
+	return b.String()
+}
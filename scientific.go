@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxFactorial is the largest n whose factorial fits in a float64.
+const maxFactorial = 170
+
+var scientificFunctions = []interface{}{
+	"sin", "cos", "tan", "asin", "acos", "atan",
+	"sinh", "cosh", "tanh", "asinh", "acosh", "atanh",
+	"log", "ln", "log2", "log10", "exp",
+	"pow", "root", "sqrt", "abs", "floor", "ceil", "round",
+	"factorial", "gamma",
+}
+
+// ScientificParams defines the parameters for the scientific tool.
+type ScientificParams struct {
+	Function  string      `json:"function" jsonschema:"function to apply: sin, cos, tan, asin, acos, atan, sinh, cosh, tanh, asinh, acosh, atanh, log, ln, log2, log10, exp, pow, root, sqrt, abs, floor, ceil, round, factorial or gamma"`
+	X         interface{} `json:"x" jsonschema:"argument of the function, as a number or a string such as \"1/3\" or \"1e-9\""`
+	Y         interface{} `json:"y,omitempty" jsonschema:"second argument: the exponent for pow, the degree for root (default: 2) and the base for log (default: 10)"`
+	AngleUnit string      `json:"angle_unit,omitempty" jsonschema:"unit of angles for trigonometric functions: 'radians' (default) or 'degrees'"`
+}
+
+func (p ScientificParams) Validate() error {
+	if err := checkNumbers(map[string]interface{}{"x": p.X, "y": p.Y}); err != nil {
+		return err
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Function,
+			validation.Required,
+			validation.In(scientificFunctions...),
+		),
+		validation.Field(&p.X, validation.NotNil.Error("is required"), validation.By(func(value interface{}) error {
+			return p.checkDomain()
+		})),
+		validation.Field(&p.Y,
+			validation.When(p.Function == "pow", validation.NotNil.Error("is required for pow")),
+			validation.When(p.Y != nil && p.Function != "pow" && p.Function != "root" && p.Function != "log",
+				validation.Nil.Error("is only supported for pow, root and log")),
+		),
+		validation.Field(&p.AngleUnit,
+			validation.In("radians", "degrees"),
+		),
+	)
+}
+
+// arguments returns x and y, which Validate has checked are numbers.
+func (p ScientificParams) arguments() (float64, *float64) {
+	x, _ := floatValue(p.X)
+	y, _ := optionalFloat(p.Y)
+	return x, y
+}
+
+// checkDomain rejects arguments for which the function is undefined.
+func (p ScientificParams) checkDomain() error {
+	x, y := p.arguments()
+	switch p.Function {
+	case "asin", "acos":
+		if x < -1 || x > 1 {
+			return fmt.Errorf("cannot take %s of a value outside [-1, 1]", p.Function)
+		}
+	case "acosh":
+		if x < 1 {
+			return errors.New("cannot take acosh of a value less than 1")
+		}
+	case "atanh":
+		if x <= -1 || x >= 1 {
+			return errors.New("cannot take atanh of a value outside (-1, 1)")
+		}
+	case "tan":
+		if p.AngleUnit == "degrees" && math.Mod(x, 180) != 0 && math.Mod(x, 90) == 0 {
+			return errors.New("cannot take tan of an odd multiple of 90 degrees")
+		}
+	case "log", "ln", "log2", "log10":
+		if x <= 0 {
+			return errors.New("cannot take the logarithm of zero or a negative number")
+		}
+		if p.Function == "log" && y != nil && (*y <= 0 || *y == 1) {
+			return errors.New("cannot take a logarithm with a base that is not positive or is 1")
+		}
+	case "sqrt":
+		if x < 0 {
+			return errors.New("cannot take the square root of a negative number")
+		}
+	case "root":
+		n := 2.0
+		if y != nil {
+			n = *y
+		}
+		if n == 0 {
+			return errors.New("cannot take the zeroth root")
+		}
+		if x < 0 && !isOddInteger(n) {
+			return errors.New("cannot take an even or fractional root of a negative number")
+		}
+	case "pow":
+		if y == nil {
+			return nil
+		}
+		if x == 0 && *y < 0 {
+			return errors.New("cannot raise zero to a negative power")
+		}
+		if x < 0 && *y != math.Trunc(*y) {
+			return errors.New("cannot raise a negative number to a non-integer power")
+		}
+	case "factorial":
+		if x < 0 || x != math.Trunc(x) {
+			return errors.New("cannot take the factorial of a negative or non-integer number")
+		}
+		if x > maxFactorial {
+			return fmt.Errorf("cannot take the factorial of a number greater than %d", maxFactorial)
+		}
+	case "gamma":
+		if x <= 0 && x == math.Trunc(x) {
+			return errors.New("cannot take gamma of zero or a negative integer")
+		}
+	}
+	return nil
+}
+
+func isOddInteger(f float64) bool {
+	return f == math.Trunc(f) && math.Mod(math.Abs(f), 2) == 1
+}
+
+// ScientificResult defines the result for the scientific tool.
+type ScientificResult struct {
+	Result    float64 `json:"result" jsonschema:"value of the function"`
+	Function  string  `json:"function" jsonschema:"function that was applied"`
+	AngleUnit string  `json:"angle_unit,omitempty" jsonschema:"angle unit used by trigonometric functions"`
+}
+
+func handleScientific(ctx context.Context, req *mcp.CallToolRequest, param ScientificParams) (*mcp.CallToolResult, ScientificResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			ScientificResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	degrees := param.AngleUnit == "degrees"
+	x, y := param.arguments()
+	result := applyScientific(param.Function, x, y, degrees)
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return errorResult(fmt.Sprintf("Calculation error: %s(%g) is out of the representable range", param.Function, x)),
+			ScientificResult{}, fmt.Errorf("calculation error: %s(%g) is out of the representable range", param.Function, x)
+	}
+
+	var angleUnit string
+	switch param.Function {
+	case "sin", "cos", "tan", "asin", "acos", "atan":
+		angleUnit = "radians"
+		if degrees {
+			angleUnit = "degrees"
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Result: %g", result)}},
+	}, ScientificResult{Result: result, Function: param.Function, AngleUnit: angleUnit}, nil
+}
+
+// applyScientific evaluates function at x. The arguments must already have
+// passed ScientificParams.Validate.
+func applyScientific(function string, x float64, y *float64, degrees bool) float64 {
+	switch function {
+	case "sin", "cos", "tan":
+		if degrees {
+			if v, ok := exactTrigDegrees(function, x); ok {
+				return v
+			}
+			x = x * math.Pi / 180
+		}
+		switch function {
+		case "sin":
+			return math.Sin(x)
+		case "cos":
+			return math.Cos(x)
+		}
+		return math.Tan(x)
+	case "asin", "acos", "atan":
+		var v float64
+		switch function {
+		case "asin":
+			v = math.Asin(x)
+		case "acos":
+			v = math.Acos(x)
+		default:
+			v = math.Atan(x)
+		}
+		if degrees {
+			v = v * 180 / math.Pi
+		}
+		return v
+	case "sinh":
+		return math.Sinh(x)
+	case "cosh":
+		return math.Cosh(x)
+	case "tanh":
+		return math.Tanh(x)
+	case "asinh":
+		return math.Asinh(x)
+	case "acosh":
+		return math.Acosh(x)
+	case "atanh":
+		return math.Atanh(x)
+	case "log":
+		if y != nil {
+			return math.Log(x) / math.Log(*y)
+		}
+		return math.Log10(x)
+	case "ln":
+		return math.Log(x)
+	case "log2":
+		return math.Log2(x)
+	case "log10":
+		return math.Log10(x)
+	case "exp":
+		return math.Exp(x)
+	case "pow":
+		return math.Pow(x, *y)
+	case "sqrt":
+		return math.Sqrt(x)
+	case "root":
+		n := 2.0
+		if y != nil {
+			n = *y
+		}
+		if n == 3 {
+			return math.Cbrt(x)
+		}
+		if x < 0 {
+			return -math.Pow(-x, 1/n)
+		}
+		return math.Pow(x, 1/n)
+	case "abs":
+		return math.Abs(x)
+	case "floor":
+		return math.Floor(x)
+	case "ceil":
+		return math.Ceil(x)
+	case "round":
+		return math.Round(x)
+	case "factorial":
+		return math.Gamma(x + 1)
+	case "gamma":
+		return math.Gamma(x)
+	}
+	return math.NaN()
+}
+
+// exactTrigDegrees returns sin, cos and tan at multiples of 30 degrees from a
+// table, where converting to radians would leave residues such as
+// sin(180°) = 1.2e-16 or sin(30°) = 0.49999999999999994.
+func exactTrigDegrees(function string, x float64) (float64, bool) {
+	if math.Mod(x, 30) != 0 {
+		return 0, false
+	}
+	half, root3 := 0.5, math.Sqrt(3)/2
+	table := [12]float64{0, half, root3, 1, root3, half, 0, -half, -root3, -1, -root3, -half}
+	k := int(math.Mod(math.Mod(x/30, 12)+12, 12))
+	sin, cos := table[k], table[(k+3)%12]
+	switch function {
+	case "sin":
+		return sin, true
+	case "cos":
+		return cos, true
+	}
+	return sin / cos, true
+}
@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
 	"math/rand"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -21,45 +24,240 @@ const (
 
 // CalculateParams defines the parameters for the calculate tool.
 type CalculateParams struct {
-	Operation string  `json:"operation" jsonschema:"operation to be performed on the numbers"`
-	Num1      float32 `json:"num1" jsonschema:"first number"`
-	Num2      float32 `json:"num2" jsonschema:"second number"`
+	Operation    string    `json:"operation" jsonschema:"operation on two numbers: 'add', 'subtract', 'multiply', 'divide', 'modulo', 'integer-divide', 'power' or 'percent-of' (num1 percent of num2); or on a list: 'sum', 'product', 'min', 'max', 'average', 'gcd' or 'lcm'"`
+	Num1         *float64  `json:"num1,omitempty" jsonschema:"first number"`
+	Num2         *float64  `json:"num2,omitempty" jsonschema:"second number"`
+	Num1Text     string    `json:"num1_text,omitempty" jsonschema:"first number as a string such as \"1,234.5\", \"3/4\", \"1e-9\", \"0x1F\", \"½\" or \"15%\", used instead of num1 so no precision is lost in JSON"`
+	Num2Text     string    `json:"num2_text,omitempty" jsonschema:"second number as a string, used instead of num2"`
+	Operands     []float64 `json:"operands,omitempty" jsonschema:"the numbers to operate on, instead of num1 and num2; two for the two-number operations, any number for the list operations"`
+	OperandsText []string  `json:"operands_text,omitempty" jsonschema:"the operands as strings, used instead of operands"`
+	Locale       string    `json:"locale,omitempty" jsonschema:"separators of the text numbers: 'en' (default, 1,234.5), 'de' (1.234,5), 'fr' (1 234,5), 'ch' (1'234.5) or another language code"`
+	Precision    string    `json:"precision,omitempty" jsonschema:"number mode: 'float64' (default), 'bigfloat' (arbitrary-precision binary floating point), 'rational' (exact fractions) or 'decimal' (exact decimal with scale and rounding)"`
+	Bits         uint      `json:"bits,omitempty" jsonschema:"mantissa size in bits for the 'bigfloat' mode (default: 256, max: 4096)"`
+	Scale        *int      `json:"scale,omitempty" jsonschema:"number of fractional digits in the 'decimal' mode (default: 20 for divide and average, otherwise exact when the result terminates)"`
+	Rounding     string    `json:"rounding,omitempty" jsonschema:"rounding mode for the 'decimal' mode: 'half-even' (default), 'half-up', 'down', 'up', 'ceiling' or 'floor'"`
 }
 
 func (p CalculateParams) Validate() error {
+	if err := checkLocale(p.Locale); err != nil {
+		return err
+	}
+	// legacy calls pass num1 and num2 instead of operands.
+	legacy := p.Operands == nil && p.OperandsText == nil
+	// The big modes report a zero divisor themselves; the float64 mode
+	// checks a text one here, after rounding it to float64.
+	float64Mode := p.Precision == "" || p.Precision == "float64"
+	nonZeroDivisor := validation.By(func(value interface{}) error {
+		s, _ := value.(string)
+		if !float64Mode || !divides(p.Operation) || s == "" {
+			return nil
+		}
+		if x, err := parseNumber(s, p.Locale); err == nil {
+			if f, _ := x.Float64(); f == 0 {
+				return errors.New("cannot divide by zero")
+			}
+		}
+		return nil
+	})
+	notWithOperands := validation.When(!legacy, validation.Empty.Error("cannot be combined with operands"))
+	// num1 and num2 are pointers so that an explicit 0 counts as given.
+	numberNotWithOperands := validation.When(!legacy, validation.Nil.Error("cannot be combined with operands"))
 	return validation.ValidateStruct(&p,
 		validation.Field(&p.Operation,
 			validation.Required,
-			validation.In("add", "subtract", "multiply", "divide"),
+			validation.In(calculateOperations...),
+		),
+		validation.Field(&p.Num1,
+			validation.When(legacy && p.Num1Text == "", validation.NotNil.Error("is required")),
+			numberNotWithOperands,
 		),
-		validation.Field(&p.Num1, validation.Required),
 		validation.Field(&p.Num2,
-			validation.Required,
+			validation.When(legacy && p.Num2Text == "", validation.NotNil.Error("is required")),
+			numberNotWithOperands,
 			validation.By(func(value interface{}) error {
-				if p.Operation == "divide" && p.Num2 == 0 {
+				if legacy && divides(p.Operation) && p.Num2Text == "" && p.Num2 != nil && *p.Num2 == 0 {
+					return errors.New("cannot divide by zero")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Num1Text,
+			numberText(p.Locale),
+			notWithOperands,
+		),
+		validation.Field(&p.Num2Text,
+			numberText(p.Locale),
+			nonZeroDivisor,
+			notWithOperands,
+		),
+		validation.Field(&p.Operands,
+			validation.When(p.Operands != nil, validation.By(func(value interface{}) error {
+				if err := checkArity(p.Operation, len(p.Operands)); err != nil {
+					return err
+				}
+				if divides(p.Operation) && p.Operands[1] == 0 {
 					return errors.New("cannot divide by zero")
 				}
 				return nil
+			})),
+		),
+		validation.Field(&p.OperandsText,
+			validation.When(p.Operands != nil, validation.Nil.Error("cannot be combined with operands")),
+			validation.When(p.OperandsText != nil, validation.By(func(value interface{}) error {
+				return checkArity(p.Operation, len(p.OperandsText))
+			})),
+			validation.Each(validation.Required, numberText(p.Locale)),
+			validation.When(len(p.OperandsText) == 2, validation.By(func(value interface{}) error {
+				return nonZeroDivisor.Validate(p.OperandsText[1])
+			})),
+		),
+		validation.Field(&p.Precision,
+			validation.In("float64", "bigfloat", "rational", "decimal"),
+		),
+		validation.Field(&p.Locale,
+			validation.When(p.Num1Text == "" && p.Num2Text == "" && p.OperandsText == nil,
+				validation.Empty.Error("only applies to the text operands")),
+		),
+		validation.Field(&p.Bits,
+			validation.Max(uint(maxBigFloatBits)),
+			validation.By(func(value interface{}) error {
+				if p.Bits != 0 && p.Precision != "bigfloat" {
+					return errors.New("is only supported with 'bigfloat' precision")
+				}
+				return nil
 			}),
 		),
+		validation.Field(&p.Scale,
+			validation.Min(0),
+			validation.Max(maxDecimalScale),
+			validation.When(p.Precision != "decimal",
+				validation.Nil.Error("is only supported with 'decimal' precision")),
+		),
+		validation.Field(&p.Rounding,
+			validation.In(roundingModes...),
+			validation.When(p.Precision != "decimal",
+				validation.Empty.Error("is only supported with 'decimal' precision")),
+		),
 	)
 }
 
+// operands returns the exact operands for the big and decimal modes,
+// preferring the text fields over the JSON numbers. The parameters must
+// already have passed Validate.
+func (p CalculateParams) operands() []*big.Rat {
+	var values []interface{}
+	switch {
+	case p.OperandsText != nil:
+		for _, s := range p.OperandsText {
+			values = append(values, s)
+		}
+	case p.Operands != nil:
+		for _, x := range p.Operands {
+			values = append(values, x)
+		}
+	default:
+		values = []interface{}{p.Num1Text, p.Num2Text}
+		if p.Num1Text == "" {
+			values[0] = *p.Num1
+		}
+		if p.Num2Text == "" {
+			values[1] = *p.Num2
+		}
+	}
+	xs := make([]*big.Rat, len(values))
+	for i, value := range values {
+		xs[i], _ = decimalValue(value, p.Locale)
+	}
+	return xs
+}
+
+// values returns the operands for the float64 mode, with the text operands
+// rounded to the nearest float64. The parameters must already have passed
+// Validate.
+func (p CalculateParams) values() []float64 {
+	if p.Operands != nil {
+		return p.Operands
+	}
+	if p.OperandsText == nil && p.Num1Text == "" && p.Num2Text == "" {
+		return []float64{*p.Num1, *p.Num2}
+	}
+	operands := p.operands()
+	xs := make([]float64, len(operands))
+	for i, x := range operands {
+		xs[i], _ = x.Float64()
+	}
+	return xs
+}
+
 type GenerateRandomNumberParams struct {
-	Min          *int   `json:"min,omitempty" jsonschema:"minimum value (default: 1)"`
-	Max          *int   `json:"max,omitempty" jsonschema:"maximum value (default: 100)"`
-	Distribution string `json:"distribution,omitempty" jsonschema:"probability distribution: 'uniform' (default), 'normal' (Gaussian/bell curve), or 'exponential' (exponential decay)"`
+	Min          interface{} `json:"min,omitempty" jsonschema:"minimum value, as a number or a string such as \"1e3\"; the start of the range for uniform and triangular (default: 1), otherwise a truncation bound"`
+	Max          interface{} `json:"max,omitempty" jsonschema:"maximum value; the end of the range for uniform and triangular (default: 100), otherwise a truncation bound"`
+	Distribution string      `json:"distribution,omitempty" jsonschema:"probability distribution: 'uniform' (default), 'normal', 'exponential', 'poisson', 'binomial', 'geometric', 'gamma', 'beta', 'log-normal', 'weibull', 'triangular' or 'zipf'"`
+	Mean         interface{} `json:"mean,omitempty" jsonschema:"normal: mean (default: middle of the range)"`
+	StdDev       interface{} `json:"stddev,omitempty" jsonschema:"normal: standard deviation (default: a sixth of the range)"`
+	Lambda       interface{} `json:"lambda,omitempty" jsonschema:"exponential: rate; poisson: mean"`
+	N            *int        `json:"n,omitempty" jsonschema:"binomial: number of trials; zipf: number of ranks"`
+	P            interface{} `json:"p,omitempty" jsonschema:"binomial and geometric: success probability"`
+	Alpha        interface{} `json:"alpha,omitempty" jsonschema:"gamma: shape; beta: first shape"`
+	Beta         interface{} `json:"beta,omitempty" jsonschema:"gamma: rate; beta: second shape"`
+	Mu           interface{} `json:"mu,omitempty" jsonschema:"log-normal: mean of the logarithm"`
+	Sigma        interface{} `json:"sigma,omitempty" jsonschema:"log-normal: standard deviation of the logarithm"`
+	Shape        interface{} `json:"shape,omitempty" jsonschema:"weibull: shape k"`
+	Scale        interface{} `json:"scale,omitempty" jsonschema:"weibull: scale"`
+	Mode         interface{} `json:"mode,omitempty" jsonschema:"triangular: peak (default: middle of the range)"`
+	Exponent     interface{} `json:"exponent,omitempty" jsonschema:"zipf: exponent s, greater than 1"`
+	Output       string      `json:"output,omitempty" jsonschema:"'integer' (default) rounds values to whole numbers; 'float' returns them unrounded in values"`
+	Seed         *int64      `json:"seed,omitempty" jsonschema:"reseed this session's random stream for reproducible results; later calls without a seed continue the sequence"`
+	Secure       bool        `json:"secure,omitempty" jsonschema:"draw from the cryptographically secure generator (crypto/rand) instead of the session stream; cannot be seeded"`
+	Count        int         `json:"count,omitempty" jsonschema:"number of values to generate and return in numbers (default: 1)"`
+	Sorted       bool        `json:"sorted,omitempty" jsonschema:"return numbers in ascending order"`
+	Unique       bool        `json:"unique,omitempty" jsonschema:"sample without replacement so that numbers holds no duplicates"`
+	Histogram    bool        `json:"histogram,omitempty" jsonschema:"summarize the numbers in a histogram over [min, max], or over the values drawn when unbounded"`
+	Bins         int         `json:"bins,omitempty" jsonschema:"number of histogram bins (default: 10)"`
 }
 
 func (p GenerateRandomNumberParams) Validate() error {
+	if err := checkNumbers(p.numbers()); err != nil {
+		return err
+	}
+	integer := p.Output != "float"
+	bound := validation.By(func(value interface{}) error {
+		v, _ := optionalFloat(value)
+		if v == nil {
+			return nil
+		}
+		if math.IsNaN(*v) || math.IsInf(*v, 0) {
+			return errors.New("must be a finite number")
+		}
+		if integer && (*v != math.Trunc(*v) || math.Abs(*v) > maxSafeInteger) {
+			return errors.New("must be a whole number between -2^53 and 2^53 for integer output")
+		}
+		return nil
+	})
 	return validation.ValidateStruct(&p,
 		validation.Field(&p.Distribution,
-			validation.In("", "uniform", "normal", "exponential"),
+			validation.In(distributionNames...),
+			validation.By(func(value interface{}) error { return checkDistribution(p) }),
+		),
+		validation.Field(&p.Output,
+			validation.In("", "integer", "float"),
+		),
+		validation.Field(&p.Seed,
+			validation.When(p.Secure, validation.Nil.Error("cannot be used with secure")),
+		),
+		validation.Field(&p.Count,
+			validation.Min(0),
+			validation.Max(maxRandomCount),
+		),
+		validation.Field(&p.Bins,
+			validation.Min(0),
+			validation.Max(maxHistogramBins),
+			validation.When(!p.Histogram, validation.Empty.Error("is only supported with histogram")),
 		),
-		validation.Field(&p.Min),
-		validation.Field(&p.Max),
+		validation.Field(&p.Min, bound),
+		validation.Field(&p.Max, bound),
 		validation.Field(&p.Min, validation.By(func(value interface{}) error {
-			if p.Min != nil && p.Max != nil && *p.Min >= *p.Max {
+			if lo, hi := p.bounds(); lo >= hi {
 				return errors.New("min must be less than max")
 			}
 			return nil
@@ -67,13 +265,24 @@ func (p GenerateRandomNumberParams) Validate() error {
 	)
 }
 
+// maxHistogramBins bounds the bins of a generate-random-number histogram.
+const maxHistogramBins = 1000
+
 type GenerateRandomNumberResult struct {
-	Number int `json:"number" jsonschema:"generated random number"`
+	Number     int                `json:"number" jsonschema:"generated random number; the first of numbers when count is given, rounded with output float"`
+	Numbers    []int              `json:"numbers,omitempty" jsonschema:"all generated numbers; present when count is given with integer output"`
+	Values     []float64          `json:"values,omitempty" jsonschema:"all generated values; present with output float"`
+	Parameters map[string]float64 `json:"parameters,omitempty" jsonschema:"parameters of the distribution, including derived defaults"`
+	Histogram  []HistogramBin     `json:"histogram,omitempty" jsonschema:"counts of numbers per bin; present when histogram is requested"`
+	Seed       *int64             `json:"seed,omitempty" jsonschema:"seed of this session's random stream; pass it back to reproduce the sequence; omitted in secure mode"`
+	Sequence   int                `json:"sequence,omitempty" jsonschema:"number of calls drawing from the stream since it was seeded, including this one; omitted in secure mode"`
 }
 
 // CalculateResult defines the result for the calculate tool.
 type CalculateResult struct {
-	Result float32 `json:"result" jsonschema:"result of the operation"`
+	Result float64 `json:"result" jsonschema:"result of the operation; in the big modes it is saturated to the largest float64 when out of range"`
+	Value  string  `json:"value,omitempty" jsonschema:"result as a decimal string in the 'bigfloat', 'rational' and 'decimal' modes"`
+	Exact  string  `json:"exact,omitempty" jsonschema:"exact result as a reduced fraction in the 'rational' mode"`
 }
 
 func createMCPServer() *mcp.Server {
@@ -86,16 +295,164 @@ func createMCPServer() *mcp.Server {
 	// 	// Calculator tool
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "calculate",
-		Description: "Perform basic mathematical operations like add, subtract, multiply, and divide",
+		Description: "Perform basic mathematical operations like add, subtract, multiply, and divide in float64, arbitrary-precision (bigfloat), exact rational or exact decimal arithmetic",
 	}, handleCalculate)
 
+	// Expression evaluator tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "evaluate",
+		Description: "Evaluate an arithmetic expression with operator precedence, parentheses, unary minus, functions (sqrt, sin, ln, ...) and constants (pi, e, ...), returning the value and its parse tree",
+	}, handleEvaluate)
+
+	// Scientific function tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "scientific",
+		Description: "Apply a scientific function: trigonometric and hyperbolic functions and their inverses, logarithms, exp, powers, roots, abs, floor/ceil/round, factorial and gamma",
+	}, handleScientific)
+
+	// Statistics tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "statistics",
+		Description: "Summarize a list of numbers: count, sum, mean, median, mode, variance and standard deviation (sample and population), min/max, percentiles, skewness and kurtosis",
+	}, handleStatistics)
+
+	// Linear algebra tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "matrix",
+		Description: "Linear algebra on matrices given as nested arrays: add, subtract, multiply, transpose, determinant, inverse, rank, trace, solve Ax=b, and LU/QR decomposition",
+	}, handleMatrix)
+
+	// Unit conversion tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "convert-units",
+		Description: "Convert a quantity between units with dimensional analysis, supporting SI prefixes, compound units such as kg·m/s² and temperature scales",
+	}, handleConvertUnits)
+
+	// Currency conversion tools
+	if path := os.Getenv("RATES_FILE"); path != "" {
+		if err := loadRatesFile(path); err != nil {
+			log.Printf("Failed to load currency rates: %v", err)
+		} else {
+			log.Printf("Loaded currency rates from %s", path)
+		}
+	}
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "convert-currency",
+		Description: "Convert an amount between currencies using the offline rate table, triangulating through its base currency and rounding to the target currency's ISO 4217 minor unit",
+	}, handleConvertCurrency)
+	// The rate table is shared by every session, so replacing it is only
+	// offered when an admin token guards it.
+	if os.Getenv("CURRENCY_ADMIN_TOKEN") != "" {
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "set-currency-rates",
+			Description: "Admin: replace the currency rate table with rates against a base currency",
+		}, handleSetCurrencyRates)
+		log.Println("Enabled set-currency-rates for holders of CURRENCY_ADMIN_TOKEN")
+	}
+
 	// Random number generator tool
+	if limit := os.Getenv("RANDOM_MAX_COUNT"); limit != "" {
+		if n, err := strconv.Atoi(limit); err != nil || n < 1 {
+			log.Printf("Ignoring invalid RANDOM_MAX_COUNT %q", limit)
+		} else {
+			maxRandomCount = n
+		}
+	}
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "generate-random-number",
-		Description: "Generate a random number between 1 and 100",
+		Description: "Generate one or a batch of random numbers in a range (default 1 to 100), optionally sorted, without replacement, or summarized in a histogram",
 	}, handleGenerateRandomNumber)
 
-	log.Println("Loaded tools: calculate, random_number")
+	// Secure token generator tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "generate-random-token",
+		Description: "Generate a cryptographically secure random token: hex, base64url, UUIDv4/v7, passphrase, or a string over a custom alphabet",
+	}, handleGenerateRandomToken)
+
+	// Random choice, shuffle and dice tools
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "random-choice",
+		Description: "Pick one or more items from a list, optionally weighted, with or without replacement",
+	}, handleRandomChoice)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "shuffle",
+		Description: "Shuffle a list into a uniformly random order (Fisher–Yates)",
+	}, handleShuffle)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "roll-dice",
+		Description: "Roll dice given in dice notation such as 4d6kh3+2, returning every roll, which were kept, and the total",
+	}, handleRollDice)
+
+	// Probability distribution tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "distribution",
+		Description: "Evaluate the pdf/pmf, cdf, survival function or quantile (inverse cdf) of a probability distribution, reporting the method used and its accuracy",
+	}, handleDistribution)
+
+	// Symbolic algebra tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "symbolic",
+		Description: "Simplify, expand, factor, differentiate or substitute into an expression with variables, returning a canonical form and LaTeX",
+	}, handleSymbolic)
+
+	// Equation solver tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "solve",
+		Description: "Solve an equation in one unknown (exact roots of polynomials up to degree 4, Brent's or Newton's method in an interval otherwise) or a system of linear equations",
+	}, handleSolve)
+
+	// Calculus tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "calculus",
+		Description: "Numerically integrate an expression in one variable (adaptive Gauss–Kronrod or Simpson, infinite bounds allowed), differentiate it at a point, or find a limit, with an error estimate",
+	}, handleCalculus)
+
+	// Complex number tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "complex",
+		Description: "Complex arithmetic: add, subtract, multiply, divide, power, conjugate, modulus, argument, polar and rectangular forms, exp, log and sqrt; operands as {re, im} objects or strings like 3+4i",
+	}, handleComplex)
+
+	// Number theory tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "number-theory",
+		Description: "Arbitrary-size integers: primality (Miller–Rabin), factorization (trial division and Pollard's rho), gcd, lcm, extended Euclid, modular power and inverse, totient, next and previous prime",
+	}, handleNumberTheory)
+
+	// Bits tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "bits",
+		Description: "Fixed-width integer words (8 to 128 bits, signed or unsigned): base conversion 2-36, AND, OR, XOR, NOT, shifts, rotates, popcount, and two's-complement and IEEE-754 decoding",
+	}, handleBits)
+
+	// Date and time tool
+	if dir := os.Getenv("HOLIDAYS_DIR"); dir != "" {
+		names, err := loadHolidayCalendars(dir)
+		if err != nil {
+			log.Printf("Failed to load holiday calendars: %v", err)
+		}
+		if len(names) > 0 {
+			log.Printf("Loaded holiday calendars from %s: %v", dir, names)
+		}
+	}
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "datetime",
+		Description: "Date and time arithmetic: add or subtract ISO 8601 durations, the difference between timestamps, time zone conversion (IANA tzdata), ISO week numbers, and business days with holiday calendars",
+	}, handleDateTime)
+
+	// Finance tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "finance",
+		Description: "Financial math on exact decimal amounts: compound interest, future and present value, loan payments, amortization schedules (rows and CSV), NPV, and IRR/XIRR by Brent's method",
+	}, handleFinance)
+
+	// Percent and ratio tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "percent-and-ratio",
+		Description: "Exact percentages and ratios: percent of, what percent, percent change, adding, subtracting and reversing a percentage (such as VAT), splitting an amount by a ratio with largest-remainder allocation so no cents are lost, simplifying ratios, and rounding to decimal places or significant figures with explicit rounding modes",
+	}, handlePercentRatio)
+
+	log.Println("Loaded tools: calculate, evaluate, scientific, statistics, matrix, convert-units, convert-currency, random_number, random_token, random_choice, shuffle, roll_dice, distribution, symbolic, solve, calculus, complex, number-theory, bits, datetime, finance, percent-and-ratio")
 
 	// Math constants resource
 	server.AddResource(&mcp.Resource{
@@ -104,27 +461,64 @@ func createMCPServer() *mcp.Server {
 		Description: "Mathematical constants",
 	}, handleMathConstants)
 
-	log.Println("Loaded resources: math-constants")
+	// Unit registry resource
+	server.AddResource(&mcp.Resource{
+		URI:         "units://registry",
+		Name:        "unit-registry",
+		Description: "Units known to convert-units with their dimensions, SI factors, prefixes and aliases",
+		MIMEType:    "application/json",
+	}, handleUnits)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: "units://registry/{+unit}",
+		Name:        "unit",
+		Description: "Dimension and SI conversion factor of a unit or unit expression",
+		MIMEType:    "application/json",
+	}, handleUnits)
+
+	// Currency rate table resource
+	server.AddResource(&mcp.Resource{
+		URI:         "rates://current",
+		Name:        "currency-rates",
+		Description: "Current currency rate table with its base currency and as-of timestamp",
+		MIMEType:    "application/json",
+	}, handleCurrencyRates)
+
+	log.Println("Loaded resources: math-constants, unit-registry, currency-rates")
 
 	// Calculation explanation prompt
 	server.AddPrompt(&mcp.Prompt{
 		Name:        "calculation-explanation",
-		Description: "Explain how a mathematical calculation works",
+		Description: "Work through a calculation step by step, with column addition and subtraction, long multiplication and division, and the order of operations",
 		Arguments: []*mcp.PromptArgument{
+			{
+				Name:        "expression",
+				Description: "The expression to work out, e.g. 12 + 3 × (4 - 1) or 7^2 - 144 / 12",
+				Required:    false,
+			},
+			{
+				Name:        "level",
+				Description: "Pedagogy level: 'elementary' (every column narrated, remainders), 'high-school' (default; PEMDAS and written methods) or 'university' (concise, with exact values)",
+				Required:    false,
+			},
 			{
 				Name:        "operation",
-				Description: "The mathematical operation: add, subtract, multiply, or divide",
-				Required:    true,
+				Description: "Instead of an expression: the mathematical operation, add, subtract, multiply, or divide",
+				Required:    false,
 			},
 			{
 				Name:        "num1",
-				Description: "The first number",
-				Required:    true,
+				Description: "The first number, with operation",
+				Required:    false,
 			},
 			{
 				Name:        "num2",
-				Description: "The second number",
-				Required:    true,
+				Description: "The second number, with operation",
+				Required:    false,
+			},
+			{
+				Name:        "locale",
+				Description: "Separators of num1 and num2: 'en' (default, 1,234.5), 'de' (1.234,5), 'fr' (1 234,5) or 'ch' (1'234.5)",
+				Required:    false,
 			},
 		},
 	}, handleCalculationExplanation)
@@ -151,6 +545,11 @@ func createMCPServer() *mcp.Server {
 				Description: "Probability distribution: 'uniform' (default), 'normal' (Gaussian/bell curve), or 'exponential' (exponential decay)",
 				Required:    false,
 			},
+			{
+				Name:        "seed",
+				Description: "Integer seed for a reproducible result; reseeds this session's random stream",
+				Required:    false,
+			},
 		},
 	}, handleGenerateRandomNumberPrompt)
 
@@ -217,23 +616,80 @@ func handleCalculate(ctx context.Context, req *mcp.CallToolRequest, param Calcul
 			CalculateResult{}, fmt.Errorf("invalid parameters: %v", err)
 	}
 
-	var result float32
-	switch param.Operation {
-	case "add":
-		result = param.Num1 + param.Num2
-	case "subtract":
-		result = param.Num1 - param.Num2
-	case "multiply":
-		result = param.Num1 * param.Num2
-	case "divide":
-		result = param.Num1 / param.Num2
+	operands := param.operands()
+	switch param.Precision {
+	case "bigfloat":
+		bits := param.Bits
+		if bits == 0 {
+			bits = defaultBigFloatBits
+		}
+		value, err := calculateBigFloat(param.Operation, operands, bits)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+				CalculateResult{}, fmt.Errorf("calculation error: %v", err)
+		}
+		result, _ := value.Float64()
+		text := value.Text('g', -1)
+		result = saturate(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Result: %s", text)}},
+		}, CalculateResult{Result: result, Value: text}, nil
+	case "rational":
+		value, err := calculateExact(param.Operation, operands)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+				CalculateResult{}, fmt.Errorf("calculation error: %v", err)
+		}
+		result, _ := value.Float64()
+		text := ratDecimalString(value, maxRationalDigits)
+		result = saturate(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Result: %s (%s)", value.RatString(), text)}},
+		}, CalculateResult{Result: result, Value: text, Exact: value.RatString()}, nil
+	case "decimal":
+		rounding := param.Rounding
+		if rounding == "" {
+			rounding = "half-even"
+		}
+		text, value, err := calculateDecimal(param.Operation, operands, param.Scale, rounding)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+				CalculateResult{}, fmt.Errorf("calculation error: %v", err)
+		}
+		result, _ := value.Float64()
+		result = saturate(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Result: %s", text)}},
+		}, CalculateResult{Result: result, Value: text}, nil
+	}
+
+	result, err := calculateFloat(param.Operation, param.values())
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			CalculateResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	if math.IsInf(result, 0) {
+		return errorResult("Calculation error: result overflows float64; use 'bigfloat' or 'rational' precision"),
+			CalculateResult{}, errors.New("calculation error: result overflows float64")
+	}
+	if math.IsNaN(result) {
+		return errorResult("Calculation error: an intermediate value overflows float64; use 'bigfloat' or 'rational' precision"),
+			CalculateResult{}, errors.New("calculation error: an intermediate value overflows float64")
 	}
 
 	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Result: %f", result)}},
+		Content: []mcp.Content{&mcp.TextContent{Text: "Result: " + strconv.FormatFloat(result, 'g', -1, 64)}},
 	}, CalculateResult{Result: result}, nil
 }
 
+// errorResult builds the IsError tool result that handlers return alongside
+// their error.
+func errorResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: text}}}
+}
+
 func handleGenerateRandomNumber(ctx context.Context, req *mcp.CallToolRequest, param GenerateRandomNumberParams) (*mcp.CallToolResult, GenerateRandomNumberResult, error) {
 	if err := param.Validate(); err != nil {
 		return &mcp.CallToolResult{IsError: true,
@@ -241,66 +697,96 @@ func handleGenerateRandomNumber(ctx context.Context, req *mcp.CallToolRequest, p
 			GenerateRandomNumberResult{}, fmt.Errorf("invalid parameters: %v", err)
 	}
 
-	distribution := "uniform"
-	if param.Distribution != "" {
-		distribution = param.Distribution
+	spec := resolveDistribution(param)
+	float := param.Output == "float"
+	count := param.Count
+	if count == 0 {
+		count = 1
 	}
 
-	min := 1
-	max := 100
-	if param.Min != nil {
-		min = *param.Min
+	var (
+		values []float64
+		result GenerateRandomNumberResult
+	)
+	seed, sequence, source, err := randomDraw(req.Session, param.Seed, param.Secure, func(src randomSource) error {
+		var err error
+		values, err = sampleValues(src, spec, count, float, param.Unique)
+		return err
+	})
+	result.Seed, result.Sequence = seed, sequence
+	if err != nil {
+		return errorResult(fmt.Sprintf("Generation error: %v", err)),
+			GenerateRandomNumberResult{}, fmt.Errorf("generation error: %v", err)
 	}
-	if param.Max != nil {
-		max = *param.Max
+	if param.Sorted {
+		slices.Sort(values)
+	}
+	result.Number = int(math.Round(math.Max(math.Min(values[0], maxSafeInteger), -maxSafeInteger)))
+	if len(spec.params) > 0 {
+		result.Parameters = spec.params
 	}
 
-	var number int
-	switch distribution {
-	case "uniform", "":
-		number = rand.Intn(max-min+1) + min
-	case "normal":
-		mean := float64(max+min) / 2.0
-		stdDev := float64(max-min) / 6.0 // ~99.7% within range
-		val := rand.NormFloat64()*stdDev + mean
-		number = clamp(int(val), min, max)
-	case "exponential":
-		// Scale exponential to fit range, with rate parameter based on range
-		lambda := 1.0 / float64(max-min)
-		val := rand.ExpFloat64() / lambda
-		number = clamp(int(val)+min, min, max)
-	default:
-		return &mcp.CallToolResult{IsError: true,
-				Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Invalid distribution: %s", distribution)}}},
-			GenerateRandomNumberResult{}, fmt.Errorf("invalid distribution: %s", distribution)
+	details := "distribution: " + spec.String()
+	if spec.bounded() {
+		details += fmt.Sprintf(", range: [%g, %g]", spec.lo, spec.hi)
 	}
+	details += ", " + source
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Generated random number: %d (distribution: %s, range: [%d, %d])", number, distribution, min, max)}},
-	}, GenerateRandomNumberResult{Number: number}, nil
-}
+	if param.Count == 0 && !param.Histogram && !float {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Generated random number: %d (%s)", result.Number, details)}},
+		}, result, nil
+	}
 
-func clamp(val, min, max int) int {
-	if val < min {
-		return min
+	if float {
+		result.Values = values
+	} else {
+		result.Numbers = make([]int, len(values))
+		for i, v := range values {
+			result.Numbers[i] = int(v)
+		}
 	}
-	if val > max {
-		return max
+	noun := "numbers"
+	if len(values) == 1 {
+		noun = "number"
 	}
-	return val
+	text := fmt.Sprintf("Generated %d random %s (%s): %s", len(values), noun, details, formatValues(values, maxListedNumbers))
+	if param.Histogram {
+		bins := param.Bins
+		if bins == 0 {
+			bins = 10
+		}
+		result.Histogram = histogram(values, spec.lo, spec.hi, bins, float)
+		text += "\nHistogram:"
+		for _, b := range result.Histogram {
+			text += fmt.Sprintf("\n  [%g, %g]: %d", b.Low, b.High, b.Count)
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// maxListedNumbers bounds how many generated numbers the text content lists;
+// the structured result always carries all of them.
+const maxListedNumbers = 50
+
+// mathConstants are served by the math://constants resource and can be used
+// as identifiers in expressions.
+var mathConstants = map[string]float64{
+	"pi":           3.141592653589793,
+	"e":            2.718281828459045,
+	"golden_ratio": 1.618033988749895,
+	"sqrt2":        1.4142135623730951,
+	"sqrt3":        1.7320508075688772,
+	"ln2":          0.6931471805599453,
+	"ln10":         2.302585092994046,
+	"euler":        0.5772156649015329,
 }
 
 func handleMathConstants(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-	constants := map[string]float64{
-		"pi":           3.141592653589793,
-		"e":            2.718281828459045,
-		"golden_ratio": 1.618033988749895,
-		"sqrt2":        1.4142135623730951,
-		"sqrt3":        1.7320508075688772,
-		"ln2":          0.6931471805599453,
-		"ln10":         2.302585092994046,
-		"euler":        0.5772156649015329,
-	}
+	constants := mathConstants
 
 	uri := req.Params.URI
 	constantName := ""
@@ -345,125 +831,61 @@ func handleMathConstants(ctx context.Context, req *mcp.ReadResourceRequest) (*mc
 	return nil, mcp.ResourceNotFoundError(uri)
 }
 
-func handleCalculationExplanation(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+func handleGenerateRandomNumberPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
 	args := req.Params.Arguments
-	operation := args["operation"]
-	num1Str := args["num1"]
-	num2Str := args["num2"]
 
-	if operation == "" || num1Str == "" || num2Str == "" {
-		return &mcp.GetPromptResult{
-			Description: "Calculation explanation prompt",
-			Messages: []*mcp.PromptMessage{
-				{
-					Role:    "user",
-					Content: &mcp.TextContent{Text: "Please provide operation, num1, and num2 arguments"},
-				},
-			},
-		}, nil
-	}
+	// Parse arguments with defaults
+	min := 1
+	max := 100
+	distribution := "uniform"
 
-	var num1, num2 float64
-	var err error
-	if num1, err = parseFloat(num1Str); err != nil {
+	invalid := func(name string, err error) *mcp.GetPromptResult {
 		return &mcp.GetPromptResult{
 			Messages: []*mcp.PromptMessage{
 				{
 					Role:    "user",
-					Content: &mcp.TextContent{Text: fmt.Sprintf("Invalid number for num1: %s", num1Str)},
+					Content: &mcp.TextContent{Text: fmt.Sprintf("Invalid %s: %v", name, err)},
 				},
 			},
-		}, nil
-	}
-	if num2, err = parseFloat(num2Str); err != nil {
-		return &mcp.GetPromptResult{
-			Messages: []*mcp.PromptMessage{
-				{
-					Role:    "user",
-					Content: &mcp.TextContent{Text: fmt.Sprintf("Invalid number for num2: %s", num2Str)},
-				},
-			},
-		}, nil
-	}
-
-	var result float64
-	var explanation string
-	switch operation {
-	case "add":
-		result = num1 + num2
-		explanation = fmt.Sprintf("To add %g and %g, you simply combine the two numbers: %g + %g = %g", num1, num2, num1, num2, result)
-	case "subtract":
-		result = num1 - num2
-		explanation = fmt.Sprintf("To subtract %g from %g, you take away the second number from the first: %g - %g = %g", num2, num1, num1, num2, result)
-	case "multiply":
-		result = num1 * num2
-		explanation = fmt.Sprintf("To multiply %g by %g, you calculate the product: %g × %g = %g", num1, num2, num1, num2, result)
-	case "divide":
-		if num2 == 0 {
-			return &mcp.GetPromptResult{
-				Messages: []*mcp.PromptMessage{
-					{
-						Role:    "user",
-						Content: &mcp.TextContent{Text: "Cannot divide by zero. Division by zero is undefined in mathematics."},
-					},
-				},
-			}, nil
 		}
-		result = num1 / num2
-		explanation = fmt.Sprintf("To divide %g by %g, you calculate the quotient: %g ÷ %g = %g", num1, num2, num1, num2, result)
-	default:
-		return &mcp.GetPromptResult{
-			Messages: []*mcp.PromptMessage{
-				{
-					Role:    "user",
-					Content: &mcp.TextContent{Text: fmt.Sprintf("Invalid operation: %s. Valid operations are: add, subtract, multiply, divide", operation)},
-				},
-			},
-		}, nil
 	}
-
-	message := fmt.Sprintf("%s\n\nResult: %g", explanation, result)
-
-	return &mcp.GetPromptResult{
-		Description: "Calculation explanation",
-		Messages: []*mcp.PromptMessage{
-			{
-				Role:    "user",
-				Content: &mcp.TextContent{Text: message},
-			},
-		},
-	}, nil
-}
-
-func parseFloat(s string) (float64, error) {
-	var f float64
-	_, err := fmt.Sscanf(s, "%f", &f)
-	return f, err
-}
-
-func handleGenerateRandomNumberPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
-	args := req.Params.Arguments
-
-	// Parse arguments with defaults
-	min := 1
-	max := 100
-	distribution := "uniform"
-
 	if minStr := args["min"]; minStr != "" {
-		if parsed, err := strconv.Atoi(minStr); err == nil {
-			min = parsed
+		parsed, err := parseInteger(minStr)
+		if err != nil {
+			return invalid("min", err), nil
 		}
+		min = int(parsed)
 	}
 	if maxStr := args["max"]; maxStr != "" {
-		if parsed, err := strconv.Atoi(maxStr); err == nil {
-			max = parsed
+		parsed, err := parseInteger(maxStr)
+		if err != nil {
+			return invalid("max", err), nil
 		}
+		max = int(parsed)
 	}
 	if distStr := args["distribution"]; distStr != "" {
 		distribution = distStr
 	}
+	var seed *int64
+	if seedStr := args["seed"]; seedStr != "" {
+		parsed, err := parseInteger(seedStr)
+		if err != nil {
+			return invalid("seed", err), nil
+		}
+		seed = &parsed
+	}
 
 	// Validate
+	if min < -maxSafeInteger || max > maxSafeInteger {
+		return &mcp.GetPromptResult{
+			Messages: []*mcp.PromptMessage{
+				{
+					Role:    "user",
+					Content: &mcp.TextContent{Text: fmt.Sprintf("Invalid range: min (%d) and max (%d) must be between -2^53 and 2^53", min, max)},
+				},
+			},
+		}, nil
+	}
 	if min >= max {
 		return &mcp.GetPromptResult{
 			Messages: []*mcp.PromptMessage{
@@ -490,24 +912,31 @@ func handleGenerateRandomNumberPrompt(ctx context.Context, req *mcp.GetPromptReq
 	var number int
 	var explanation string
 
+	lo, hi := float64(min), float64(max)
+	spec := resolveDistribution(GenerateRandomNumberParams{Min: lo, Max: hi, Distribution: distribution})
+	var err error
+	usedSeed, _ := randomStreams.stream(req.Session).draw(seed, func(r *rand.Rand) {
+		var values []float64
+		if values, err = sampleValues(streamSource(r), spec, 1, false, false); err == nil {
+			number = int(values[0])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	switch distribution {
 	case "uniform", "":
-		number = rand.Intn(max-min+1) + min
 		explanation = fmt.Sprintf("Using uniform distribution, each number between %d and %d has an equal probability of being selected.", min, max)
 	case "normal":
 		mean := float64(max+min) / 2.0
 		stdDev := float64(max-min) / 6.0
-		val := rand.NormFloat64()*stdDev + mean
-		number = clamp(int(val), min, max)
-		explanation = fmt.Sprintf("Using normal (Gaussian) distribution with mean %.2f and standard deviation %.2f. Values near the center (%d-%d) are more likely.", mean, stdDev, (min+max)/2-5, (min+max)/2+5)
+		explanation = fmt.Sprintf("Using normal (Gaussian) distribution with mean %.2f and standard deviation %.2f. Values near the center (%d-%d) are more likely; values outside the range are redrawn.", mean, stdDev, (min+max)/2-5, (min+max)/2+5)
 	case "exponential":
-		lambda := 1.0 / float64(max-min)
-		val := rand.ExpFloat64() / lambda
-		number = clamp(int(val)+min, min, max)
-		explanation = fmt.Sprintf("Using exponential distribution. Lower values in the range (%d-%d) are more likely than higher values.", min, (min+max)/2)
+		explanation = fmt.Sprintf("Using exponential distribution starting at %d with mean %d. Lower values in the range (%d-%d) are more likely than higher values; values above %d are redrawn.", min, max-min, min, (min+max)/2, max)
 	}
 
-	message := fmt.Sprintf("%s\n\nGenerated random number: %d\nRange: [%d, %d]\nDistribution: %s", explanation, number, min, max, distribution)
+	message := fmt.Sprintf("%s\n\nGenerated random number: %d\nRange: [%d, %d]\nDistribution: %s\nSeed: %d", explanation, number, min, max, distribution, usedSeed)
 
 	return &mcp.GetPromptResult{
 		Description: "Random number generation",
@@ -0,0 +1,879 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxSystemSize bounds the number of equations and unknowns of a linear
+	// system.
+	maxSystemSize = 20
+	// maxSolveIterations bounds max_iterations of the numeric methods.
+	maxSolveIterations = 10_000
+	// scanIntervals is the number of pieces [min, max] is cut into when
+	// looking for sign changes.
+	scanIntervals          = 200
+	defaultSolveTolerance  = 1e-12
+	defaultSolveIterations = 100
+)
+
+var solveMethods = []interface{}{"auto", "exact", "brent", "newton"}
+
+// SolveParams defines the parameters for the solve tool.
+type SolveParams struct {
+	Equation      string      `json:"equation,omitempty" jsonschema:"equation in one unknown, e.g. 3x^2 - 5x + 2 = 0 or cos(x) = x; an expression without '=' is set equal to 0"`
+	Variable      string      `json:"variable,omitempty" jsonschema:"the unknown of equation (default: its only variable)"`
+	Equations     []string    `json:"equations,omitempty" jsonschema:"a system of linear equations instead of equation, e.g. [\"2x + y = 3\", \"x - y = 0\"]"`
+	Variables     []string    `json:"variables,omitempty" jsonschema:"the unknowns of equations, in the order to report them (default: all variables, sorted)"`
+	Method        string      `json:"method,omitempty" jsonschema:"'auto' (default): exact for polynomials, otherwise Brent on every sign change in [min, max] with Newton as fallback; 'exact', 'brent' or 'newton'"`
+	Min           interface{} `json:"min,omitempty" jsonschema:"lower end of the interval to search, as a number or a string such as \"-1/2\"; for polynomials, only real roots in [min, max] are returned"`
+	Max           interface{} `json:"max,omitempty" jsonschema:"upper end of the interval to search"`
+	Guess         interface{} `json:"guess,omitempty" jsonschema:"starting point of Newton's method (default: the middle of [min, max])"`
+	Tolerance     *float64    `json:"tolerance,omitempty" jsonschema:"absolute tolerance on the root for the numeric methods (default: 1e-12)"`
+	MaxIterations int         `json:"max_iterations,omitempty" jsonschema:"iteration limit of the numeric methods (default: 100)"`
+}
+
+func (p SolveParams) Validate() error {
+	if err := checkNumbers(map[string]interface{}{"min": p.Min, "max": p.Max, "guess": p.Guess}); err != nil {
+		return err
+	}
+	system := len(p.Equations) > 0
+	lo, hi, _ := p.points()
+	finite := func(value interface{}) error {
+		if v, ok := value.(*float64); ok && v != nil && (math.IsNaN(*v) || math.IsInf(*v, 0)) {
+			return errors.New("must be a finite number")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Equation,
+			validation.When(!system, validation.Required.Error("is required unless equations is given")),
+			validation.When(system, validation.Empty.Error("cannot be combined with equations")),
+			validation.Length(1, maxSymbolicLength),
+		),
+		validation.Field(&p.Variable,
+			validation.When(system, validation.Empty.Error("does not apply to equations; use variables")),
+			validation.Match(identifierPattern).Error("must be a variable name"),
+		),
+		validation.Field(&p.Equations,
+			validation.Length(0, maxSystemSize),
+			validation.Each(validation.Required, validation.Length(1, maxSymbolicLength)),
+		),
+		validation.Field(&p.Variables,
+			validation.When(!system, validation.Empty.Error("only applies to equations; use variable")),
+			validation.Length(0, maxSystemSize),
+			validation.Each(validation.Match(identifierPattern).Error("must be a variable name")),
+		),
+		validation.Field(&p.Method,
+			validation.In(solveMethods...),
+			validation.When(system, validation.In("", "auto", "exact").Error("linear systems are solved exactly")),
+		),
+		validation.Field(&p.Max,
+			validation.By(func(value interface{}) error {
+				if (lo == nil) != (hi == nil) {
+					return errors.New("min and max must be given together")
+				}
+				if lo != nil && hi != nil && *lo >= *hi {
+					return errors.New("must be greater than min")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Tolerance,
+			validation.By(finite),
+			validation.By(func(value interface{}) error {
+				if p.Tolerance != nil && *p.Tolerance <= 0 {
+					return errors.New("must be greater than 0")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.MaxIterations, validation.Min(0), validation.Max(maxSolveIterations)),
+	)
+}
+
+// SolveRoot is one root of an equation.
+type SolveRoot struct {
+	Exact        string   `json:"exact,omitempty" jsonschema:"the root in closed form, in the syntax of the evaluate tool; complex roots are written a + b*i"`
+	LaTeX        string   `json:"latex,omitempty" jsonschema:"the exact root as LaTeX"`
+	Value        float64  `json:"value" jsonschema:"the root, or its real part"`
+	Imaginary    float64  `json:"imaginary,omitempty" jsonschema:"imaginary part of a complex root"`
+	Multiplicity int      `json:"multiplicity,omitempty" jsonschema:"multiplicity of a polynomial root"`
+	Method       string   `json:"method" jsonschema:"how the root was found"`
+	Residual     *float64 `json:"residual,omitempty" jsonschema:"|f(root)| for numeric roots, where f is left side minus right side"`
+	Iterations   int      `json:"iterations,omitempty" jsonschema:"iterations used by a numeric method"`
+}
+
+// SolveAssignment is the value of one unknown of a linear system.
+type SolveAssignment struct {
+	Variable string   `json:"variable" jsonschema:"the unknown"`
+	Exact    string   `json:"exact" jsonschema:"its value, in terms of the free unknowns if there are any"`
+	LaTeX    string   `json:"latex" jsonschema:"its value as LaTeX"`
+	Value    *float64 `json:"value,omitempty" jsonschema:"its numeric value, when it does not depend on free unknowns"`
+}
+
+// SolveResult defines the result for the solve tool.
+type SolveResult struct {
+	Variable    string            `json:"variable,omitempty" jsonschema:"the unknown of a single equation"`
+	Roots       []SolveRoot       `json:"roots,omitempty" jsonschema:"the roots of a single equation: real roots in increasing order, then complex roots"`
+	Status      string            `json:"status,omitempty" jsonschema:"linear systems: 'unique', 'infinite' or 'inconsistent'"`
+	Solution    []SolveAssignment `json:"solution,omitempty" jsonschema:"linear systems: the value of each unknown"`
+	Free        []string          `json:"free,omitempty" jsonschema:"linear systems: unknowns that can take any value"`
+	Evaluations int               `json:"evaluations,omitempty" jsonschema:"function evaluations used by the numeric methods"`
+	Note        string            `json:"note,omitempty" jsonschema:"remarks on the result"`
+}
+
+func handleSolve(ctx context.Context, req *mcp.CallToolRequest, param SolveParams) (*mcp.CallToolResult, SolveResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			SolveResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	var result SolveResult
+	var err error
+	if len(param.Equations) > 0 {
+		result, err = solveLinearSystem(param.Equations, param.Variables)
+	} else {
+		result, err = solveEquation(param)
+	}
+	if err != nil {
+		var invalid invalidEquationError
+		if errors.As(err, &invalid) {
+			return errorResult(fmt.Sprintf("Invalid expression: %v", err)),
+				SolveResult{}, fmt.Errorf("invalid expression: %v", err)
+		}
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			SolveResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: formatSolveResult(result)}},
+	}, result, nil
+}
+
+// invalidEquationError reports an equation that does not parse.
+type invalidEquationError struct{ err error }
+
+func (e invalidEquationError) Error() string { return e.err.Error() }
+
+// equationSides splits an equation at its '=' and returns the sides as one
+// expression, left minus right. Positions in parse errors refer to the
+// whole equation.
+func equationSides(equation string) (*exprNode, error) {
+	if strings.Count(equation, "=") > 1 {
+		return nil, invalidEquationError{fmt.Errorf("%q has more than one '='", equation)}
+	}
+	left, right, found := strings.Cut(equation, "=")
+	lhs, err := parseExpression(left)
+	if err != nil {
+		return nil, invalidEquationError{fmt.Errorf("left side: %v", err)}
+	}
+	if !found {
+		return lhs, nil
+	}
+	rhs, err := parseExpression(right)
+	if err != nil {
+		offset := len([]rune(left)) + 1
+		return nil, invalidEquationError{fmt.Errorf("right side (after position %d): %v", offset, err)}
+	}
+	return &exprNode{kind: nodeBinary, text: "-", args: []*exprNode{lhs, rhs}, pos: len([]rune(left)) + 1}, nil
+}
+
+// exprVariables returns the identifiers of node that are not constants,
+// sorted.
+func exprVariables(node *exprNode) []string {
+	var names []string
+	var walk func(n *exprNode)
+	walk = func(n *exprNode) {
+		if n.kind == nodeIdent {
+			if _, constant := mathConstants[n.text]; !constant && !slices.Contains(names, n.text) {
+				names = append(names, n.text)
+			}
+		}
+		for _, arg := range n.args {
+			walk(arg)
+		}
+	}
+	walk(node)
+	slices.Sort(names)
+	return names
+}
+
+// solveEquation solves a single equation for its unknown.
+func solveEquation(param SolveParams) (SolveResult, error) {
+	node, err := equationSides(param.Equation)
+	if err != nil {
+		return SolveResult{}, err
+	}
+	vars := exprVariables(node)
+	x := param.Variable
+	switch {
+	case x == "" && len(vars) == 1:
+		x = vars[0]
+	case x == "" && len(vars) == 0:
+		return SolveResult{}, errors.New("the equation has no unknown")
+	case x == "":
+		return SolveResult{}, fmt.Errorf("the equation has %d variables (%s); choose the unknown with variable", len(vars), strings.Join(vars, ", "))
+	case len(vars) > 1 || len(vars) == 1 && vars[0] != x:
+		return SolveResult{}, fmt.Errorf("the equation has variables other than %s (%s); only one unknown is supported", x, strings.Join(vars, ", "))
+	}
+
+	method := param.Method
+	if method == "" {
+		method = "auto"
+	}
+	if method == "auto" || method == "exact" {
+		poly, ok, err := equationPolynomial(node, x)
+		switch {
+		case ok && err == nil:
+			return solvePolynomial(poly, x, param)
+		case method == "exact" && err != nil:
+			return SolveResult{}, fmt.Errorf("%v; give min and max to solve numerically", err)
+		case method == "exact":
+			return SolveResult{}, fmt.Errorf("exact solutions need a polynomial equation in %s of degree at most %d with rational coefficients; give min and max to solve numerically", x, maxSolveDegree)
+		case err != nil:
+			// Too large to solve exactly, the polynomial is searched for
+			// roots like any other function when there is somewhere to look.
+			if lo, hi, guess := param.points(); (lo == nil || hi == nil) && guess == nil {
+				return SolveResult{}, fmt.Errorf("%v, so it is solved numerically: give min and max to search an interval, or guess to start Newton's method", err)
+			}
+		}
+	}
+	return solveNumerically(node, x, method, param)
+}
+
+// equationPolynomial returns the left minus right side of an equation as a
+// polynomial in x with rational coefficients, if it is one. ok is false when
+// it is not; err tells why a polynomial cannot be solved exactly, when its
+// degree or expansion passes a limit.
+func equationPolynomial(node *exprNode, x string) (poly ratPoly, ok bool, err error) {
+	polynomial := false
+	defer func() {
+		switch {
+		case err != nil && !polynomial:
+			poly, ok, err = nil, false, nil
+		case err != nil:
+			poly, ok = nil, true
+			err = fmt.Errorf("the equation is a polynomial in %s, but too large to solve exactly: %v", x, err)
+		}
+	}()
+	defer catchSymbolic(&err)
+
+	s, err := symFromExpr(node)
+	if err != nil || !isPolynomial(s, x) {
+		return nil, false, nil
+	}
+	polynomial = true
+	terms := termsOf(expand(s))
+	degrees := make([]int64, len(terms))
+	for i, t := range terms {
+		_, rest := splitCoefficient(t)
+		exp := big.NewRat(0, 1)
+		switch {
+		case rest.kind == symVariable:
+			exp = big.NewRat(1, 1)
+		case rest.kind == symPower:
+			exp = rest.args[1].num
+		}
+		if exp.Cmp(big.NewRat(maxSolveDegree, 1)) > 0 {
+			return nil, true, fmt.Errorf("its degree, %s, is above the limit of %d", exp.RatString(), maxSolveDegree)
+		}
+		degrees[i] = exp.Num().Int64()
+	}
+	var coeffs []*big.Rat
+	for i, t := range terms {
+		c, _ := splitCoefficient(t)
+		for int64(len(coeffs)) <= degrees[i] {
+			coeffs = append(coeffs, new(big.Rat))
+		}
+		coeffs[degrees[i]].Add(coeffs[degrees[i]], c)
+	}
+	return trimPoly(coeffs), true, nil
+}
+
+// isPolynomial reports whether s is built from rational numbers and x by
+// sums, products and whole-number powers.
+func isPolynomial(s *sym, x string) bool {
+	switch s.kind {
+	case symNumber:
+		return true
+	case symVariable:
+		return s.name == x
+	case symSum, symProduct:
+		for _, arg := range s.args {
+			if !isPolynomial(arg, x) {
+				return false
+			}
+		}
+		return true
+	case symPower:
+		exp := s.args[1]
+		return exp.isNumber() && exp.num.IsInt() && exp.num.Sign() > 0 && isPolynomial(s.args[0], x)
+	}
+	return false
+}
+
+// points returns min, max and guess, nil where they are not given. The
+// numbers must already have passed checkNumbers.
+func (p SolveParams) points() (lo, hi, guess *float64) {
+	lo, _ = optionalFloat(p.Min)
+	hi, _ = optionalFloat(p.Max)
+	guess, _ = optionalFloat(p.Guess)
+	return lo, hi, guess
+}
+
+// solvePolynomial returns the roots of poly = 0, restricted to real roots
+// in [min, max] when an interval is given.
+func solvePolynomial(poly ratPoly, x string, param SolveParams) (result SolveResult, err error) {
+	defer catchSymbolic(&err)
+
+	result.Variable = x
+	switch poly.degree() {
+	case -1:
+		result.Note = fmt.Sprintf("both sides are equal for every value of %s", x)
+		return result, nil
+	case 0:
+		result.Note = fmt.Sprintf("no value of %s satisfies the equation", x)
+		return result, nil
+	}
+	roots, err := polynomialRoots(poly)
+	if err != nil {
+		return SolveResult{}, err
+	}
+	lo, hi, _ := param.points()
+	interval := lo != nil && hi != nil
+	for _, r := range roots {
+		if interval && (imag(r.value) != 0 || real(r.value) < *lo || real(r.value) > *hi) {
+			continue
+		}
+		root := SolveRoot{
+			Value:        real(r.value),
+			Imaginary:    imag(r.value),
+			Multiplicity: r.multiplicity,
+			Method:       r.method,
+		}
+		if r.exact() {
+			root.Exact = complexString(r.re, r.im, false)
+			root.LaTeX = complexString(r.re, r.im, true)
+		}
+		result.Roots = append(result.Roots, root)
+	}
+	switch {
+	case interval && len(result.Roots) == 0:
+		result.Note = fmt.Sprintf("the polynomial has no real roots in [%g, %g]", *lo, *hi)
+	case slices.ContainsFunc(roots, func(r polyRoot) bool { return !r.exact() }):
+		result.Note = "rational roots are exact; the roots of the remaining factors of degree 5 or more are numeric"
+	}
+	return result, nil
+}
+
+// solveNumerically finds real roots of f(x) = 0, with f the left minus the
+// right side, by Brent's method and Newton's method.
+func solveNumerically(node *exprNode, x, method string, param SolveParams) (SolveResult, error) {
+	result := SolveResult{Variable: x}
+	f := func(v float64) float64 {
+		result.Evaluations++
+		y, err := evalExpr(node, map[string]float64{x: v})
+		if err != nil {
+			return math.NaN()
+		}
+		return y
+	}
+	df, derivativeNote := derivativeOf(node, x, f)
+	tol := defaultSolveTolerance
+	if param.Tolerance != nil {
+		tol = *param.Tolerance
+	}
+	maxIter := param.MaxIterations
+	if maxIter == 0 {
+		maxIter = defaultSolveIterations
+	}
+	lower, upper, guess := param.points()
+	interval := lower != nil && upper != nil
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if interval {
+		lo, hi = *lower, *upper
+	}
+
+	addRoot := func(v float64, iterations int, method string) {
+		residual := math.Abs(f(v))
+		result.Roots = append(result.Roots, SolveRoot{Value: v, Method: method, Residual: &residual, Iterations: iterations})
+	}
+
+	switch method {
+	case "brent":
+		if !interval {
+			return SolveResult{}, errors.New("brent needs an interval: give min and max")
+		}
+		flo, fhi := f(lo), f(hi)
+		if err := checkBracket(lo, hi, flo, fhi); err != nil {
+			return SolveResult{}, err
+		}
+		root, iterations, err := brent(f, lo, hi, flo, fhi, tol, maxIter)
+		if err != nil {
+			return SolveResult{}, err
+		}
+		if err := checkContinuous(f, root, flo, fhi); err != nil {
+			return SolveResult{}, err
+		}
+		addRoot(root, iterations, "Brent's method")
+	case "newton":
+		if guess == nil && !interval {
+			return SolveResult{}, errors.New("newton needs a starting point: give guess, or min and max")
+		}
+		x0 := (lo + hi) / 2
+		if guess != nil {
+			x0 = *guess
+		}
+		root, iterations, err := newton(f, df, x0, lo, hi, tol, maxIter)
+		if err != nil {
+			return SolveResult{}, err
+		}
+		addRoot(root, iterations, "Newton's method")
+	default:
+		if !interval && guess == nil {
+			return SolveResult{}, fmt.Errorf("the equation is not a polynomial in %s with rational coefficients, so it is solved numerically: give min and max to search an interval, or guess to start Newton's method", x)
+		}
+		var poles []string
+		if interval {
+			roots, discontinuities, err := scanRoots(f, lo, hi, tol, maxIter)
+			if err != nil {
+				return SolveResult{}, err
+			}
+			for _, r := range roots {
+				addRoot(r.value, r.iterations, "Brent's method")
+			}
+			for _, d := range discontinuities {
+				poles = append(poles, fmt.Sprintf("%.6g", d))
+			}
+		}
+		if len(result.Roots) == 0 {
+			x0 := (lo + hi) / 2
+			if guess != nil {
+				x0 = *guess
+			}
+			root, iterations, err := newton(f, df, x0, lo, hi, tol, maxIter)
+			if err != nil {
+				if interval {
+					return SolveResult{}, fmt.Errorf("f has no sign change in [%g, %g] over %d subintervals, and the fallback failed: %v", lo, hi, scanIntervals, err)
+				}
+				return SolveResult{}, err
+			}
+			addRoot(root, iterations, "Newton's method")
+		} else {
+			result.Note = fmt.Sprintf("roots found at the sign changes of f over %d subintervals of [%g, %g]; roots where f touches zero without changing sign can be missed", scanIntervals, lo, hi)
+		}
+		if len(poles) > 0 {
+			result.Note = strings.TrimPrefix(result.Note+"; ", "; ") + "f changes sign without a root (a discontinuity) near " + strings.Join(poles, ", ")
+		}
+	}
+	if derivativeNote != "" && method != "brent" {
+		result.Note = strings.TrimPrefix(result.Note+"; ", "; ") + derivativeNote
+	}
+	return result, nil
+}
+
+// derivativeOf returns the derivative of node in x, symbolic where
+// possible and a central difference of f otherwise, with a note on which.
+func derivativeOf(node *exprNode, x string, f func(float64) float64) (func(float64) float64, string) {
+	var d *sym
+	err := func() (err error) {
+		defer catchSymbolic(&err)
+		s, err := symFromExpr(node)
+		if err != nil {
+			return err
+		}
+		d = differentiate(s, x)
+		return nil
+	}()
+	if err == nil {
+		return func(v float64) float64 {
+			y, ok := d.eval(map[string]float64{x: v})
+			if !ok {
+				return math.NaN()
+			}
+			return y
+		}, ""
+	}
+	return func(v float64) float64 {
+		h := math.Cbrt(0x1p-52) * max(1, math.Abs(v))
+		return (f(v+h) - f(v-h)) / (2 * h)
+	}, "Newton's method used a numeric derivative because " + err.Error()
+}
+
+// checkBracket reports whether f(lo) and f(hi) have opposite signs.
+func checkBracket(lo, hi, flo, fhi float64) error {
+	switch {
+	case math.IsNaN(flo):
+		return fmt.Errorf("f is not defined at min = %g", lo)
+	case math.IsNaN(fhi):
+		return fmt.Errorf("f is not defined at max = %g", hi)
+	case flo == 0 || fhi == 0:
+		return nil
+	case (flo > 0) == (fhi > 0):
+		return fmt.Errorf("brent needs f(min) and f(max) of opposite signs, but f(%g) = %g and f(%g) = %g", lo, flo, hi, fhi)
+	}
+	return nil
+}
+
+// checkContinuous rejects a sign change that Brent's method has narrowed
+// down to a pole or jump rather than a root: there |f| stays large.
+func checkContinuous(f func(float64) float64, root, fa, fb float64) error {
+	if y := math.Abs(f(root)); y > 1e-6*max(1, math.Min(math.Abs(fa), math.Abs(fb))) {
+		return fmt.Errorf("f changes sign at x = %g but |f(x)| = %g there: the function is discontinuous, not zero", root, y)
+	}
+	return nil
+}
+
+type scannedRoot struct {
+	value      float64
+	iterations int
+}
+
+// scanRoots cuts [lo, hi] into scanIntervals pieces and runs Brent's method
+// on each piece where f changes sign. Sign changes at discontinuities are
+// returned separately.
+func scanRoots(f func(float64) float64, lo, hi, tol float64, maxIter int) ([]scannedRoot, []float64, error) {
+	var roots []scannedRoot
+	var discontinuities []float64
+	step := (hi - lo) / scanIntervals
+	a, fa := lo, f(lo)
+	if fa == 0 {
+		roots = append(roots, scannedRoot{lo, 0})
+	}
+	for i := 1; i <= scanIntervals; i++ {
+		b := lo + float64(i)*step
+		if i == scanIntervals {
+			b = hi
+		}
+		fb := f(b)
+		switch {
+		case fb == 0:
+			roots = append(roots, scannedRoot{b, 0})
+		case math.IsNaN(fa) || math.IsNaN(fb) || fa == 0:
+		case (fa > 0) != (fb > 0):
+			root, iterations, err := brent(f, a, b, fa, fb, tol, maxIter)
+			if err != nil {
+				return nil, nil, err
+			}
+			if checkContinuous(f, root, fa, fb) != nil {
+				discontinuities = append(discontinuities, root)
+			} else {
+				roots = append(roots, scannedRoot{root, iterations})
+			}
+		}
+		a, fa = b, fb
+	}
+	return roots, discontinuities, nil
+}
+
+// brent finds a root of f in [a, b], where f(a) and f(b) differ in sign, by
+// Brent's method: inverse quadratic interpolation and secant steps, with
+// bisection whenever they would converge too slowly. The root is located
+// to within tol plus a relative 4.4e-16.
+func brent(f func(float64) float64, a, b, fa, fb, tol float64, maxIter int) (float64, int, error) {
+	if fa == 0 {
+		return a, 0, nil
+	}
+	if fb == 0 {
+		return b, 0, nil
+	}
+	c, fc := b, fb
+	var d, e float64
+	for iteration := 1; iteration <= maxIter; iteration++ {
+		if (fb > 0) == (fc > 0) {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		tol1 := 2*0x1p-52*math.Abs(b) + tol/2
+		xm := (c - b) / 2
+		if math.Abs(xm) <= tol1 || fb == 0 {
+			return b, iteration, nil
+		}
+		if math.Abs(e) >= tol1 && math.Abs(fa) > math.Abs(fb) {
+			var p, q float64
+			s := fb / fa
+			if a == c {
+				// Secant step.
+				p = 2 * xm * s
+				q = 1 - s
+			} else {
+				// Inverse quadratic interpolation.
+				q = fa / fc
+				r := fb / fc
+				p = s * (2*xm*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			}
+			p = math.Abs(p)
+			if 2*p < math.Min(3*xm*q-math.Abs(tol1*q), math.Abs(e*q)) {
+				e = d
+				d = p / q
+			} else {
+				d = xm
+				e = d
+			}
+		} else {
+			d = xm
+			e = d
+		}
+		a, fa = b, fb
+		if math.Abs(d) > tol1 {
+			b += d
+		} else {
+			b += math.Copysign(tol1, xm)
+		}
+		fb = f(b)
+		if math.IsNaN(fb) || math.IsInf(fb, 0) {
+			return 0, iteration, fmt.Errorf("Brent's method stopped: f is not defined at x = %g", b)
+		}
+	}
+	return 0, maxIter, fmt.Errorf("Brent's method did not converge in %d iterations: the root is in [%g, %g], where f = %g and %g; raise max_iterations or tolerance",
+		maxIter, math.Min(b, c), math.Max(b, c), fb, fc)
+}
+
+// newton finds a root of f by Newton's method from x0. While f(lo) and
+// f(hi) differ in sign it keeps a bracket around the root and bisects
+// whenever a step would leave it or the derivative vanishes; otherwise a
+// step that leaves [lo, hi] or lands where f is undefined is halved.
+func newton(f, df func(float64) float64, x0, lo, hi, tol float64, maxIter int) (float64, int, error) {
+	if x0 < lo || x0 > hi {
+		return 0, 0, fmt.Errorf("the starting point %g is outside [%g, %g]", x0, lo, hi)
+	}
+	x, fx := x0, f(x0)
+	if math.IsNaN(fx) || math.IsInf(fx, 0) {
+		return 0, 0, fmt.Errorf("f is not defined at the starting point x = %g", x0)
+	}
+	if fx == 0 {
+		return x, 0, nil
+	}
+	a, b := lo, hi
+	fa, fb := math.NaN(), math.NaN()
+	if !math.IsInf(lo, 0) && !math.IsInf(hi, 0) {
+		fa, fb = f(lo), f(hi)
+	}
+	bracketed := !math.IsNaN(fa) && !math.IsNaN(fb) && fa != 0 && fb != 0 && (fa > 0) != (fb > 0)
+
+	var step float64
+	for iteration := 1; iteration <= maxIter; iteration++ {
+		d := df(x)
+		step = fx / d
+		next := x - step
+		switch {
+		case bracketed && (d == 0 || math.IsNaN(step) || next <= a || next >= b):
+			next = a + (b-a)/2
+		case d == 0 || math.IsNaN(step) || math.IsInf(step, 0):
+			return 0, iteration, fmt.Errorf("Newton's method stopped at x = %g, where f(x) = %g and the derivative is %g; try another guess or give min and max around a sign change", x, fx, d)
+		}
+		fnext := f(next)
+		for halvings := 0; !bracketed && (next < lo || next > hi || math.IsNaN(fnext) || math.IsInf(fnext, 0)); halvings++ {
+			if halvings == 60 {
+				return 0, iteration, fmt.Errorf("Newton's method stopped at x = %g, where f(x) = %g: every step leaves [%g, %g] or the domain of f", x, fx, lo, hi)
+			}
+			next = x - (x-next)/2
+			fnext = f(next)
+		}
+		if math.IsNaN(fnext) || math.IsInf(fnext, 0) {
+			return 0, iteration, fmt.Errorf("Newton's method stopped: f is not defined at x = %g", next)
+		}
+		if bracketed {
+			if (fnext > 0) == (fa > 0) {
+				a, fa = next, fnext
+			} else {
+				b = next
+			}
+		}
+		if fnext == 0 {
+			return next, iteration, nil
+		}
+		if math.Abs(next-x) <= tol+4*0x1p-52*math.Abs(next) {
+			// Tiny steps far from a zero of f mean Newton's method is
+			// stuck, typically against an end of [lo, hi].
+			if math.Abs(fnext) > 1e-6*max(1, math.Abs(f(x0))) {
+				return 0, iteration, fmt.Errorf("Newton's method stalled at x = %g, where f(x) = %g is not close to 0; the equation may have no root in [%g, %g]", next, fnext, lo, hi)
+			}
+			return next, iteration, nil
+		}
+		x, fx = next, fnext
+	}
+	return 0, maxIter, fmt.Errorf("Newton's method did not converge in %d iterations from x0 = %g: last x = %g, f(x) = %g, last step = %g; try another guess, give min and max around a sign change, or raise max_iterations",
+		maxIter, x0, x, fx, step)
+}
+
+// solveLinearSystem solves a system of linear equations with rational
+// coefficients exactly by Gauss–Jordan elimination.
+func solveLinearSystem(equations, unknowns []string) (result SolveResult, err error) {
+	defer catchSymbolic(&err)
+
+	sides := make([]*sym, len(equations))
+	var all []string
+	for i, eq := range equations {
+		node, err := equationSides(eq)
+		if err != nil {
+			return SolveResult{}, invalidEquationError{fmt.Errorf("equation %d: %v", i+1, err)}
+		}
+		s, err := symFromExpr(node)
+		if err != nil {
+			return SolveResult{}, invalidEquationError{fmt.Errorf("equation %d: %v", i+1, err)}
+		}
+		sides[i] = expand(s)
+		for _, v := range sides[i].variables() {
+			if !slices.Contains(all, v) {
+				all = append(all, v)
+			}
+		}
+	}
+	slices.Sort(all)
+	if len(unknowns) == 0 {
+		unknowns = all
+	}
+	for _, v := range all {
+		if !slices.Contains(unknowns, v) {
+			return SolveResult{}, fmt.Errorf("%s appears in the equations but not in variables", v)
+		}
+	}
+	if len(unknowns) == 0 {
+		return SolveResult{}, errors.New("the equations have no unknowns")
+	}
+	if len(unknowns) > maxSystemSize {
+		return SolveResult{}, fmt.Errorf("at most %d unknowns are supported", maxSystemSize)
+	}
+
+	// The augmented matrix [A | b] of A·x = b.
+	n := len(unknowns)
+	rows := make([][]*big.Rat, len(sides))
+	for i, s := range sides {
+		row := make([]*big.Rat, n+1)
+		for j := range row {
+			row[j] = new(big.Rat)
+		}
+		for _, t := range termsOf(s) {
+			c, rest := splitCoefficient(t)
+			if rest.isInt(1) {
+				row[n].Sub(row[n], c)
+				continue
+			}
+			j := -1
+			if rest.kind == symVariable {
+				j = slices.Index(unknowns, rest.name)
+			}
+			if j < 0 {
+				return SolveResult{}, fmt.Errorf("equation %d is not linear with rational coefficients: it has the term %s", i+1, t)
+			}
+			row[j].Add(row[j], c)
+		}
+		rows[i] = row
+	}
+
+	// Reduce to reduced row echelon form.
+	var pivots []int
+	r := 0
+	for col := 0; col < n && r < len(rows); col++ {
+		p := slices.IndexFunc(rows[r:], func(row []*big.Rat) bool { return row[col].Sign() != 0 })
+		if p < 0 {
+			continue
+		}
+		rows[r], rows[r+p] = rows[r+p], rows[r]
+		inv := new(big.Rat).Inv(rows[r][col])
+		for j := range rows[r] {
+			rows[r][j].Mul(rows[r][j], inv)
+		}
+		for i := range rows {
+			if i == r || rows[i][col].Sign() == 0 {
+				continue
+			}
+			factor := new(big.Rat).Set(rows[i][col])
+			for j := range rows[i] {
+				rows[i][j].Sub(rows[i][j], new(big.Rat).Mul(factor, rows[r][j]))
+			}
+		}
+		pivots = append(pivots, col)
+		r++
+	}
+	for _, row := range rows[r:] {
+		if row[n].Sign() != 0 {
+			result.Status = "inconsistent"
+			result.Note = "the equations contradict each other, so the system has no solution"
+			return result, nil
+		}
+	}
+
+	result.Status = "unique"
+	for col := range n {
+		if !slices.Contains(pivots, col) {
+			result.Free = append(result.Free, unknowns[col])
+		}
+	}
+	if len(result.Free) > 0 {
+		result.Status = "infinite"
+		result.Note = fmt.Sprintf("the system has infinitely many solutions; %s can take any value", strings.Join(result.Free, ", "))
+	}
+	for col, name := range unknowns {
+		value := symVar(name)
+		if i := slices.Index(pivots, col); i >= 0 {
+			terms := []*sym{symRat(rows[i][n])}
+			for j := range n {
+				if j != col && rows[i][j].Sign() != 0 {
+					terms = append(terms, symMul(symRat(new(big.Rat).Neg(rows[i][j])), symVar(unknowns[j])))
+				}
+			}
+			value = symAdd(terms...)
+		}
+		assignment := SolveAssignment{Variable: name, Exact: value.String(), LaTeX: value.latex()}
+		if v, ok := value.float(); ok && len(value.variables()) == 0 {
+			assignment.Value = &v
+		}
+		result.Solution = append(result.Solution, assignment)
+	}
+	return result, nil
+}
+
+// formatSolveResult renders the result as text.
+func formatSolveResult(r SolveResult) string {
+	var b strings.Builder
+	for _, root := range r.Roots {
+		value := fmt.Sprintf("%.15g", root.Value)
+		if root.Imaginary != 0 {
+			value = fmt.Sprintf("%.15g %+.15gi", root.Value, root.Imaginary)
+		}
+		if root.Exact != "" && root.Exact != value {
+			fmt.Fprintf(&b, "%s = %s ≈ %s", r.Variable, root.Exact, value)
+		} else {
+			fmt.Fprintf(&b, "%s = %s", r.Variable, value)
+		}
+		if root.Multiplicity > 1 {
+			fmt.Fprintf(&b, " (multiplicity %d)", root.Multiplicity)
+		}
+		fmt.Fprintf(&b, " [%s]\n", root.Method)
+	}
+	for _, a := range r.Solution {
+		if a.Value != nil && a.Exact != fmt.Sprintf("%.15g", *a.Value) {
+			fmt.Fprintf(&b, "%s = %s ≈ %.15g\n", a.Variable, a.Exact, *a.Value)
+		} else {
+			fmt.Fprintf(&b, "%s = %s\n", a.Variable, a.Exact)
+		}
+	}
+	if len(r.Roots) == 0 && len(r.Solution) == 0 && r.Note == "" {
+		b.WriteString("No solutions\n")
+	}
+	if r.Note != "" {
+		fmt.Fprintf(&b, "Note: %s\n", r.Note)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxStatisticsValues bounds the size of a dataset accepted by the
+// statistics tool.
+const maxStatisticsValues = 1_000_000
+
+// StatisticsParams defines the parameters for the statistics tool.
+type StatisticsParams struct {
+	Data        []interface{} `json:"data" jsonschema:"the numbers to summarize, each a number or a string such as \"1,234.5\" or \"3/4\""`
+	Percentiles []float64     `json:"percentiles,omitempty" jsonschema:"percentiles to compute, each between 0 and 100 (default: 25, 50, 75)"`
+}
+
+func (p StatisticsParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Data,
+			validation.Required.Error("must contain at least one number"),
+			validation.Length(0, maxStatisticsValues),
+			validation.By(func(value interface{}) error {
+				for i, v := range p.Data {
+					if _, err := floatValue(v); err != nil {
+						return fmt.Errorf("value at index %d: %v", i, err)
+					}
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Percentiles,
+			validation.Each(validation.Min(0.0), validation.Max(100.0)),
+		),
+	)
+}
+
+// values returns data as float64s. The parameters must already have passed
+// Validate.
+func (p StatisticsParams) values() []float64 {
+	data := make([]float64, len(p.Data))
+	for i, v := range p.Data {
+		data[i], _ = floatValue(v)
+	}
+	return data
+}
+
+// Percentile is one requested percentile of a dataset.
+type Percentile struct {
+	Percent float64 `json:"percent" jsonschema:"requested percentile between 0 and 100"`
+	Value   float64 `json:"value" jsonschema:"value at that percentile, linearly interpolated between closest ranks"`
+}
+
+// StatisticsResult defines the result for the statistics tool.
+type StatisticsResult struct {
+	Count              int          `json:"count" jsonschema:"number of values"`
+	Sum                float64      `json:"sum" jsonschema:"sum of the values"`
+	Mean               float64      `json:"mean" jsonschema:"arithmetic mean"`
+	Median             float64      `json:"median" jsonschema:"median"`
+	Mode               []float64    `json:"mode" jsonschema:"most frequent values; empty when every value occurs once"`
+	ModeCount          int          `json:"mode_count" jsonschema:"number of occurrences of each mode"`
+	Min                float64      `json:"min" jsonschema:"smallest value"`
+	Max                float64      `json:"max" jsonschema:"largest value"`
+	Range              float64      `json:"range" jsonschema:"max minus min"`
+	PopulationVariance float64      `json:"population_variance" jsonschema:"population variance (divides by n)"`
+	PopulationStdDev   float64      `json:"population_stddev" jsonschema:"population standard deviation"`
+	SampleVariance     *float64     `json:"sample_variance,omitempty" jsonschema:"sample variance (divides by n-1); omitted for a single value"`
+	SampleStdDev       *float64     `json:"sample_stddev,omitempty" jsonschema:"sample standard deviation; omitted for a single value"`
+	Skewness           *float64     `json:"skewness,omitempty" jsonschema:"population skewness; omitted when all values are equal"`
+	Kurtosis           *float64     `json:"kurtosis,omitempty" jsonschema:"population excess kurtosis; omitted when all values are equal"`
+	Percentiles        []Percentile `json:"percentiles" jsonschema:"requested percentiles"`
+}
+
+func handleStatistics(ctx context.Context, req *mcp.CallToolRequest, param StatisticsParams) (*mcp.CallToolResult, StatisticsResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			StatisticsResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	percents := param.Percentiles
+	if len(percents) == 0 {
+		percents = []float64{25, 50, 75}
+	}
+
+	result, err := describe(param.values(), percents)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			StatisticsResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	text := fmt.Sprintf("Count: %d, Sum: %g, Mean: %g, Median: %g, Min: %g, Max: %g, Population stddev: %g",
+		result.Count, result.Sum, result.Mean, result.Median, result.Min, result.Max, result.PopulationStdDev)
+	if result.SampleStdDev != nil {
+		text += fmt.Sprintf(", Sample stddev: %g", *result.SampleStdDev)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// describe computes summary statistics of data. Moments are accumulated in a
+// single pass with the update formulas of Welford and Terriberry, which stay
+// accurate for large inputs; the sum uses Neumaier compensation. Median and
+// percentiles need a sorted copy of the data.
+func describe(data []float64, percents []float64) (StatisticsResult, error) {
+	var (
+		n                 float64
+		mean, m2, m3, m4  float64
+		sum, compensation float64
+		minimum, maximum  = math.Inf(1), math.Inf(-1)
+		counts            = make(map[float64]int)
+		modeCount         int
+	)
+	for _, x := range data {
+		n1 := n
+		n++
+		delta := x - mean
+		deltaN := delta / n
+		deltaN2 := deltaN * deltaN
+		term1 := delta * deltaN * n1
+		mean += deltaN
+		m4 += term1*deltaN2*(n*n-3*n+3) + 6*deltaN2*m2 - 4*deltaN*m3
+		m3 += term1*deltaN*(n-2) - 3*deltaN*m2
+		m2 += term1
+
+		t := sum + x
+		if math.Abs(sum) >= math.Abs(x) {
+			compensation += (sum - t) + x
+		} else {
+			compensation += (x - t) + sum
+		}
+		sum = t
+
+		minimum = math.Min(minimum, x)
+		maximum = math.Max(maximum, x)
+		counts[x]++
+		modeCount = max(modeCount, counts[x])
+	}
+	sum += compensation
+
+	result := StatisticsResult{
+		Count:              len(data),
+		Sum:                sum,
+		Mean:               mean,
+		Min:                minimum,
+		Max:                maximum,
+		Range:              maximum - minimum,
+		PopulationVariance: m2 / n,
+		PopulationStdDev:   math.Sqrt(m2 / n),
+		Mode:               []float64{},
+	}
+	if len(data) > 1 {
+		variance := m2 / (n - 1)
+		stddev := math.Sqrt(variance)
+		result.SampleVariance = &variance
+		result.SampleStdDev = &stddev
+	}
+	if m2 > 0 {
+		skewness := math.Sqrt(n) * m3 / math.Pow(m2, 1.5)
+		kurtosis := n*m4/(m2*m2) - 3
+		result.Skewness = &skewness
+		result.Kurtosis = &kurtosis
+	}
+	if modeCount > 1 {
+		for v, c := range counts {
+			if c == modeCount {
+				result.Mode = append(result.Mode, v)
+			}
+		}
+		slices.Sort(result.Mode)
+		result.ModeCount = modeCount
+	}
+
+	sorted := slices.Clone(data)
+	slices.Sort(sorted)
+	result.Median = quantile(sorted, 0.5)
+	for _, p := range percents {
+		result.Percentiles = append(result.Percentiles, Percentile{Percent: p, Value: quantile(sorted, p/100)})
+	}
+
+	for _, v := range []float64{result.Sum, result.Mean, result.PopulationVariance, result.Range} {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return StatisticsResult{}, errors.New("values are too large to summarize in float64")
+		}
+	}
+	if result.SampleVariance != nil && math.IsInf(*result.SampleVariance, 0) {
+		return StatisticsResult{}, errors.New("values are too large to summarize in float64")
+	}
+	if result.Kurtosis != nil && (math.IsInf(*result.Kurtosis, 0) || math.IsNaN(*result.Kurtosis)) {
+		result.Skewness, result.Kurtosis = nil, nil
+	}
+	return result, nil
+}
+
+// quantile returns the q-th quantile (0 <= q <= 1) of sorted data using
+// linear interpolation between closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return (1-frac)*sorted[lower] + frac*sorted[upper]
+}
@@ -0,0 +1,645 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxDerivativeOrder bounds the order of the differentiate operation.
+	maxDerivativeOrder = 10
+	maxSymbolicLength  = 4096
+)
+
+var symbolicOperations = []interface{}{"simplify", "expand", "factor", "differentiate", "substitute"}
+
+// identifierPattern matches a variable name of the expression syntax.
+var identifierPattern = regexp.MustCompile(`^[\pL_][\pL\pN_]*$`)
+
+// SymbolicParams defines the parameters for the symbolic tool.
+type SymbolicParams struct {
+	Expression string            `json:"expression" jsonschema:"expression with variables, e.g. (x + 1)^3 - 2*x*y; supports + - * / ^, parentheses, the functions and constants of the evaluate tool"`
+	Operation  string            `json:"operation" jsonschema:"simplify, expand, factor, differentiate or substitute"`
+	Variable   string            `json:"variable,omitempty" jsonschema:"differentiate only: the variable to differentiate with respect to (default: the only variable of the expression)"`
+	Order      int               `json:"order,omitempty" jsonschema:"differentiate only: how many times to differentiate (default: 1)"`
+	Values     map[string]string `json:"values,omitempty" jsonschema:"substitute only: the value of each variable to replace, as a number such as 2.5 or an expression such as y + 1"`
+}
+
+func (p SymbolicParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Expression, validation.Required, validation.Length(1, maxSymbolicLength)),
+		validation.Field(&p.Operation, validation.Required, validation.In(symbolicOperations...)),
+		validation.Field(&p.Variable,
+			validation.When(p.Operation != "differentiate", validation.Empty.Error("only applies to differentiate")),
+			validation.Match(identifierPattern).Error("must be a variable name"),
+		),
+		validation.Field(&p.Order,
+			validation.When(p.Operation != "differentiate", validation.Empty.Error("only applies to differentiate")),
+			validation.Min(0),
+			validation.Max(maxDerivativeOrder),
+		),
+		validation.Field(&p.Values,
+			validation.When(p.Operation == "substitute", validation.Required.Error("is required for substitute")),
+			validation.When(p.Operation != "substitute", validation.Empty.Error("only applies to substitute")),
+			validation.By(func(value interface{}) error {
+				for name, v := range p.Values {
+					if !identifierPattern.MatchString(name) {
+						return fmt.Errorf("%q is not a variable name", name)
+					}
+					if strings.TrimSpace(v) == "" || len(v) > maxSymbolicLength {
+						return fmt.Errorf("value of %s must be between 1 and %d characters long", name, maxSymbolicLength)
+					}
+				}
+				return nil
+			}),
+		),
+	)
+}
+
+// SymbolicResult defines the result for the symbolic tool.
+type SymbolicResult struct {
+	Result    string   `json:"result" jsonschema:"the result in canonical form, in the syntax of the expression parameter"`
+	LaTeX     string   `json:"latex" jsonschema:"the result as LaTeX"`
+	Variables []string `json:"variables" jsonschema:"variables left in the result, sorted"`
+	Value     *float64 `json:"value,omitempty" jsonschema:"numeric value of the result when no variables are left"`
+	Factors   []string `json:"factors,omitempty" jsonschema:"factor only: the factors of the result"`
+	Note      string   `json:"note,omitempty" jsonschema:"remarks on the result, such as a factor that may factor further"`
+}
+
+func handleSymbolic(ctx context.Context, req *mcp.CallToolRequest, param SymbolicParams) (*mcp.CallToolResult, SymbolicResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			SymbolicResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	s, err := parseSymbolic(param.Expression)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Invalid expression: %v", err)),
+			SymbolicResult{}, fmt.Errorf("invalid expression: %v", err)
+	}
+
+	result, err := runSymbolic(s, param)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Calculation error: %v", err)),
+			SymbolicResult{}, fmt.Errorf("calculation error: %v", err)
+	}
+
+	text := fmt.Sprintf("Result: %s\nLaTeX: %s", result.Result, result.LaTeX)
+	if result.Value != nil {
+		text += fmt.Sprintf("\nValue: %g", *result.Value)
+	}
+	if result.Note != "" {
+		text += "\nNote: " + result.Note
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, result, nil
+}
+
+// runSymbolic applies the operation of param to s.
+func runSymbolic(s *sym, param SymbolicParams) (result SymbolicResult, err error) {
+	defer catchSymbolic(&err)
+
+	switch param.Operation {
+	case "expand":
+		s = expand(s)
+	case "factor":
+		var factors []*sym
+		s, factors, result.Note = factor(s)
+		for _, f := range factors {
+			result.Factors = append(result.Factors, f.String())
+		}
+	case "differentiate":
+		x := param.Variable
+		if x == "" {
+			vars := s.variables()
+			if len(vars) != 1 {
+				return SymbolicResult{}, fmt.Errorf("the expression has %d variables (%s); choose one with variable", len(vars), strings.Join(vars, ", "))
+			}
+			x = vars[0]
+		}
+		for range max(param.Order, 1) {
+			s = differentiate(s, x)
+		}
+	case "substitute":
+		values := make(map[string]*sym, len(param.Values))
+		for name, text := range param.Values {
+			v, err := parseSymbolic(text)
+			if err != nil {
+				return SymbolicResult{}, fmt.Errorf("invalid value for %s: %v", name, err)
+			}
+			values[name] = v
+		}
+		s = substitute(s, values)
+	}
+
+	result.Result = s.String()
+	result.LaTeX = s.latex()
+	result.Variables = s.variables()
+	if len(result.Variables) == 0 {
+		if v, ok := s.float(); ok {
+			result.Value = &v
+		}
+	}
+	return result, nil
+}
+
+// parseSymbolic parses input with the expression parser and converts the
+// tree into canonical form.
+func parseSymbolic(input string) (s *sym, err error) {
+	defer catchSymbolic(&err)
+
+	node, err := parseExpression(input)
+	if err != nil {
+		return nil, err
+	}
+	return symFromExpr(node)
+}
+
+func symFromExpr(node *exprNode) (*sym, error) {
+	args := make([]*sym, len(node.args))
+	for i, arg := range node.args {
+		s, err := symFromExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = s
+	}
+	switch node.kind {
+	case nodeNumber:
+		r, err := parseDecimal(node.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", node.text, node.pos)
+		}
+		return symRat(r), nil
+	case nodeIdent:
+		return symVar(node.text), nil
+	case nodeUnary:
+		return symMul(symInt(-1), args[0]), nil
+	case nodeBinary:
+		a, b := args[0], args[1]
+		switch node.text {
+		case "+":
+			return symAdd(a, b), nil
+		case "-":
+			return symAdd(a, symMul(symInt(-1), b)), nil
+		case "*":
+			return symMul(a, b), nil
+		case "/":
+			if b.isInt(0) {
+				return nil, fmt.Errorf("cannot divide by zero at position %d", node.pos)
+			}
+			return symMul(a, symPow(b, symInt(-1))), nil
+		case "^":
+			return symPow(a, b), nil
+		case "%":
+			if !a.isNumber() || !b.isNumber() {
+				return nil, fmt.Errorf("the %% operator at position %d needs numbers on both sides", node.pos)
+			}
+			r, err := calculateExact("modulo", []*big.Rat{a.num, b.num})
+			if err != nil {
+				return nil, fmt.Errorf("%v at position %d", err, node.pos)
+			}
+			return symRat(r), nil
+		}
+	case nodeCall:
+		if _, ok := exprFunctions[node.text]; !ok {
+			return nil, fmt.Errorf("unknown function %q at position %d", node.text, node.pos)
+		}
+		return symCall(node.text, args...), nil
+	}
+	return nil, fmt.Errorf("unsupported node %q", node.kind)
+}
+
+// float evaluates s numerically, looking up named constants, and reports
+// whether the value is a finite number.
+func (s *sym) float() (float64, bool) {
+	return s.eval(nil)
+}
+
+// eval is float with the variables in vars set to the given values.
+func (s *sym) eval(vars map[string]float64) (float64, bool) {
+	args := make([]float64, len(s.args))
+	for i, arg := range s.args {
+		v, ok := arg.eval(vars)
+		if !ok {
+			return 0, false
+		}
+		args[i] = v
+	}
+	var v float64
+	switch s.kind {
+	case symNumber:
+		v, _ = s.num.Float64()
+	case symVariable:
+		c, ok := vars[s.name]
+		if !ok {
+			if c, ok = mathConstants[s.name]; !ok {
+				return 0, false
+			}
+		}
+		v = c
+	case symSum:
+		for _, a := range args {
+			v += a
+		}
+	case symProduct:
+		v = 1
+		for _, a := range args {
+			v *= a
+		}
+	case symPower:
+		v = math.Pow(args[0], args[1])
+		// math.Pow has no real odd roots of negative numbers.
+		if args[0] < 0 && s.args[1].isNumber() && !s.args[1].num.IsInt() && s.args[1].num.Denom().Bit(0) == 1 {
+			v = -math.Pow(-args[0], args[1])
+			if s.args[1].num.Num().Bit(0) == 0 {
+				v = -v
+			}
+		}
+	case symFunction:
+		var err error
+		if v, err = exprFunctions[s.name].fn(args); err != nil {
+			return 0, false
+		}
+	}
+	return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// Ordering. Terms of a sum are sorted by descending degree and then by
+// their factors, so polynomials print as 3*x^2 + 2*x*y + y^2 - 1; factors
+// of a product are sorted numbers first, then variables, calls and sums.
+
+// degree returns the total degree of s in its variables, with named
+// constants of degree 0 and calls of degree 1.
+func (s *sym) degree() float64 {
+	switch s.kind {
+	case symVariable:
+		if _, constant := mathConstants[s.name]; constant {
+			return 0
+		}
+		return 1
+	case symFunction:
+		return 1
+	case symProduct:
+		d := 0.0
+		for _, f := range s.args {
+			d += f.degree()
+		}
+		return d
+	case symSum:
+		d := 0.0
+		for _, t := range s.args {
+			d = max(d, t.degree())
+		}
+		return d
+	case symPower:
+		if e, ok := s.args[1].float(); ok {
+			return s.args[0].degree() * e
+		}
+		return s.args[0].degree()
+	}
+	return 0
+}
+
+// baseAndExponent splits a factor into its base and numeric exponent, 1
+// for a factor that is not a power or has a symbolic exponent.
+func baseAndExponent(f *sym) (*sym, float64) {
+	if f.kind == symPower {
+		if e, ok := f.args[1].float(); ok {
+			return f.args[0], e
+		}
+	}
+	return f, 1
+}
+
+// factorRank orders the kinds of factor bases in a product.
+func factorRank(base *sym) int {
+	switch base.kind {
+	case symPower:
+		return factorRank(base.args[0])
+	case symNumber:
+		return 0
+	case symVariable:
+		return 1
+	case symFunction:
+		return 2
+	}
+	return 3
+}
+
+func compareFactors(a, b *sym) int {
+	ba, ea := baseAndExponent(a)
+	bb, eb := baseAndExponent(b)
+	if c := factorRank(ba) - factorRank(bb); c != 0 {
+		return c
+	}
+	if c := strings.Compare(ba.String(), bb.String()); c != 0 {
+		return c
+	}
+	switch {
+	case ea > eb:
+		return -1
+	case ea < eb:
+		return 1
+	}
+	return strings.Compare(a.String(), b.String())
+}
+
+func sortFactors(factors []*sym) {
+	slices.SortStableFunc(factors, compareFactors)
+}
+
+func sortTerms(terms []*sym) {
+	slices.SortStableFunc(terms, func(a, b *sym) int {
+		_, ra := splitCoefficient(a)
+		_, rb := splitCoefficient(b)
+		if da, db := ra.degree(), rb.degree(); da != db {
+			if da > db {
+				return -1
+			}
+			return 1
+		}
+		fa, fb := termsOfProduct(ra), termsOfProduct(rb)
+		for i := range min(len(fa), len(fb)) {
+			if c := compareFactors(fa[i], fb[i]); c != 0 {
+				return c
+			}
+		}
+		return len(fa) - len(fb)
+	})
+}
+
+// termsOfProduct returns the factors of a product, or s itself.
+func termsOfProduct(s *sym) []*sym {
+	if s.kind == symProduct {
+		return s.args
+	}
+	return []*sym{s}
+}
+
+// Formatting. String renders s in the expression syntax, so that results
+// can be passed back to this tool or to evaluate; latex renders it for
+// typesetting.
+
+// isNegative reports whether a term prints with a leading minus sign.
+func isNegative(t *sym) bool {
+	c, _ := splitCoefficient(t)
+	return c.Sign() < 0
+}
+
+// negateTerm returns -t without renormalizing, for printing.
+func negateTerm(t *sym) *sym {
+	c, rest := splitCoefficient(t)
+	return scaleTerm(new(big.Rat).Neg(c), rest)
+}
+
+// splitFraction splits a product into a sign, numerator factors and
+// denominator factors with their exponents made positive.
+func splitFraction(s *sym) (negative bool, coeff *big.Rat, num, den []*sym) {
+	coeff, rest := splitCoefficient(s)
+	negative = coeff.Sign() < 0
+	coeff = new(big.Rat).Abs(coeff)
+	for _, f := range termsOfProduct(rest) {
+		if f.kind == symPower && f.args[1].isNumber() && f.args[1].num.Sign() < 0 {
+			den = append(den, invertPower(f))
+		} else if !f.isInt(1) {
+			num = append(num, f)
+		}
+	}
+	return negative, coeff, num, den
+}
+
+// invertPower returns b^-e for the power b^e, without renormalizing.
+func invertPower(f *sym) *sym {
+	e := new(big.Rat).Neg(f.args[1].num)
+	if e.Cmp(big.NewRat(1, 1)) == 0 {
+		return f.args[0]
+	}
+	return compound(symPower, "", []*sym{f.args[0], symRat(e)})
+}
+
+func (s *sym) String() string {
+	switch s.kind {
+	case symNumber:
+		return s.num.RatString()
+	case symVariable:
+		return s.name
+	case symSum:
+		var b strings.Builder
+		for i, t := range s.args {
+			switch {
+			case i == 0:
+				b.WriteString(t.String())
+			case isNegative(t):
+				b.WriteString(" - " + negateTerm(t).String())
+			default:
+				b.WriteString(" + " + t.String())
+			}
+		}
+		return b.String()
+	case symProduct:
+		return formatProduct(s)
+	case symPower:
+		if s.args[1].isNumber() && s.args[1].num.Sign() < 0 {
+			return formatProduct(s)
+		}
+		base, exp := s.args[0], s.args[1]
+		if exp.isNumber() && exp.num.Cmp(big.NewRat(1, 2)) == 0 {
+			return "sqrt(" + base.String() + ")"
+		}
+		b := base.String()
+		if base.kind == symSum || base.kind == symProduct || base.kind == symPower ||
+			base.isNumber() && (base.num.Sign() < 0 || !base.num.IsInt()) {
+			b = "(" + b + ")"
+		}
+		e := exp.String()
+		if !(exp.kind == symVariable || exp.kind == symFunction || exp.isNumber() && exp.num.IsInt()) {
+			e = "(" + e + ")"
+		}
+		return b + "^" + e
+	case symFunction:
+		args := make([]string, len(s.args))
+		for i, arg := range s.args {
+			args[i] = arg.String()
+		}
+		return s.name + "(" + strings.Join(args, ", ") + ")"
+	}
+	return "?"
+}
+
+// formatProduct prints a product or negative power as a fraction, e.g.
+// -3*x/(4*y^2).
+func formatProduct(s *sym) string {
+	negative, coeff, num, den := splitFraction(s)
+	factor := func(f *sym) string {
+		if f.kind == symSum {
+			return "(" + f.String() + ")"
+		}
+		return f.String()
+	}
+	var top, bottom []string
+	if !coeff.Num().IsInt64() || coeff.Num().Int64() != 1 || len(num) == 0 {
+		top = append(top, coeff.Num().String())
+	}
+	if !coeff.IsInt() {
+		bottom = append(bottom, coeff.Denom().String())
+	}
+	for _, f := range num {
+		top = append(top, factor(f))
+	}
+	for _, f := range den {
+		bottom = append(bottom, factor(f))
+	}
+	text := strings.Join(top, "*")
+	if len(bottom) == 1 {
+		text += "/" + bottom[0]
+	} else if len(bottom) > 1 {
+		text += "/(" + strings.Join(bottom, "*") + ")"
+	}
+	if negative {
+		text = "-" + text
+	}
+	return text
+}
+
+// latexNames maps named constants and Greek letters to LaTeX.
+var latexNames = map[string]string{
+	"pi": `\pi`, "e": "e", "golden_ratio": `\varphi`, "euler": `\gamma`,
+	"sqrt2": `\sqrt{2}`, "sqrt3": `\sqrt{3}`, "ln2": `\ln 2`, "ln10": `\ln 10`,
+	"alpha": `\alpha`, "beta": `\beta`, "gamma": `\gamma`, "delta": `\delta`, "epsilon": `\epsilon`,
+	"theta": `\theta`, "lambda": `\lambda`, "mu": `\mu`, "sigma": `\sigma`, "tau": `\tau`,
+	"phi": `\phi`, "omega": `\omega`, "rho": `\rho`, "nu": `\nu`, "kappa": `\kappa`,
+}
+
+// latexFunctions maps functions to LaTeX operators.
+var latexFunctions = map[string]string{
+	"sin": `\sin`, "cos": `\cos`, "tan": `\tan`, "asin": `\arcsin`, "acos": `\arccos`, "atan": `\arctan`,
+	"sinh": `\sinh`, "cosh": `\cosh`, "tanh": `\tanh`, "ln": `\ln`, "log": `\log_{10}`, "log2": `\log_{2}`,
+	"min": `\min`, "max": `\max`,
+}
+
+func latexVariable(name string) string {
+	if l, ok := latexNames[name]; ok {
+		return l
+	}
+	base, sub, ok := strings.Cut(name, "_")
+	if ok && sub != "" && base != "" {
+		return latexVariable(base) + "_{" + sub + "}"
+	}
+	if len([]rune(name)) > 1 {
+		return `\mathrm{` + strings.ReplaceAll(name, "_", `\_`) + "}"
+	}
+	return name
+}
+
+func (s *sym) latex() string {
+	switch s.kind {
+	case symNumber:
+		if s.num.IsInt() {
+			return s.num.Num().String()
+		}
+		sign := ""
+		if s.num.Sign() < 0 {
+			sign = "-"
+		}
+		return fmt.Sprintf(`%s\frac{%s}{%s}`, sign, new(big.Int).Abs(s.num.Num()), s.num.Denom())
+	case symVariable:
+		return latexVariable(s.name)
+	case symSum:
+		var b strings.Builder
+		for i, t := range s.args {
+			switch {
+			case i == 0:
+				b.WriteString(t.latex())
+			case isNegative(t):
+				b.WriteString(" - " + negateTerm(t).latex())
+			default:
+				b.WriteString(" + " + t.latex())
+			}
+		}
+		return b.String()
+	case symProduct:
+		return latexProduct(s)
+	case symPower:
+		base, exp := s.args[0], s.args[1]
+		if exp.isNumber() && exp.num.Sign() < 0 {
+			return latexProduct(s)
+		}
+		if exp.isNumber() && exp.num.Num().IsInt64() && exp.num.Num().Int64() == 1 && exp.num.Denom().IsInt64() {
+			if n := exp.num.Denom().Int64(); n == 2 {
+				return `\sqrt{` + base.latex() + "}"
+			} else {
+				return fmt.Sprintf(`\sqrt[%d]{%s}`, n, base.latex())
+			}
+		}
+		b := base.latex()
+		if base.kind == symSum || base.kind == symProduct || base.kind == symPower || base.kind == symFunction ||
+			base.isNumber() && (base.num.Sign() < 0 || !base.num.IsInt()) {
+			b = `\left(` + b + `\right)`
+		}
+		return b + "^{" + exp.latex() + "}"
+	case symFunction:
+		args := make([]string, len(s.args))
+		for i, arg := range s.args {
+			args[i] = arg.latex()
+		}
+		inner := strings.Join(args, ", ")
+		switch s.name {
+		case "exp":
+			return "e^{" + inner + "}"
+		case "abs":
+			return `\left|` + inner + `\right|`
+		case "floor":
+			return `\left\lfloor ` + inner + ` \right\rfloor`
+		case "ceil":
+			return `\left\lceil ` + inner + ` \right\rceil`
+		}
+		op, ok := latexFunctions[s.name]
+		if !ok {
+			op = `\operatorname{` + s.name + "}"
+		}
+		return op + `\left(` + inner + `\right)`
+	}
+	return "?"
+}
+
+// latexProduct typesets a product as juxtaposed factors, or as \frac when
+// it has a denominator.
+func latexProduct(s *sym) string {
+	negative, coeff, num, den := splitFraction(s)
+	join := func(coeff *big.Int, factors []*sym) string {
+		var parts []string
+		if coeff.Cmp(big.NewInt(1)) != 0 || len(factors) == 0 {
+			parts = append(parts, coeff.String())
+		}
+		for _, f := range factors {
+			text := f.latex()
+			if f.kind == symSum {
+				text = `\left(` + text + `\right)`
+			}
+			// Juxtaposed digits would read as one number.
+			if len(parts) > 0 && text[0] >= '0' && text[0] <= '9' {
+				parts = append(parts, `\cdot`)
+			}
+			parts = append(parts, text)
+		}
+		return strings.Join(parts, " ")
+	}
+	text := join(coeff.Num(), num)
+	if !coeff.IsInt() || len(den) > 0 {
+		text = fmt.Sprintf(`\frac{%s}{%s}`, text, join(coeff.Denom(), den))
+	}
+	if negative {
+		text = "-" + text
+	}
+	return text
+}
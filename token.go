@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Tokens are secrets handed to the client. Nothing in this file may log them,
+// and error messages must never include generated output.
+
+const (
+	defaultTokenLength      = 32
+	defaultPassphraseLength = 6
+	maxTokenLength          = 1024
+	maxPassphraseLength     = 64
+	defaultTokenAlphabet    = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// passphraseWords is the word list for passphrases: short, common, lowercase
+// English words that are easy to type and read aloud.
+//
+//go:embed wordlist.txt
+var wordlistText string
+
+var passphraseWords = strings.Fields(wordlistText)
+
+// GenerateRandomTokenParams defines the parameters for the
+// generate-random-token tool.
+type GenerateRandomTokenParams struct {
+	Format    string  `json:"format,omitempty" jsonschema:"token format: 'hex' (default), 'base64url', 'uuidv4', 'uuidv7', 'passphrase' or 'string' (characters drawn from alphabet)"`
+	Length    int     `json:"length,omitempty" jsonschema:"number of characters for hex, base64url and string (default: 32), or number of words for passphrase (default: 6); not used for UUIDs"`
+	Alphabet  string  `json:"alphabet,omitempty" jsonschema:"distinct characters to draw from for the string format (default: A-Z, a-z and 0-9)"`
+	Separator *string `json:"separator,omitempty" jsonschema:"separator between passphrase words (default: '-')"`
+}
+
+func (p GenerateRandomTokenParams) Validate() error {
+	uuid := p.Format == "uuidv4" || p.Format == "uuidv7"
+	maxLength := maxTokenLength
+	if p.Format == "passphrase" {
+		maxLength = maxPassphraseLength
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Format,
+			validation.In("", "hex", "base64url", "uuidv4", "uuidv7", "passphrase", "string"),
+		),
+		validation.Field(&p.Length,
+			validation.Min(0),
+			validation.Max(maxLength),
+			validation.When(uuid, validation.Empty.Error("is not supported for UUIDs")),
+		),
+		validation.Field(&p.Alphabet,
+			validation.When(p.Format != "string", validation.Empty.Error("is only supported with format string")),
+			validation.By(func(value interface{}) error {
+				if !utf8.ValidString(p.Alphabet) {
+					return errors.New("must be valid UTF-8")
+				}
+				seen := make(map[rune]bool)
+				for _, r := range p.Alphabet {
+					if seen[r] {
+						return fmt.Errorf("contains %q more than once, which would bias the output", r)
+					}
+					seen[r] = true
+				}
+				if p.Alphabet != "" && len(seen) < 2 {
+					return errors.New("must contain at least two characters")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&p.Separator,
+			validation.When(p.Format != "passphrase", validation.Nil.Error("is only supported with format passphrase")),
+		),
+	)
+}
+
+// GenerateRandomTokenResult defines the result for the generate-random-token
+// tool.
+type GenerateRandomTokenResult struct {
+	Token       string  `json:"token" jsonschema:"the generated token"`
+	Format      string  `json:"format" jsonschema:"format of the token"`
+	EntropyBits float64 `json:"entropy_bits" jsonschema:"bits of randomness in the token"`
+}
+
+func handleGenerateRandomToken(ctx context.Context, req *mcp.CallToolRequest, param GenerateRandomTokenParams) (*mcp.CallToolResult, GenerateRandomTokenResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			GenerateRandomTokenResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	format := param.Format
+	if format == "" {
+		format = "hex"
+	}
+	length := param.Length
+	if length == 0 {
+		length = defaultTokenLength
+		if format == "passphrase" {
+			length = defaultPassphraseLength
+		}
+	}
+
+	var (
+		token   string
+		entropy float64
+		err     error
+	)
+	switch format {
+	case "hex":
+		token, err = randomHex(length)
+		entropy = 4 * float64(length)
+	case "base64url":
+		token, err = randomBase64URL(length)
+		entropy = 6 * float64(length)
+	case "uuidv4":
+		token, err = randomUUIDv4()
+		entropy = 122
+	case "uuidv7":
+		token, err = randomUUIDv7(time.Now())
+		entropy = 74
+	case "passphrase":
+		separator := "-"
+		if param.Separator != nil {
+			separator = *param.Separator
+		}
+		token, err = randomPassphrase(length, separator)
+		entropy = float64(length) * math.Log2(float64(len(passphraseWords)))
+	case "string":
+		alphabet := param.Alphabet
+		if alphabet == "" {
+			alphabet = defaultTokenAlphabet
+		}
+		token, err = randomString(length, []rune(alphabet))
+		entropy = float64(length) * math.Log2(float64(utf8.RuneCountInString(alphabet)))
+	}
+	if err != nil {
+		return errorResult(fmt.Sprintf("Generation error: %v", err)),
+			GenerateRandomTokenResult{}, fmt.Errorf("generation error: %v", err)
+	}
+	entropy = math.Round(entropy*100) / 100
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: token}},
+	}, GenerateRandomTokenResult{Token: token, Format: format, EntropyBits: entropy}, nil
+}
+
+// randomHex returns n hexadecimal characters, each carrying 4 random bits.
+func randomHex(n int) (string, error) {
+	buf := make([]byte, (n+1)/2)
+	if _, err := cryptorand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf)[:n], nil
+}
+
+// randomBase64URL returns n unpadded base64url characters, each carrying 6
+// random bits.
+func randomBase64URL(n int) (string, error) {
+	buf := make([]byte, (6*n+7)/8)
+	if _, err := cryptorand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)[:n], nil
+}
+
+// randomUUIDv4 returns a random UUID as specified by RFC 9562, section 5.4.
+func randomUUIDv4() (string, error) {
+	var u [16]byte
+	if _, err := cryptorand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u), nil
+}
+
+// randomUUIDv7 returns a time-ordered UUID as specified by RFC 9562, section
+// 5.7: a 48-bit Unix timestamp in milliseconds followed by random bits.
+func randomUUIDv7(now time.Time) (string, error) {
+	var u [16]byte
+	if _, err := cryptorand.Read(u[6:]); err != nil {
+		return "", err
+	}
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(now.UnixMilli()))
+	copy(u[:6], ts[2:])
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u), nil
+}
+
+func formatUUID(u [16]byte) string {
+	h := hex.EncodeToString(u[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// randomPassphrase joins n words drawn uniformly from passphraseWords.
+func randomPassphrase(n int, separator string) (string, error) {
+	words := make([]string, n)
+	for i := range words {
+		k, err := secureUniform(0, len(passphraseWords)-1)
+		if err != nil {
+			return "", err
+		}
+		words[i] = passphraseWords[k]
+	}
+	return strings.Join(words, separator), nil
+}
+
+// randomString returns n characters drawn uniformly from alphabet.
+func randomString(n int, alphabet []rune) (string, error) {
+	out := make([]rune, n)
+	for i := range out {
+		k, err := secureUniform(0, len(alphabet)-1)
+		if err != nil {
+			return "", err
+		}
+		out[i] = alphabet[k]
+	}
+	return string(out), nil
+}
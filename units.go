@@ -0,0 +1,584 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// dimension holds the exponents of the SI base dimensions in the order of
+// baseUnits: length, mass, time, current, temperature, amount, luminosity.
+type dimension [7]int
+
+var baseUnits = [7]string{"m", "kg", "s", "A", "K", "mol", "cd"}
+
+func (d dimension) add(o dimension, sign int) dimension {
+	for i := range d {
+		d[i] += sign * o[i]
+	}
+	return d
+}
+
+func (d dimension) scale(n int) dimension {
+	for i := range d {
+		d[i] *= n
+	}
+	return d
+}
+
+// siString renders d in SI base units, e.g. "kg·m^2·s^-2".
+func (d dimension) siString() string {
+	var parts []string
+	// Mass reads more naturally first, as in kg·m/s².
+	for _, i := range []int{1, 0, 2, 3, 4, 5, 6} {
+		switch d[i] {
+		case 0:
+		case 1:
+			parts = append(parts, baseUnits[i])
+		default:
+			parts = append(parts, fmt.Sprintf("%s^%d", baseUnits[i], d[i]))
+		}
+	}
+	if len(parts) == 0 {
+		return "1"
+	}
+	return strings.Join(parts, "·")
+}
+
+// name returns a human-readable name for well-known dimensions, falling back
+// to the SI base unit expression.
+func (d dimension) name() string {
+	if n, ok := dimensionNames[d]; ok {
+		return n
+	}
+	return d.siString()
+}
+
+var dimensionNames = map[dimension]string{
+	{}:                      "dimensionless",
+	{1, 0, 0, 0, 0, 0, 0}:   "length",
+	{0, 1, 0, 0, 0, 0, 0}:   "mass",
+	{0, 0, 1, 0, 0, 0, 0}:   "time",
+	{0, 0, 0, 1, 0, 0, 0}:   "electric current",
+	{0, 0, 0, 0, 1, 0, 0}:   "temperature",
+	{0, 0, 0, 0, 0, 1, 0}:   "amount of substance",
+	{0, 0, 0, 0, 0, 0, 1}:   "luminous intensity",
+	{2, 0, 0, 0, 0, 0, 0}:   "area",
+	{3, 0, 0, 0, 0, 0, 0}:   "volume",
+	{1, 0, -1, 0, 0, 0, 0}:  "speed",
+	{1, 0, -2, 0, 0, 0, 0}:  "acceleration",
+	{0, 0, -1, 0, 0, 0, 0}:  "frequency",
+	{1, 1, -2, 0, 0, 0, 0}:  "force",
+	{2, 1, -2, 0, 0, 0, 0}:  "energy",
+	{2, 1, -3, 0, 0, 0, 0}:  "power",
+	{-1, 1, -2, 0, 0, 0, 0}: "pressure",
+	{0, 0, 1, 1, 0, 0, 0}:   "electric charge",
+	{2, 1, -3, -1, 0, 0, 0}: "voltage",
+	{2, 1, -3, -2, 0, 0, 0}: "electrical resistance",
+	{-3, 1, 0, 0, 0, 0, 0}:  "density",
+}
+
+// unitDef describes a unit as a conversion to SI: si = value*factor + offset.
+// Only temperature scales such as degC have a non-zero offset.
+type unitDef struct {
+	name       string
+	factor     float64
+	offset     float64
+	dim        dimension
+	prefixable bool
+}
+
+var (
+	dimLength      = dimension{1, 0, 0, 0, 0, 0, 0}
+	dimMass        = dimension{0, 1, 0, 0, 0, 0, 0}
+	dimTime        = dimension{0, 0, 1, 0, 0, 0, 0}
+	dimCurrent     = dimension{0, 0, 0, 1, 0, 0, 0}
+	dimTemperature = dimension{0, 0, 0, 0, 1, 0, 0}
+	dimAmount      = dimension{0, 0, 0, 0, 0, 1, 0}
+	dimLuminosity  = dimension{0, 0, 0, 0, 0, 0, 1}
+	dimArea        = dimension{2, 0, 0, 0, 0, 0, 0}
+	dimVolume      = dimension{3, 0, 0, 0, 0, 0, 0}
+	dimSpeed       = dimension{1, 0, -1, 0, 0, 0, 0}
+	dimFrequency   = dimension{0, 0, -1, 0, 0, 0, 0}
+	dimForce       = dimension{1, 1, -2, 0, 0, 0, 0}
+	dimEnergy      = dimension{2, 1, -2, 0, 0, 0, 0}
+	dimPower       = dimension{2, 1, -3, 0, 0, 0, 0}
+	dimPressure    = dimension{-1, 1, -2, 0, 0, 0, 0}
+	dimCharge      = dimension{0, 0, 1, 1, 0, 0, 0}
+	dimVoltage     = dimension{2, 1, -3, -1, 0, 0, 0}
+	dimResistance  = dimension{2, 1, -3, -2, 0, 0, 0}
+	dimCapacitance = dimension{-2, -1, 4, 2, 0, 0, 0}
+)
+
+// unitRegistry maps unit symbols to their definitions. Symbols marked
+// prefixable also accept the SI prefixes in siPrefixes (km, mg, kWh, ...).
+var unitRegistry = map[string]unitDef{
+	// Length
+	"m":   {"metre", 1, 0, dimLength, true},
+	"in":  {"inch", 0.0254, 0, dimLength, false},
+	"ft":  {"foot", 0.3048, 0, dimLength, false},
+	"yd":  {"yard", 0.9144, 0, dimLength, false},
+	"mi":  {"mile", 1609.344, 0, dimLength, false},
+	"nmi": {"nautical mile", 1852, 0, dimLength, false},
+	"au":  {"astronomical unit", 149597870700, 0, dimLength, false},
+	"ly":  {"light-year", 9460730472580800, 0, dimLength, false},
+	"Å":   {"ångström", 1e-10, 0, dimLength, false},
+
+	// Mass
+	"g":  {"gram", 1e-3, 0, dimMass, true},
+	"t":  {"tonne", 1000, 0, dimMass, false},
+	"lb": {"pound", 0.45359237, 0, dimMass, false},
+	"oz": {"ounce", 0.028349523125, 0, dimMass, false},
+	"st": {"stone", 6.35029318, 0, dimMass, false},
+
+	// Time
+	"s":   {"second", 1, 0, dimTime, true},
+	"min": {"minute", 60, 0, dimTime, false},
+	"h":   {"hour", 3600, 0, dimTime, false},
+	"d":   {"day", 86400, 0, dimTime, false},
+	"wk":  {"week", 604800, 0, dimTime, false},
+	"yr":  {"Julian year", 31557600, 0, dimTime, false},
+
+	// Other base units
+	"A":   {"ampere", 1, 0, dimCurrent, true},
+	"K":   {"kelvin", 1, 0, dimTemperature, true},
+	"mol": {"mole", 1, 0, dimAmount, true},
+	"cd":  {"candela", 1, 0, dimLuminosity, true},
+
+	// Temperature scales (affine)
+	"degC": {"degree Celsius", 1, 273.15, dimTemperature, false},
+	"degF": {"degree Fahrenheit", 5.0 / 9.0, 273.15 - 32*5.0/9.0, dimTemperature, false},
+	"degR": {"degree Rankine", 5.0 / 9.0, 0, dimTemperature, false},
+
+	// Area and volume
+	"ha":   {"hectare", 1e4, 0, dimArea, false},
+	"acre": {"acre", 4046.8564224, 0, dimArea, false},
+	"L":    {"litre", 1e-3, 0, dimVolume, true},
+	"gal":  {"US gallon", 3.785411784e-3, 0, dimVolume, false},
+	"qt":   {"US quart", 9.46352946e-4, 0, dimVolume, false},
+	"pt":   {"US pint", 4.73176473e-4, 0, dimVolume, false},
+	"cup":  {"US cup", 2.365882365e-4, 0, dimVolume, false},
+	"floz": {"US fluid ounce", 2.95735295625e-5, 0, dimVolume, false},
+
+	// Speed
+	"mph": {"mile per hour", 0.44704, 0, dimSpeed, false},
+	"kn":  {"knot", 1852.0 / 3600.0, 0, dimSpeed, false},
+
+	// Derived SI units and their common non-SI relatives
+	"Hz":   {"hertz", 1, 0, dimFrequency, true},
+	"N":    {"newton", 1, 0, dimForce, true},
+	"lbf":  {"pound-force", 4.4482216152605, 0, dimForce, false},
+	"dyn":  {"dyne", 1e-5, 0, dimForce, false},
+	"J":    {"joule", 1, 0, dimEnergy, true},
+	"Wh":   {"watt-hour", 3600, 0, dimEnergy, true},
+	"cal":  {"thermochemical calorie", 4.184, 0, dimEnergy, true},
+	"eV":   {"electronvolt", 1.602176634e-19, 0, dimEnergy, true},
+	"BTU":  {"British thermal unit", 1055.05585262, 0, dimEnergy, false},
+	"erg":  {"erg", 1e-7, 0, dimEnergy, false},
+	"W":    {"watt", 1, 0, dimPower, true},
+	"hp":   {"mechanical horsepower", 745.69987158227022, 0, dimPower, false},
+	"Pa":   {"pascal", 1, 0, dimPressure, true},
+	"bar":  {"bar", 1e5, 0, dimPressure, true},
+	"atm":  {"standard atmosphere", 101325, 0, dimPressure, false},
+	"psi":  {"pound per square inch", 6894.757293168361, 0, dimPressure, false},
+	"mmHg": {"millimetre of mercury", 133.322387415, 0, dimPressure, false},
+	"Torr": {"torr", 101325.0 / 760.0, 0, dimPressure, false},
+	"C":    {"coulomb", 1, 0, dimCharge, true},
+	"V":    {"volt", 1, 0, dimVoltage, true},
+	"ohm":  {"ohm", 1, 0, dimResistance, true},
+	"F":    {"farad", 1, 0, dimCapacitance, true},
+
+	// Angles are dimensionless
+	"rad": {"radian", 1, 0, dimension{}, false},
+	"deg": {"degree of arc", math.Pi / 180, 0, dimension{}, false},
+	"%":   {"percent", 0.01, 0, dimension{}, false},
+}
+
+// unitAliases maps alternative spellings to registry symbols.
+var unitAliases = map[string]string{
+	"°C": "degC", "℃": "degC", "°F": "degF", "℉": "degF", "°R": "degR",
+	"Ω": "ohm", "°": "deg", "l": "L", "hr": "h", "sec": "s", "kph": "km/h",
+	"mile": "mi", "inch": "in", "foot": "ft", "feet": "ft", "knot": "kn",
+	"kmh": "km/h", "tonne": "t", "lbs": "lb",
+}
+
+var siPrefixes = map[string]float64{
+	"Y": 1e24, "Z": 1e21, "E": 1e18, "P": 1e15, "T": 1e12, "G": 1e9, "M": 1e6,
+	"k": 1e3, "h": 1e2, "da": 1e1, "d": 1e-1, "c": 1e-2, "m": 1e-3,
+	"µ": 1e-6, "μ": 1e-6, "u": 1e-6, "n": 1e-9, "p": 1e-12, "f": 1e-15,
+	"a": 1e-18, "z": 1e-21, "y": 1e-24,
+}
+
+// lookupUnit resolves a single unit symbol, trying exact matches and aliases
+// before splitting off an SI prefix.
+func lookupUnit(symbol string) (unitDef, bool) {
+	if u, ok := unitRegistry[symbol]; ok {
+		return u, true
+	}
+	for prefix, factor := range siPrefixes {
+		if !strings.HasPrefix(symbol, prefix) {
+			continue
+		}
+		if u, ok := unitRegistry[symbol[len(prefix):]]; ok && u.prefixable {
+			u.factor *= factor
+			return u, true
+		}
+	}
+	return unitDef{}, false
+}
+
+// parsedUnit is the result of parsing a unit expression.
+type parsedUnit struct {
+	factor float64
+	offset float64
+	dim    dimension
+	affine bool
+}
+
+var superscripts = map[rune]rune{
+	'⁰': '0', '¹': '1', '²': '2', '³': '3', '⁴': '4',
+	'⁵': '5', '⁶': '6', '⁷': '7', '⁸': '8', '⁹': '9', '⁻': '-',
+}
+
+// parseUnit parses a unit expression such as "km/h", "kg·m/s²", "W/(m*K)"
+// or "m^3". Factors are joined by "*", "·" or spaces; "/" divides by the
+// factor that follows it, so "J/kg/K" is J·kg⁻¹·K⁻¹.
+func parseUnit(expr string) (parsedUnit, error) {
+	expr = strings.TrimSpace(expr)
+	if alias, ok := unitAliases[expr]; ok {
+		expr = alias
+	}
+	if expr == "" {
+		return parsedUnit{}, errors.New("unit is empty")
+	}
+	p := &unitParser{input: []rune(expr)}
+	u, err := p.parseProduct()
+	if err != nil {
+		return parsedUnit{}, err
+	}
+	if p.pos < len(p.input) {
+		return parsedUnit{}, fmt.Errorf("unexpected %q at position %d in unit %q", p.input[p.pos], p.pos+1, expr)
+	}
+	if u.affine && p.factors > 1 {
+		return parsedUnit{}, fmt.Errorf("temperature scale in %q can only be converted on its own; use K for temperature differences", expr)
+	}
+	return u, nil
+}
+
+type unitParser struct {
+	input   []rune
+	pos     int
+	factors int
+}
+
+func (p *unitParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *unitParser) parseProduct() (parsedUnit, error) {
+	result := parsedUnit{factor: 1}
+	sign := 1
+	for {
+		p.skipSpaces()
+		factor, err := p.parseFactor()
+		if err != nil {
+			return parsedUnit{}, err
+		}
+		result.factor *= math.Pow(factor.factor, float64(sign))
+		result.dim = result.dim.add(factor.dim, sign)
+		if factor.affine {
+			result.affine, result.offset = true, factor.offset
+		}
+
+		p.skipSpaces()
+		if p.pos >= len(p.input) || p.input[p.pos] == ')' {
+			return result, nil
+		}
+		switch p.input[p.pos] {
+		case '/':
+			sign = -1
+			p.pos++
+		case '*', '·', '⋅':
+			sign = 1
+			p.pos++
+		default:
+			sign = 1
+		}
+	}
+}
+
+func (p *unitParser) parseFactor() (parsedUnit, error) {
+	var u parsedUnit
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		p.pos++
+		inner, err := p.parseProduct()
+		if err != nil {
+			return parsedUnit{}, err
+		}
+		if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return parsedUnit{}, fmt.Errorf("missing ')' at position %d", p.pos+1)
+		}
+		p.pos++
+		u = inner
+	} else {
+		start := p.pos
+		for p.pos < len(p.input) {
+			r := p.input[p.pos]
+			if !unicode.IsLetter(r) && r != '°' && r != '%' && r != 'Ω' && r != '℃' && r != '℉' {
+				break
+			}
+			p.pos++
+		}
+		if start == p.pos {
+			if p.pos < len(p.input) {
+				return parsedUnit{}, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos+1)
+			}
+			return parsedUnit{}, errors.New("unit expression ends unexpectedly")
+		}
+		symbol := string(p.input[start:p.pos])
+		if alias, ok := unitAliases[symbol]; ok && !strings.Contains(alias, "/") {
+			symbol = alias
+		}
+		def, ok := lookupUnit(symbol)
+		if !ok {
+			return parsedUnit{}, fmt.Errorf("unknown unit %q", symbol)
+		}
+		u = parsedUnit{factor: def.factor, offset: def.offset, dim: def.dim, affine: def.offset != 0}
+	}
+	p.factors++
+
+	exponent, err := p.parseExponent()
+	if err != nil {
+		return parsedUnit{}, err
+	}
+	if exponent != 1 {
+		if u.affine {
+			return parsedUnit{}, errors.New("temperature scales cannot be raised to a power")
+		}
+		u.factor = math.Pow(u.factor, float64(exponent))
+		u.dim = u.dim.scale(exponent)
+	}
+	return u, nil
+}
+
+// parseExponent reads an optional exponent written as "^2", "²" or "2".
+func (p *unitParser) parseExponent() (int, error) {
+	var digits []rune
+	if p.pos < len(p.input) && (p.input[p.pos] == '^' || unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '-') {
+		if p.input[p.pos] == '^' {
+			p.pos++
+		}
+		for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || (p.input[p.pos] == '-' && len(digits) == 0)) {
+			digits = append(digits, p.input[p.pos])
+			p.pos++
+		}
+		if len(digits) == 0 {
+			return 0, fmt.Errorf("missing exponent at position %d", p.pos+1)
+		}
+	} else {
+		for p.pos < len(p.input) {
+			d, ok := superscripts[p.input[p.pos]]
+			if !ok {
+				break
+			}
+			digits = append(digits, d)
+			p.pos++
+		}
+		if len(digits) == 0 {
+			return 1, nil
+		}
+	}
+	n, err := strconv.Atoi(string(digits))
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid exponent %q", string(digits))
+	}
+	return n, nil
+}
+
+// ConvertUnitsParams defines the parameters for the convert-units tool.
+type ConvertUnitsParams struct {
+	Value interface{} `json:"value" jsonschema:"the quantity to convert, as a number or a string such as \"1,500\" or \"2½\""`
+	From  string      `json:"from" jsonschema:"unit of the value, e.g. mph, kWh, degC or kg·m/s²"`
+	To    string      `json:"to" jsonschema:"unit to convert to, with the same dimension as from"`
+}
+
+func (p ConvertUnitsParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Value, validation.NotNil.Error("is required"), floatNumber),
+		validation.Field(&p.From, validation.Required, validation.Length(1, 100)),
+		validation.Field(&p.To, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// ConvertUnitsResult defines the result for the convert-units tool.
+type ConvertUnitsResult struct {
+	Result    float64 `json:"result" jsonschema:"the converted value"`
+	From      string  `json:"from" jsonschema:"source unit"`
+	To        string  `json:"to" jsonschema:"target unit"`
+	Dimension string  `json:"dimension" jsonschema:"physical dimension of both units"`
+	SIUnit    string  `json:"si_unit" jsonschema:"the dimension expressed in SI base units"`
+}
+
+func handleConvertUnits(ctx context.Context, req *mcp.CallToolRequest, param ConvertUnitsParams) (*mcp.CallToolResult, ConvertUnitsResult, error) {
+	if err := param.Validate(); err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: %v", err)),
+			ConvertUnitsResult{}, fmt.Errorf("invalid parameters: %v", err)
+	}
+
+	from, err := parseUnit(param.From)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: from: %v", err)),
+			ConvertUnitsResult{}, fmt.Errorf("invalid parameters: from: %v", err)
+	}
+	to, err := parseUnit(param.To)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Invalid parameters: to: %v", err)),
+			ConvertUnitsResult{}, fmt.Errorf("invalid parameters: to: %v", err)
+	}
+	if from.dim != to.dim {
+		msg := fmt.Sprintf("incompatible dimensions: %s is %s but %s is %s", param.From, from.dim.name(), param.To, to.dim.name())
+		return errorResult(fmt.Sprintf("Invalid parameters: %s", msg)),
+			ConvertUnitsResult{}, fmt.Errorf("invalid parameters: %s", msg)
+	}
+
+	value, _ := floatValue(param.Value)
+	si := value*from.factor + from.offset
+	// degC and degF read a temperature, not a difference, so it cannot lie
+	// below absolute zero. Between K and degR the value may be either. A
+	// nanokelvin of slack absorbs the rounding of the offsets, so that
+	// -459.67 degF is still 0 K.
+	if (from.affine || to.affine) && si < -1e-9 {
+		msg := fmt.Sprintf("value: %g %s is below absolute zero (%.6g K)", value, param.From, si)
+		return errorResult(fmt.Sprintf("Invalid parameters: %s", msg)),
+			ConvertUnitsResult{}, fmt.Errorf("invalid parameters: %s", msg)
+	}
+	// Chained factors such as 5/9 leave residues like 211.99999999999991 for
+	// 100 degC in degF; 15 significant digits are all float64 can promise.
+	result := roundSignificant((si-to.offset)/to.factor, 15)
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return errorResult("Calculation error: result is out of the representable range"),
+			ConvertUnitsResult{}, errors.New("calculation error: result is out of the representable range")
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("%g %s = %g %s", value, param.From, result, param.To)}},
+	}, ConvertUnitsResult{
+		Result:    result,
+		From:      param.From,
+		To:        param.To,
+		Dimension: from.dim.name(),
+		SIUnit:    from.dim.siString(),
+	}, nil
+}
+
+// roundSignificant rounds v to the given number of significant digits.
+func roundSignificant(v float64, digits int) float64 {
+	if v == 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return v
+	}
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', digits, 64), 64)
+	if err != nil {
+		return v
+	}
+	return rounded
+}
+
+// unitInfo describes a registered unit in the units:// resource.
+type unitInfo struct {
+	Symbol     string  `json:"symbol"`
+	Name       string  `json:"name"`
+	Dimension  string  `json:"dimension"`
+	SIUnit     string  `json:"si_unit"`
+	Factor     float64 `json:"factor"`
+	Offset     float64 `json:"offset,omitempty"`
+	Prefixable bool    `json:"prefixable"`
+}
+
+func newUnitInfo(symbol string, def unitDef) unitInfo {
+	return unitInfo{
+		Symbol:     symbol,
+		Name:       def.name,
+		Dimension:  def.dim.name(),
+		SIUnit:     def.dim.siString(),
+		Factor:     def.factor,
+		Offset:     def.offset,
+		Prefixable: def.prefixable,
+	}
+}
+
+func handleUnits(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	if uri == "units://registry" {
+		units := make([]unitInfo, 0, len(unitRegistry))
+		for symbol, def := range unitRegistry {
+			units = append(units, newUnitInfo(symbol, def))
+		}
+		sort.Slice(units, func(i, j int) bool {
+			if units[i].Dimension != units[j].Dimension {
+				return units[i].Dimension < units[j].Dimension
+			}
+			return units[i].Symbol < units[j].Symbol
+		})
+		data, err := json.MarshalIndent(map[string]any{
+			"units":    units,
+			"prefixes": siPrefixes,
+			"aliases":  unitAliases,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{
+				{URI: uri, Text: string(data), MIMEType: "application/json"},
+			},
+		}, nil
+	}
+
+	// Describe a single unit expression from a URI like "units://registry/km/h".
+	if expr, ok := strings.CutPrefix(uri, "units://registry/"); ok && expr != "" {
+		if def, ok := unitRegistry[expr]; ok {
+			data, err := json.MarshalIndent(newUnitInfo(expr, def), "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			return &mcp.ReadResourceResult{
+				Contents: []*mcp.ResourceContents{
+					{URI: uri, Text: string(data), MIMEType: "application/json"},
+				},
+			}, nil
+		}
+		u, err := parseUnit(expr)
+		if err != nil {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		data, err := json.MarshalIndent(unitInfo{
+			Symbol:    expr,
+			Name:      expr,
+			Dimension: u.dim.name(),
+			SIUnit:    u.dim.siString(),
+			Factor:    u.factor,
+			Offset:    u.offset,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{
+				{URI: uri, Text: string(data), MIMEType: "application/json"},
+			},
+		}, nil
+	}
+
+	return nil, mcp.ResourceNotFoundError(uri)
+}